@@ -73,13 +73,15 @@ func (m *MockSubscriber) EXPECT() *MockSubscriberMockRecorder {
 }
 
 // Subscribe mocks base method
-func (m *MockSubscriber) Subscribe(streamName string, h ...stream.EventHandler) {
+func (m *MockSubscriber) Subscribe(streamName string, h ...stream.EventHandler) stream.Subscription {
 	m.ctrl.T.Helper()
 	varargs := []interface{}{streamName}
 	for _, a := range h {
 		varargs = append(varargs, a)
 	}
-	m.ctrl.Call(m, "Subscribe", varargs...)
+	ret := m.ctrl.Call(m, "Subscribe", varargs...)
+	ret0, _ := ret[0].(stream.Subscription)
+	return ret0
 }
 
 // Subscribe indicates an expected call of Subscribe
@@ -89,6 +91,41 @@ func (mr *MockSubscriberMockRecorder) Subscribe(streamName interface{}, h ...int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockSubscriber)(nil).Subscribe), varargs...)
 }
 
+// MockSubscription is a mock of Subscription interface
+type MockSubscription struct {
+	ctrl     *gomock.Controller
+	recorder *MockSubscriptionMockRecorder
+}
+
+// MockSubscriptionMockRecorder is the mock recorder for MockSubscription
+type MockSubscriptionMockRecorder struct {
+	mock *MockSubscription
+}
+
+// NewMockSubscription creates a new mock instance
+func NewMockSubscription(ctrl *gomock.Controller) *MockSubscription {
+	mock := &MockSubscription{ctrl: ctrl}
+	mock.recorder = &MockSubscriptionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockSubscription) EXPECT() *MockSubscriptionMockRecorder {
+	return m.recorder
+}
+
+// Unsubscribe mocks base method
+func (m *MockSubscription) Unsubscribe() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Unsubscribe")
+}
+
+// Unsubscribe indicates an expected call of Unsubscribe
+func (mr *MockSubscriptionMockRecorder) Unsubscribe() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unsubscribe", reflect.TypeOf((*MockSubscription)(nil).Unsubscribe))
+}
+
 // MockEventHandler is a mock of EventHandler interface
 type MockEventHandler struct {
 	ctrl     *gomock.Controller
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+func TestPolicy_Check(t *testing.T) {
+	policy := NewPolicy().
+		Allow("users", Any, Authenticated()).
+		Allow("users", "delete", RequireRole("admin")).
+		Allow("stats", "ping", AllowAll())
+	ctx := context.Background()
+	john := &Principal{ID: "john"}
+	admin := &Principal{ID: "root", Roles: []string{"admin"}}
+
+	rename := command.New("rename", "users", uuid.New(), nil)
+	del := command.New("delete", "users", uuid.New(), nil)
+	ping := command.New("ping", "stats", uuid.New(), nil)
+	reset := command.New("reset", "stats", uuid.New(), nil)
+
+	assert.True(t, policy.Check(ctx, john, rename))
+	assert.False(t, policy.Check(ctx, nil, rename))
+	assert.False(t, policy.Check(ctx, john, del))
+	assert.True(t, policy.Check(ctx, admin, del))
+	assert.True(t, policy.Check(ctx, nil, ping))
+	assert.False(t, policy.Check(ctx, admin, reset))
+	assert.False(t, policy.Check(ctx, admin, command.New("create", "orders", uuid.New(), nil)))
+}
+
+func TestExtractors(t *testing.T) {
+	ctx := context.Background()
+	bearer := BearerToken(func(ctx context.Context, token string) (*Principal, error) {
+		if token != "secret" {
+			return nil, ErrInvalidCredentials
+		}
+		return &Principal{ID: "john"}, nil
+	})
+	extract := FirstOf(bearer, PeerCertificate(CommonName))
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer secret")
+	p, err := extract(ctx, Request{Headers: header})
+	assert.NoError(t, err)
+	assert.Equal(t, "john", p.ID)
+
+	p, err = extract(ctx, Request{Headers: MD{"authorization": {"bearer secret"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, "john", p.ID)
+
+	header.Set("Authorization", "Bearer invalid")
+	_, err = extract(ctx, Request{Headers: header})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}}
+	p, err = extract(ctx, Request{
+		Headers: http.Header{},
+		TLS:     &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "billing", p.ID)
+
+	_, err = extract(ctx, Request{Headers: http.Header{}})
+	assert.ErrorIs(t, err, ErrNoCredentials)
+
+	p, err = Optional(extract)(ctx, Request{})
+	assert.NoError(t, err)
+	assert.Nil(t, p)
+}
+
+func TestCommandSinkerAuthorization(t *testing.T) {
+	policy := NewPolicy().Allow("users", "rename", RequireRole("admin"))
+	var called bool
+	sinker := NewCommandSinkerAuthorization(policy)(commandSinkerFunc(
+		func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			called = true
+			return cmd.ReplyOk(1), nil
+		}))
+	cmd := command.New("rename", "users", uuid.New(), nil)
+
+	ctx := NewContext(context.Background(), &Principal{ID: "john"})
+	reply, err := sinker.CommandSink(ctx, cmd)
+	assert.NoError(t, err)
+	assert.False(t, called)
+	assert.Equal(t, &command.UnauthorizedError{Principal: "john", StreamName: "users", Command: "rename"}, reply.Err())
+
+	ctx = NewContext(context.Background(), &Principal{ID: "root", Roles: []string{"admin"}})
+	reply, err = sinker.CommandSink(ctx, cmd)
+	assert.NoError(t, err)
+	assert.True(t, called)
+	assert.NoError(t, reply.Err())
+}
+
+func TestOwnerGuard(t *testing.T) {
+	guard := OwnerGuard(func(s *stream.Stream) string {
+		return s.State().(*ownedState).Owner
+	})
+	s := stream.New("users", uuid.New(), &ownedState{Owner: "john"})
+	cmd := command.New("rename", "users", s.ID(), nil)
+
+	assert.NoError(t, guard(NewContext(context.Background(), &Principal{ID: "john"}), s, cmd))
+	assert.True(t, command.IsUnauthorized(guard(NewContext(context.Background(), &Principal{ID: "bob"}), s, cmd)))
+	assert.True(t, command.IsUnauthorized(guard(context.Background(), s, cmd)))
+}
+
+type commandSinkerFunc func(ctx context.Context, cmd *command.Command) (*command.Reply, error)
+
+func (fn commandSinkerFunc) CommandSink(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+	return fn(ctx, cmd)
+}
+
+type ownedState struct {
+	Owner string
+}
+
+func (s *ownedState) Mutate(_ *event.Event)          {}
+func (s *ownedState) MarshalBinary() ([]byte, error) { return nil, nil }
+func (s *ownedState) UnmarshalBinary(_ []byte) error { return nil }
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"strings"
+)
+
+// Extractor authenticates the request and returns its principal.
+// ErrNoCredentials means the request has no credentials of the kind.
+type Extractor func(ctx context.Context, r Request) (*Principal, error)
+
+// BearerToken extracts the token from the Authorization header
+// and verifies it with the given func.
+func BearerToken(verify func(ctx context.Context, token string) (*Principal, error)) Extractor {
+	return func(ctx context.Context, r Request) (*Principal, error) {
+		header := r.header("Authorization")
+		if len(header) == 0 {
+			header = r.header("authorization")
+		}
+		if len(header) == 0 {
+			return nil, ErrNoCredentials
+		}
+		const prefix = "bearer "
+		if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+			return nil, ErrInvalidCredentials
+		}
+		return verify(ctx, strings.TrimSpace(header[len(prefix):]))
+	}
+}
+
+// PeerCertificate maps the verified client certificate of the mTLS connection
+// to the principal.
+func PeerCertificate(fn func(cert *x509.Certificate) (*Principal, error)) Extractor {
+	return func(ctx context.Context, r Request) (*Principal, error) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			return nil, ErrNoCredentials
+		}
+		return fn(r.TLS.VerifiedChains[0][0])
+	}
+}
+
+// CommonName uses the subject common name of the certificate as the principal ID.
+func CommonName(cert *x509.Certificate) (*Principal, error) {
+	if len(cert.Subject.CommonName) == 0 {
+		return nil, ErrInvalidCredentials
+	}
+	return &Principal{ID: cert.Subject.CommonName}, nil
+}
+
+// FirstOf tries the extractors in order until one finds the credentials.
+func FirstOf(extractors ...Extractor) Extractor {
+	return func(ctx context.Context, r Request) (*Principal, error) {
+		for _, extract := range extractors {
+			p, err := extract(ctx, r)
+			if errors.Is(err, ErrNoCredentials) {
+				continue
+			}
+			return p, err
+		}
+		return nil, ErrNoCredentials
+	}
+}
+
+// Optional lets the requests without credentials pass anonymously.
+func Optional(extract Extractor) Extractor {
+	return func(ctx context.Context, r Request) (*Principal, error) {
+		p, err := extract(ctx, r)
+		if errors.Is(err, ErrNoCredentials) {
+			return nil, nil
+		}
+		return p, err
+	}
+}
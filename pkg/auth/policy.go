@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+// Any matches any command name of the stream.
+const Any = "*"
+
+// ReasonUnauthenticated is the reason of the replies to the commands
+// whose sender failed the authentication.
+const ReasonUnauthenticated = "authentication failed"
+
+// Rule reports whether the principal may send the command.
+// The principal is nil for the anonymous sender.
+type Rule func(ctx context.Context, p *Principal, cmd *command.Command) bool
+
+func AllowAll() Rule {
+	return func(context.Context, *Principal, *command.Command) bool {
+		return true
+	}
+}
+
+func Authenticated() Rule {
+	return func(_ context.Context, p *Principal, _ *command.Command) bool {
+		return p != nil
+	}
+}
+
+func RequireRole(roles ...string) Rule {
+	return func(_ context.Context, p *Principal, _ *command.Command) bool {
+		for _, role := range roles {
+			if p.HasRole(role) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Policy holds the rules per stream and command name.
+// The commands without a rule are denied.
+type Policy struct {
+	rules map[string]map[string][]Rule
+}
+
+func NewPolicy() *Policy {
+	return &Policy{rules: make(map[string]map[string][]Rule)}
+}
+
+// Allow adds the rules of the command. All of them must pass.
+// Use Any as the command name to apply the rules to the whole stream.
+func (p *Policy) Allow(streamName, commandName string, rules ...Rule) *Policy {
+	commands, ok := p.rules[streamName]
+	if !ok {
+		commands = make(map[string][]Rule)
+		p.rules[streamName] = commands
+	}
+	commands[commandName] = append(commands[commandName], rules...)
+	return p
+}
+
+func (p *Policy) Check(ctx context.Context, principal *Principal, cmd *command.Command) bool {
+	commands, ok := p.rules[cmd.StreamName()]
+	if !ok {
+		return false
+	}
+	rules, ok := commands[cmd.Name()]
+	if !ok {
+		rules, ok = commands[Any]
+	}
+	if !ok {
+		return false
+	}
+	for _, rule := range rules {
+		if !rule(ctx, principal, cmd) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewCommandSinkerAuthorization checks the principal from the context against
+// the policy. The denied commands are replied with command.UnauthorizedError.
+func NewCommandSinkerAuthorization(policy *Policy) stream.CommandSinkerInterceptor {
+	return func(sinker stream.CommandSinker) stream.CommandSinker {
+		return commandSinkAuthorization{
+			next:   sinker,
+			policy: policy,
+		}
+	}
+}
+
+type commandSinkAuthorization struct {
+	next   stream.CommandSinker
+	policy *Policy
+}
+
+func (a commandSinkAuthorization) CommandSink(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+	principal, _ := FromContext(ctx)
+	if !a.policy.Check(ctx, principal, cmd) {
+		return cmd.ReplyErr(Unauthorized(principal, cmd, "")), nil
+	}
+	return a.next.CommandSink(ctx, cmd)
+}
+
+func Unauthorized(p *Principal, cmd *command.Command, reason string) *command.UnauthorizedError {
+	err := &command.UnauthorizedError{
+		StreamName: cmd.StreamName(),
+		Command:    cmd.Name(),
+		Reason:     reason,
+	}
+	if p != nil {
+		err.Principal = p.ID
+	}
+	return err
+}
+
+// OwnerGuard denies the commands to the loaded stream
+// unless the principal is its owner.
+func OwnerGuard(owner func(s *stream.Stream) string) stream.StreamGuard {
+	return func(ctx context.Context, s *stream.Stream, cmd *command.Command) error {
+		principal, ok := FromContext(ctx)
+		if !ok || principal.ID != owner(s) {
+			return Unauthorized(principal, cmd, "not the owner of the stream")
+		}
+		return nil
+	}
+}
+
+// ReplyUnauthorized replies to the command whose sender failed the authentication.
+// The reason is generic, the extraction error may disclose the details
+// of the credentials, so it is passed to the error handler of the server.
+func ReplyUnauthorized(cmd *command.Command) *command.Reply {
+	return cmd.ReplyErr(Unauthorized(nil, cmd, ReasonUnauthenticated))
+}
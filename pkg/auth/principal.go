@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+)
+
+var (
+	ErrNoCredentials      = errors.New("auth: no credentials")
+	ErrInvalidCredentials = errors.New("auth: invalid credentials")
+)
+
+// Principal is the authenticated sender of the command.
+type Principal struct {
+	ID     string
+	Roles  []string
+	Claims map[string]string
+}
+
+func (p *Principal) HasRole(role string) bool {
+	if p == nil {
+		return false
+	}
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// Headers is the transport metadata of the request,
+// e.g. http.Header, nats.Header or MD.
+type Headers interface {
+	Get(key string) string
+}
+
+// MD adapts the gRPC metadata to Headers.
+type MD map[string][]string
+
+func (md MD) Get(key string) string {
+	if vals := md[key]; len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// Request carries the transport data the principal is extracted from.
+type Request struct {
+	Headers Headers
+	TLS     *tls.ConnectionState
+}
+
+func (r Request) header(key string) string {
+	if r.Headers == nil {
+		return ""
+	}
+	return r.Headers.Get(key)
+}
@@ -0,0 +1,135 @@
+package catalog
+
+import "encoding/json"
+
+// AsyncAPIVersion is the version of the specification of the generated document.
+const AsyncAPIVersion = "2.6.0"
+
+type asyncAPI struct {
+	AsyncAPI           string                     `json:"asyncapi"`
+	Info               asyncAPIInfo               `json:"info"`
+	DefaultContentType string                     `json:"defaultContentType"`
+	Channels           map[string]asyncAPIChannel `json:"channels"`
+	Components         asyncAPIComponents         `json:"components"`
+}
+
+type asyncAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type asyncAPIChannel struct {
+	Description string             `json:"description,omitempty"`
+	Publish     *asyncAPIOperation `json:"publish,omitempty"`
+	Subscribe   *asyncAPIOperation `json:"subscribe,omitempty"`
+}
+
+type asyncAPIOperation struct {
+	OperationID string          `json:"operationId"`
+	Message     asyncAPIMessage `json:"message"`
+}
+
+type asyncAPIMessage struct {
+	OneOf []asyncAPIRef `json:"oneOf"`
+}
+
+type asyncAPIRef struct {
+	Ref string `json:"$ref"`
+}
+
+type asyncAPIComponents struct {
+	Messages map[string]asyncAPIMessageObject `json:"messages"`
+}
+
+type asyncAPIMessageObject struct {
+	Name         string          `json:"name"`
+	Title        string          `json:"title"`
+	Payload      asyncAPIPayload `json:"payload"`
+	Kind         string          `json:"x-gulfstream-kind"`
+	Service      string          `json:"x-gulfstream-service,omitempty"`
+	CreateStream bool            `json:"x-gulfstream-create-stream,omitempty"`
+	DropStream   bool            `json:"x-gulfstream-drop-stream,omitempty"`
+	Emits        []string        `json:"x-gulfstream-emits,omitempty"`
+	Result       *asyncAPIResult `json:"x-gulfstream-result,omitempty"`
+	Producers    []string        `json:"x-gulfstream-producers,omitempty"`
+	Consumers    []string        `json:"x-gulfstream-consumers,omitempty"`
+}
+
+type asyncAPIPayload struct {
+	Type   string `json:"type"`
+	GoType string `json:"x-go-type,omitempty"`
+}
+
+type asyncAPIResult struct {
+	GoType string `json:"x-go-type"`
+}
+
+// AsyncAPI renders the catalog as AsyncAPI document in JSON.
+// Each stream has the commands channel published by the clients
+// and the events channel subscribed by the handlers.
+func (c *Catalog) AsyncAPI() ([]byte, error) {
+	doc := asyncAPI{
+		AsyncAPI:           AsyncAPIVersion,
+		Info:               asyncAPIInfo{Title: c.title, Version: c.version},
+		DefaultContentType: "application/octet-stream",
+		Channels:           make(map[string]asyncAPIChannel),
+		Components: asyncAPIComponents{
+			Messages: make(map[string]asyncAPIMessageObject),
+		},
+	}
+	for _, s := range c.model().sortedStreams() {
+		if commands := s.sortedCommands(); len(commands) > 0 {
+			op := &asyncAPIOperation{OperationID: s.name + ".commands"}
+			for _, cmd := range commands {
+				id := qualify(s.name, cmd.name)
+				op.Message.OneOf = append(op.Message.OneOf, messageRef(id))
+				msg := asyncAPIMessageObject{
+					Name:         cmd.name,
+					Title:        id,
+					Payload:      payload(c.commandType(cmd.name)),
+					Kind:         "command",
+					Service:      cmd.service,
+					CreateStream: cmd.createStream,
+					DropStream:   cmd.dropStream,
+					Emits:        cmd.emits,
+				}
+				if result := c.resultType(cmd.name); len(result) > 0 {
+					msg.Result = &asyncAPIResult{GoType: result}
+				}
+				doc.Components.Messages[id] = msg
+			}
+			doc.Channels[s.name+".commands"] = asyncAPIChannel{
+				Description: "Commands of the " + s.name + " stream.",
+				Publish:     op,
+			}
+		}
+		if events := s.sortedEvents(); len(events) > 0 {
+			op := &asyncAPIOperation{OperationID: s.name + ".events"}
+			for _, e := range events {
+				id := qualify(s.name, e.name)
+				op.Message.OneOf = append(op.Message.OneOf, messageRef(id))
+				doc.Components.Messages[id] = asyncAPIMessageObject{
+					Name:      e.name,
+					Title:     id,
+					Payload:   payload(c.eventType(e.name)),
+					Kind:      "event",
+					Producers: sortedKeys(e.producers),
+					Consumers: s.consumers(e),
+				}
+			}
+			doc.Channels[s.name+".events"] = asyncAPIChannel{
+				Description: "Events of the " + s.name + " stream.",
+				Subscribe:   op,
+			}
+		}
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func messageRef(id string) asyncAPIRef {
+	return asyncAPIRef{Ref: "#/components/messages/" + id}
+}
+
+func payload(goType string) asyncAPIPayload {
+	return asyncAPIPayload{Type: "object", GoType: goType}
+}
@@ -0,0 +1,156 @@
+package catalog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+	"github.com/go-gulfstream/gulfstream/pkg/eventbus"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+// MutatorDescriber is implemented by stream.Mutator.
+type MutatorDescriber interface {
+	Describe() stream.MutatorInfo
+}
+
+// SubscriberDescriber is implemented by the event buses,
+// e.g. eventbus.Channel and the kafka subscriber.
+type SubscriberDescriber interface {
+	Describe() []eventbus.SubscriptionInfo
+}
+
+// Service is the registration metadata of the service.
+type Service struct {
+	Name          string
+	Mutators      []stream.MutatorInfo
+	Subscriptions []eventbus.SubscriptionInfo
+}
+
+type ServiceOption func(*Service)
+
+func WithMutator(m MutatorDescriber) ServiceOption {
+	return func(s *Service) {
+		s.Mutators = append(s.Mutators, m.Describe())
+	}
+}
+
+func WithSubscriber(sub SubscriberDescriber) ServiceOption {
+	return func(s *Service) {
+		s.Subscriptions = append(s.Subscriptions, sub.Describe()...)
+	}
+}
+
+// WithProjection adds the projection of the stream
+// that is not subscribed through a described subscriber.
+func WithProjection(streamName string, p stream.EventNamer) ServiceOption {
+	return func(s *Service) {
+		s.Subscriptions = append(s.Subscriptions, eventbus.SubscriptionInfo{
+			StreamName: streamName,
+			Events:     p.EventNames(),
+		})
+	}
+}
+
+// Catalog collects the commands, events and handlers of the services
+// and renders them as AsyncAPI document or Graphviz graph.
+type Catalog struct {
+	title        string
+	version      string
+	services     []Service
+	eventCodec   *event.Codec
+	commandCodec *command.Codec
+}
+
+type Option func(*Catalog)
+
+// WithEventCodec sets the codec used to resolve the payload types of the events.
+func WithEventCodec(c *event.Codec) Option {
+	return func(cat *Catalog) {
+		cat.eventCodec = c
+	}
+}
+
+// WithCommandCodec sets the codec used to resolve the payload
+// and result types of the commands.
+func WithCommandCodec(c *command.Codec) Option {
+	return func(cat *Catalog) {
+		cat.commandCodec = c
+	}
+}
+
+func New(title, version string, opts ...Option) *Catalog {
+	c := &Catalog{
+		title:   title,
+		version: version,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Catalog) AddService(name string, opts ...ServiceOption) {
+	s := Service{Name: name}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	c.services = append(c.services, s)
+}
+
+func (c *Catalog) Services() []Service {
+	return c.services
+}
+
+func (c *Catalog) commandType(name string) string {
+	if c.commandCodec == nil {
+		return ""
+	}
+	cc, found := c.commandCodec.Lookup(name)
+	if !found {
+		return ""
+	}
+	return typeName(cc)
+}
+
+func (c *Catalog) resultType(name string) string {
+	if c.commandCodec == nil {
+		return ""
+	}
+	cc, found := c.commandCodec.LookupResult(name)
+	if !found {
+		return ""
+	}
+	return typeName(cc)
+}
+
+func (c *Catalog) eventType(name string) string {
+	if c.eventCodec == nil {
+		return ""
+	}
+	cc, found := c.eventCodec.Lookup(name)
+	if !found {
+		return ""
+	}
+	return typeName(cc)
+}
+
+func typeName(cc codec.Codec) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", codec.Unwrap(cc)), "*")
+}
+
+func qualify(streamName, name string) string {
+	return streamName + "." + name
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
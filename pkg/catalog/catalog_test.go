@@ -0,0 +1,79 @@
+package catalog
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+	"github.com/go-gulfstream/gulfstream/pkg/eventbus"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+type createOrder struct {
+	Total int
+}
+
+type orderCreated struct {
+	Number int
+}
+
+func testCatalog() *Catalog {
+	commandCodec := command.NewCodec()
+	commandCodec.Register("create", codec.JSON(&createOrder{}))
+	commandCodec.RegisterResult("create", codec.JSON(&orderCreated{}))
+	eventCodec := event.NewCodec()
+	eventCodec.Register("created", codec.JSON(&orderCreated{}))
+
+	cat := New("shop", "1.0.0", WithCommandCodec(commandCodec), WithEventCodec(eventCodec))
+	orders := stream.NewMutator(stream.NewStorage("orders", nil), nil)
+	orders.AddCommandController("create", nil,
+		stream.WithCommandControllerCreateIfNotExists(),
+		stream.WithCommandControllerEmits("created"))
+	orders.AddCommandController("cancel", nil,
+		stream.WithCommandControllerEmits("cancelled"))
+	cat.AddService("orders", WithMutator(orders))
+
+	users := stream.NewMutator(stream.NewStorage("users", nil), nil)
+	users.AddEventController("created", nil,
+		stream.WithEventControllerEmits("orderAdded"))
+	bus := eventbus.NewChannel()
+	bus.Subscribe("orders", eventbus.MutatorHandler(users))
+	cat.AddService("users", WithMutator(users), WithSubscriber(bus))
+
+	projection := stream.NewProjection()
+	projection.AddEventController("created", nil)
+	projection.AddEventController("cancelled", nil)
+	cat.AddService("reports", WithProjection("orders", projection))
+	return cat
+}
+
+func checkGolden(t *testing.T, name string, data []byte) {
+	file := filepath.Join("testdata", name)
+	if *update {
+		assert.NoError(t, os.WriteFile(file, data, 0644))
+	}
+	golden, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, string(golden), string(data))
+}
+
+func TestCatalog_AsyncAPI(t *testing.T) {
+	data, err := testCatalog().AsyncAPI()
+	assert.NoError(t, err)
+	checkGolden(t, "asyncapi.golden.json", data)
+}
+
+func TestCatalog_Graphviz(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	assert.NoError(t, testCatalog().Graphviz(buf))
+	checkGolden(t, "graph.golden.dot", buf.Bytes())
+}
@@ -0,0 +1,67 @@
+package catalog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Graphviz renders the dependency graph of the commands, the events
+// and the services handling them in the DOT language.
+func (c *Catalog) Graphviz(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	m := c.model()
+	streams := m.sortedStreams()
+
+	fmt.Fprintln(bw, "digraph catalog {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, "  node [fontname=\"Helvetica\"];")
+	services := make(map[string]struct{})
+	for _, svc := range c.services {
+		services[svc.Name] = struct{}{}
+	}
+	for _, svc := range sortedKeys(services) {
+		fmt.Fprintf(bw, "  %q [shape=component];\n", serviceNode(svc))
+	}
+	for _, s := range streams {
+		fmt.Fprintf(bw, "  subgraph %q {\n", "cluster_"+s.name)
+		fmt.Fprintf(bw, "    label=%q;\n", s.name)
+		for _, cmd := range s.sortedCommands() {
+			fmt.Fprintf(bw, "    %q [shape=box, label=%q];\n", commandNode(s.name, cmd.name), cmd.name)
+		}
+		for _, e := range s.sortedEvents() {
+			fmt.Fprintf(bw, "    %q [shape=ellipse, label=%q];\n", eventNode(s.name, e.name), e.name)
+		}
+		fmt.Fprintln(bw, "  }")
+	}
+	for _, s := range streams {
+		for _, cmd := range s.sortedCommands() {
+			fmt.Fprintf(bw, "  %q -> %q [style=dashed];\n", serviceNode(cmd.service), commandNode(s.name, cmd.name))
+			for _, eventName := range cmd.emits {
+				fmt.Fprintf(bw, "  %q -> %q;\n", commandNode(s.name, cmd.name), eventNode(s.name, eventName))
+			}
+		}
+		for _, e := range s.sortedEvents() {
+			for _, svc := range sortedKeys(e.reactors) {
+				fmt.Fprintf(bw, "  %q -> %q [style=dotted];\n", serviceNode(svc), eventNode(s.name, e.name))
+			}
+			for _, svc := range s.consumers(e) {
+				fmt.Fprintf(bw, "  %q -> %q;\n", eventNode(s.name, e.name), serviceNode(svc))
+			}
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+func serviceNode(name string) string {
+	return "service:" + name
+}
+
+func commandNode(streamName, name string) string {
+	return "command:" + qualify(streamName, name)
+}
+
+func eventNode(streamName, name string) string {
+	return "event:" + qualify(streamName, name)
+}
@@ -0,0 +1,147 @@
+package catalog
+
+import (
+	"sort"
+
+	"github.com/go-gulfstream/gulfstream/pkg/eventbus"
+)
+
+type model struct {
+	streams map[string]*streamModel
+}
+
+type streamModel struct {
+	name     string
+	commands map[string]*commandModel
+	events   map[string]*eventModel
+	// consumers of all events of the stream.
+	anyConsumers map[string]struct{}
+}
+
+type commandModel struct {
+	name         string
+	service      string
+	createStream bool
+	dropStream   bool
+	emits        []string
+}
+
+type eventModel struct {
+	name      string
+	producers map[string]struct{}
+	consumers map[string]struct{}
+	// services emitting the event in reaction to other events.
+	reactors map[string]struct{}
+}
+
+func (c *Catalog) model() *model {
+	m := &model{streams: make(map[string]*streamModel)}
+	for _, svc := range c.services {
+		for _, mutator := range svc.Mutators {
+			s := m.stream(mutator.StreamName)
+			for _, cmd := range mutator.Commands {
+				s.commands[cmd.Name] = &commandModel{
+					name:         cmd.Name,
+					service:      svc.Name,
+					createStream: cmd.CreateStream,
+					dropStream:   cmd.DropStream,
+					emits:        cmd.Emits,
+				}
+				for _, eventName := range cmd.Emits {
+					s.event(eventName).producers[svc.Name] = struct{}{}
+				}
+			}
+			for _, e := range mutator.Events {
+				for _, eventName := range e.Emits {
+					s.event(eventName).producers[svc.Name] = struct{}{}
+					s.event(eventName).reactors[svc.Name] = struct{}{}
+				}
+			}
+		}
+		for _, sub := range svc.Subscriptions {
+			s := m.stream(sub.StreamName)
+			for _, eventName := range sub.Events {
+				if eventName == eventbus.AnyEvent {
+					s.anyConsumers[svc.Name] = struct{}{}
+					continue
+				}
+				s.event(eventName).consumers[svc.Name] = struct{}{}
+			}
+		}
+	}
+	return m
+}
+
+func (m *model) stream(name string) *streamModel {
+	s, found := m.streams[name]
+	if !found {
+		s = &streamModel{
+			name:         name,
+			commands:     make(map[string]*commandModel),
+			events:       make(map[string]*eventModel),
+			anyConsumers: make(map[string]struct{}),
+		}
+		m.streams[name] = s
+	}
+	return s
+}
+
+func (m *model) sortedStreams() []*streamModel {
+	streams := make([]*streamModel, 0, len(m.streams))
+	for _, s := range m.streams {
+		streams = append(streams, s)
+	}
+	sort.Slice(streams, func(i, j int) bool {
+		return streams[i].name < streams[j].name
+	})
+	return streams
+}
+
+func (s *streamModel) event(name string) *eventModel {
+	e, found := s.events[name]
+	if !found {
+		e = &eventModel{
+			name:      name,
+			producers: make(map[string]struct{}),
+			consumers: make(map[string]struct{}),
+			reactors:  make(map[string]struct{}),
+		}
+		s.events[name] = e
+	}
+	return e
+}
+
+func (s *streamModel) sortedCommands() []*commandModel {
+	commands := make([]*commandModel, 0, len(s.commands))
+	for _, cmd := range s.commands {
+		commands = append(commands, cmd)
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].name < commands[j].name
+	})
+	return commands
+}
+
+func (s *streamModel) sortedEvents() []*eventModel {
+	events := make([]*eventModel, 0, len(s.events))
+	for _, e := range s.events {
+		events = append(events, e)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].name < events[j].name
+	})
+	return events
+}
+
+// consumers returns the services handling the event including
+// the consumers of all events of the stream.
+func (s *streamModel) consumers(e *eventModel) []string {
+	all := make(map[string]struct{}, len(e.consumers)+len(s.anyConsumers))
+	for svc := range e.consumers {
+		all[svc] = struct{}{}
+	}
+	for svc := range s.anyConsumers {
+		all[svc] = struct{}{}
+	}
+	return sortedKeys(all)
+}
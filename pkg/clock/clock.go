@@ -0,0 +1,67 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of the time of the events, the commands and the streams.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// System returns the clock of the system time.
+func System() Clock {
+	return systemClock{}
+}
+
+// OrSystem returns the clock or the system clock if it is nil.
+func OrSystem(c Clock) Clock {
+	if c == nil {
+		return System()
+	}
+	return c
+}
+
+// Fake is the manually advanced clock for tests and simulations.
+type Fake struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now returns the current time of the clock and advances it by the step.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now
+	f.now = f.now.Add(f.step)
+	return now
+}
+
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+}
+
+// SetStep makes the clock advance by d on each call of Now.
+func (f *Fake) SetStep(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.step = d
+}
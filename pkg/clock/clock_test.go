@@ -0,0 +1,21 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2021, 10, 18, 0, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+	assert.Equal(t, start, c.Now())
+	c.Advance(time.Minute)
+	assert.Equal(t, start.Add(time.Minute), c.Now())
+	c.SetStep(time.Second)
+	assert.Equal(t, start.Add(time.Minute), c.Now())
+	assert.Equal(t, start.Add(time.Minute+time.Second), c.Now())
+	c.Set(start)
+	assert.Equal(t, start, c.Now())
+}
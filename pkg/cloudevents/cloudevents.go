@@ -0,0 +1,304 @@
+// Package cloudevents encodes the events to CloudEvents 1.0
+// in the structured JSON and the binary content modes.
+//
+// The stream name is mapped to source, the event name to type,
+// the event ID to id and the stream ID to subject. The stream version
+// and the payload schema version are carried by the streamversion
+// and schemaversion extension attributes, the marks of the payload
+// filters by the payloadfilters attribute.
+package cloudevents
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+)
+
+const (
+	SpecVersion = "1.0"
+	// ContentType is the media type of the event in the structured content mode.
+	ContentType = "application/cloudevents+json"
+
+	JSONDataContentType   = "application/json"
+	BinaryDataContentType = "application/octet-stream"
+)
+
+const (
+	attrSpecVersion     = "specversion"
+	attrID              = "id"
+	attrSource          = "source"
+	attrType            = "type"
+	attrSubject         = "subject"
+	attrTime            = "time"
+	attrDataContentType = "datacontenttype"
+	attrStreamVersion   = "streamversion"
+	attrSchemaVersion   = "schemaversion"
+	attrPayloadFilters  = "payloadfilters"
+)
+
+var ErrInvalidEvent = errors.New("cloudevents: invalid event")
+
+// Mode is the content mode of the event on the transport.
+type Mode int
+
+const (
+	Structured Mode = iota
+	Binary
+)
+
+var _ event.Encoding = (*Codec)(nil)
+
+// Codec encodes the events to CloudEvents. The payloads are encoded
+// by the event codec, so the registered payload codecs, filters
+// and upcasters are applied.
+type Codec struct {
+	events          *event.Codec
+	dataContentType string
+}
+
+type Option func(*Codec)
+
+func New(opts ...Option) *Codec {
+	c := &Codec{
+		events: event.DefaultCodec(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func WithEventCodec(events *event.Codec) Option {
+	return func(c *Codec) {
+		c.events = events
+	}
+}
+
+// WithDataContentType sets the content type of the payloads.
+// By default it is application/json for the valid JSON payloads
+// and application/octet-stream otherwise.
+func WithDataContentType(contentType string) Option {
+	return func(c *Codec) {
+		c.dataContentType = contentType
+	}
+}
+
+type envelope struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject"`
+	Time            string          `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	StreamVersion   int             `json:"streamversion"`
+	SchemaVersion   int             `json:"schemaversion,omitempty"`
+	PayloadFilters  string          `json:"payloadfilters,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      []byte          `json:"data_base64,omitempty"`
+}
+
+// Encode encodes the event in the structured content mode.
+func (c *Codec) Encode(e *event.Event) ([]byte, error) {
+	data, marks, err := c.events.MarshalPayload(e)
+	if err != nil {
+		return nil, err
+	}
+	env := c.envelope(e, data, marks)
+	if len(data) > 0 {
+		if isJSON(env.DataContentType) && json.Valid(data) {
+			env.Data = data
+		} else {
+			env.DataBase64 = data
+		}
+	}
+	return json.Marshal(env)
+}
+
+// Decode decodes the event in the structured content mode.
+func (c *Codec) Decode(data []byte) (*event.Event, error) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	payload := env.DataBase64
+	if len(env.Data) > 0 {
+		payload = env.Data
+		if !isJSON(env.DataContentType) && env.Data[0] == '"' {
+			var s string
+			if err := json.Unmarshal(env.Data, &s); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+			}
+			payload = []byte(s)
+		}
+	}
+	return c.restore(env, payload)
+}
+
+// EncodeBinary encodes the attributes of the event to the headers
+// and returns the payload in the binary content mode.
+func (c *Codec) EncodeBinary(e *event.Event, h Headers) ([]byte, error) {
+	data, marks, err := c.events.MarshalPayload(e)
+	if err != nil {
+		return nil, err
+	}
+	env := c.envelope(e, data, marks)
+	h.Set(attrSpecVersion, env.SpecVersion)
+	h.Set(attrID, env.ID)
+	h.Set(attrSource, env.Source)
+	h.Set(attrType, env.Type)
+	h.Set(attrSubject, env.Subject)
+	h.Set(attrTime, env.Time)
+	h.Set(attrStreamVersion, strconv.Itoa(env.StreamVersion))
+	if env.SchemaVersion > 0 {
+		h.Set(attrSchemaVersion, strconv.Itoa(env.SchemaVersion))
+	}
+	if len(env.PayloadFilters) > 0 {
+		h.Set(attrPayloadFilters, env.PayloadFilters)
+	}
+	if len(env.DataContentType) > 0 {
+		h.Set(attrDataContentType, env.DataContentType)
+	}
+	return data, nil
+}
+
+// DecodeBinary decodes the event from the headers and the payload
+// in the binary content mode.
+func (c *Codec) DecodeBinary(data []byte, h Headers) (*event.Event, error) {
+	env := envelope{
+		SpecVersion:     h.Get(attrSpecVersion),
+		ID:              h.Get(attrID),
+		Source:          h.Get(attrSource),
+		Type:            h.Get(attrType),
+		Subject:         h.Get(attrSubject),
+		Time:            h.Get(attrTime),
+		DataContentType: h.Get(attrDataContentType),
+		PayloadFilters:  h.Get(attrPayloadFilters),
+	}
+	var err error
+	if env.StreamVersion, err = atoi(h.Get(attrStreamVersion)); err != nil {
+		return nil, err
+	}
+	if env.SchemaVersion, err = atoi(h.Get(attrSchemaVersion)); err != nil {
+		return nil, err
+	}
+	return c.restore(env, data)
+}
+
+func (c *Codec) envelope(e *event.Event, data []byte, marks codec.FilterMarks) envelope {
+	env := envelope{
+		SpecVersion:    SpecVersion,
+		ID:             e.ID().String(),
+		Source:         e.StreamName(),
+		Type:           e.Name(),
+		Subject:        e.StreamID().String(),
+		Time:           e.CreatedAt().UTC().Format(time.RFC3339Nano),
+		StreamVersion:  e.Version(),
+		PayloadFilters: formatMarks(marks),
+	}
+	if version := c.events.EventSchemaVersion(e); version != event.DefaultSchemaVersion {
+		env.SchemaVersion = version
+	}
+	if len(data) > 0 {
+		env.DataContentType = c.dataContentType
+		if len(env.DataContentType) == 0 {
+			env.DataContentType = BinaryDataContentType
+			if json.Valid(data) {
+				env.DataContentType = JSONDataContentType
+			}
+		}
+	}
+	return env
+}
+
+func (c *Codec) restore(env envelope, data []byte) (*event.Event, error) {
+	if env.SpecVersion != SpecVersion {
+		return nil, fmt.Errorf("%w: unsupported specversion %q", ErrInvalidEvent, env.SpecVersion)
+	}
+	if len(env.Source) == 0 || len(env.Type) == 0 {
+		return nil, fmt.Errorf("%w: source and type are required", ErrInvalidEvent)
+	}
+	id, err := uuid.Parse(env.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: id: %v", ErrInvalidEvent, err)
+	}
+	streamID, err := uuid.Parse(env.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: subject: %v", ErrInvalidEvent, err)
+	}
+	var createdAt time.Time
+	if len(env.Time) > 0 {
+		t, err := time.Parse(time.RFC3339Nano, env.Time)
+		if err != nil {
+			return nil, fmt.Errorf("%w: time: %v", ErrInvalidEvent, err)
+		}
+		createdAt = t
+	}
+	schemaVersion := env.SchemaVersion
+	if schemaVersion == 0 {
+		schemaVersion = event.DefaultSchemaVersion
+	}
+	marks, err := parseMarks(env.PayloadFilters)
+	if err != nil {
+		return nil, err
+	}
+	e := event.Restore(id, env.Type, env.Source, streamID, env.StreamVersion, createdAt, nil)
+	payload, err := c.events.UnmarshalPayload(e, schemaVersion, data, marks)
+	if err != nil {
+		return nil, err
+	}
+	return event.Restore(id, env.Type, env.Source, streamID, env.StreamVersion, createdAt, payload), nil
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == JSONDataContentType || strings.HasSuffix(mediaType, "+json")
+}
+
+// formatMarks writes the marks as the comma separated numbers.
+func formatMarks(marks codec.FilterMarks) string {
+	parts := make([]string, len(marks))
+	for i, mark := range marks {
+		parts[i] = strconv.Itoa(int(mark))
+	}
+	return strings.Join(parts, ",")
+}
+
+func parseMarks(s string) (codec.FilterMarks, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	marks := make(codec.FilterMarks, len(parts))
+	for i, part := range parts {
+		mark, err := strconv.ParseUint(part, 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidEvent, attrPayloadFilters, err)
+		}
+		marks[i] = byte(mark)
+	}
+	return marks, nil
+}
+
+func atoi(s string) (int, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	return n, nil
+}
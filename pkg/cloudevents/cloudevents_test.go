@@ -0,0 +1,167 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+)
+
+type userCreated struct {
+	Name string `json:"name"`
+}
+
+type rawPayload struct {
+	data []byte
+}
+
+func (p *rawPayload) MarshalBinary() ([]byte, error) {
+	return p.data, nil
+}
+
+func (p *rawPayload) UnmarshalBinary(data []byte) error {
+	p.data = data
+	return nil
+}
+
+func newCodec() *Codec {
+	events := event.NewCodec()
+	events.Register("created", codec.JSON(&userCreated{}))
+	events.Register("raw", &rawPayload{})
+	return New(WithEventCodec(events))
+}
+
+func assertEvent(t *testing.T, expected, actual *event.Event) {
+	assert.Equal(t, expected.ID(), actual.ID())
+	assert.Equal(t, expected.Name(), actual.Name())
+	assert.Equal(t, expected.StreamName(), actual.StreamName())
+	assert.Equal(t, expected.StreamID(), actual.StreamID())
+	assert.Equal(t, expected.Version(), actual.Version())
+	assert.Equal(t, expected.Unix(), actual.Unix())
+	assert.Equal(t, expected.Payload(), actual.Payload())
+}
+
+func TestCodec_Structured(t *testing.T) {
+	c := newCodec()
+	e := event.New("created", "users", uuid.New(), 2, codec.JSON(&userCreated{Name: "John"}))
+	data, err := c.Encode(e)
+	assert.NoError(t, err)
+
+	var attrs map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &attrs))
+	assert.Equal(t, "1.0", attrs["specversion"])
+	assert.Equal(t, e.ID().String(), attrs["id"])
+	assert.Equal(t, "users", attrs["source"])
+	assert.Equal(t, "created", attrs["type"])
+	assert.Equal(t, e.StreamID().String(), attrs["subject"])
+	assert.Equal(t, "application/json", attrs["datacontenttype"])
+	assert.Equal(t, map[string]interface{}{"name": "John"}, attrs["data"])
+
+	e2, err := c.Decode(data)
+	assert.NoError(t, err)
+	assertEvent(t, e, e2)
+}
+
+func TestCodec_StructuredBase64(t *testing.T) {
+	c := newCodec()
+	e := event.New("raw", "users", uuid.New(), 1, &rawPayload{data: []byte{0, 1, 2}})
+	data, err := c.Encode(e)
+	assert.NoError(t, err)
+	var attrs map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &attrs))
+	assert.Equal(t, "application/octet-stream", attrs["datacontenttype"])
+	assert.Equal(t, "AAEC", attrs["data_base64"])
+	e2, err := c.Decode(data)
+	assert.NoError(t, err)
+	assertEvent(t, e, e2)
+}
+
+func TestCodec_Binary(t *testing.T) {
+	c := newCodec()
+	e := event.New("created", "users", uuid.New(), 2, codec.JSON(&userCreated{Name: "John"}))
+
+	headers := MapHeaders{}
+	data, err := c.EncodeBinary(e, headers)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name":"John"}`, string(data))
+	assert.Equal(t, "created", headers["type"])
+	assert.Equal(t, "2", headers["streamversion"])
+	e2, err := c.DecodeBinary(data, headers)
+	assert.NoError(t, err)
+	assertEvent(t, e, e2)
+
+	h := http.Header{}
+	data, err = c.EncodeBinary(e, HTTPHeaders(h))
+	assert.NoError(t, err)
+	assert.Equal(t, e.ID().String(), h.Get("ce-id"))
+	assert.Equal(t, "users", h.Get("ce-source"))
+	assert.Equal(t, "application/json", h.Get("Content-Type"))
+	e2, err = c.DecodeBinary(data, HTTPHeaders(h))
+	assert.NoError(t, err)
+	assertEvent(t, e, e2)
+}
+
+// reverseFilter is the marked filter reversing the payload.
+type reverseFilter struct{}
+
+func (reverseFilter) FilterMark() byte { return 7 }
+
+func (reverseFilter) EncodePayload(_ *event.Event, data []byte) ([]byte, error) {
+	return reverse(data), nil
+}
+
+func (reverseFilter) DecodePayload(_ *event.Event, data []byte) ([]byte, error) {
+	return reverse(data), nil
+}
+
+func reverse(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[len(data)-1-i] = b
+	}
+	return out
+}
+
+func TestCodec_PayloadFilters(t *testing.T) {
+	events := event.NewCodec()
+	events.Register("raw", &rawPayload{})
+	events.Use(reverseFilter{})
+	c := New(WithEventCodec(events))
+	e := event.New("raw", "users", uuid.New(), 1, &rawPayload{data: []byte{0, 1, 2}})
+
+	data, err := c.Encode(e)
+	assert.NoError(t, err)
+	var attrs map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &attrs))
+	assert.Equal(t, "7", attrs["payloadfilters"])
+	e2, err := c.Decode(data)
+	assert.NoError(t, err)
+	assertEvent(t, e, e2)
+
+	headers := MapHeaders{}
+	data, err = c.EncodeBinary(e, headers)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{2, 1, 0}, data)
+	assert.Equal(t, "7", headers["payloadfilters"])
+	e2, err = c.DecodeBinary(data, headers)
+	assert.NoError(t, err)
+	assertEvent(t, e, e2)
+
+	delete(headers, "payloadfilters")
+	e2, err = c.DecodeBinary(data, headers)
+	assert.NoError(t, err)
+	assert.Equal(t, &rawPayload{data: []byte{2, 1, 0}}, e2.Payload(), "the unmarked payload is not filtered")
+}
+
+func TestCodec_DecodeInvalid(t *testing.T) {
+	c := newCodec()
+	_, err := c.Decode([]byte(`{"specversion":"0.3","id":"1","source":"users","type":"created"}`))
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+	_, err = c.DecodeBinary(nil, MapHeaders{"specversion": "1.0", "source": "users", "type": "created", "id": "1"})
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+}
@@ -0,0 +1,54 @@
+package cloudevents
+
+import "net/http"
+
+// Headers carries the attributes of the event in the binary content mode.
+// The keys are the attribute names, e.g. id or datacontenttype,
+// the implementations map them to the transport headers.
+type Headers interface {
+	Get(attr string) string
+	Set(attr, value string)
+}
+
+// MapHeaders keeps the attributes as is.
+type MapHeaders map[string]string
+
+func (h MapHeaders) Get(attr string) string {
+	return h[attr]
+}
+
+func (h MapHeaders) Set(attr, value string) {
+	h[attr] = value
+}
+
+// HTTPHeaders maps the attributes to the ce- prefixed HTTP headers
+// and datacontenttype to Content-Type.
+func HTTPHeaders(h http.Header) Headers {
+	return httpHeaders(h)
+}
+
+type httpHeaders http.Header
+
+func (h httpHeaders) Get(attr string) string {
+	return http.Header(h).Get(httpHeader(attr))
+}
+
+func (h httpHeaders) Set(attr, value string) {
+	http.Header(h).Set(httpHeader(attr), value)
+}
+
+func httpHeader(attr string) string {
+	if attr == attrDataContentType {
+		return "Content-Type"
+	}
+	return "ce-" + attr
+}
+
+// KafkaHeader returns the Kafka header of the attribute in the binary content mode:
+// the ce_ prefixed attribute name or content-type for datacontenttype.
+func KafkaHeader(attr string) string {
+	if attr == attrDataContentType {
+		return "content-type"
+	}
+	return "ce_" + attr
+}
@@ -0,0 +1,88 @@
+package codec
+
+import (
+	"encoding/binary"
+	"sync"
+)
+
+// The append helpers write the fixed-width fields of the binary
+// containers in little-endian order without reflection.
+
+func AppendUint16(dst []byte, v uint16) []byte {
+	return append(dst, byte(v), byte(v>>8))
+}
+
+func AppendUint32(dst []byte, v uint32) []byte {
+	return append(dst, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func AppendUint64(dst []byte, v uint64) []byte {
+	return append(dst,
+		byte(v), byte(v>>8), byte(v>>16), byte(v>>24),
+		byte(v>>32), byte(v>>40), byte(v>>48), byte(v>>56),
+	)
+}
+
+// Grow makes sure dst has the room for n more bytes.
+func Grow(dst []byte, n int) []byte {
+	if cap(dst)-len(dst) >= n {
+		return dst
+	}
+	buf := make([]byte, len(dst), 2*cap(dst)+n)
+	copy(buf, dst)
+	return buf
+}
+
+// BeginExtensions reserves the size of the extension area and returns
+// the offset to pass to EndExtensions.
+func BeginExtensions(dst []byte) ([]byte, int) {
+	return append(dst, 0, 0, 0, 0), len(dst)
+}
+
+// EndExtensions writes the size of the extension area started at the offset.
+func EndExtensions(dst []byte, offset int) []byte {
+	binary.LittleEndian.PutUint32(dst[offset:], uint32(len(dst)-offset-4))
+	return dst
+}
+
+// AppendExtension appends the single extension.
+func AppendExtension(dst []byte, tag uint16, val []byte) []byte {
+	dst = AppendUint16(dst, tag)
+	dst = AppendUint32(dst, uint32(len(val)))
+	return append(dst, val...)
+}
+
+// AppendNanos appends the nanoseconds extension if the nanoseconds are not zero.
+func AppendNanos(dst []byte, tag uint16, nsec uint32) []byte {
+	if nsec == 0 {
+		return dst
+	}
+	dst = AppendUint16(dst, tag)
+	dst = AppendUint32(dst, 4)
+	return AppendUint32(dst, nsec)
+}
+
+// BufferPool keeps the encoding buffers to reuse.
+type BufferPool struct {
+	pool sync.Pool
+}
+
+// Get returns the empty buffer.
+func (p *BufferPool) Get() *[]byte {
+	if buf, ok := p.pool.Get().(*[]byte); ok {
+		*buf = (*buf)[:0]
+		return buf
+	}
+	buf := make([]byte, 0, 512)
+	return &buf
+}
+
+// Put returns the buffer to the pool. The large buffers are dropped.
+func (p *BufferPool) Put(buf *[]byte) {
+	if cap(*buf) > maxPooledBuffer {
+		return
+	}
+	p.pool.Put(buf)
+}
+
+const maxPooledBuffer = 64 << 10
@@ -0,0 +1,35 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendUint(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, binary.Write(&buf, binary.LittleEndian, uint16(0x0102)))
+	assert.NoError(t, binary.Write(&buf, binary.LittleEndian, uint32(0x01020304)))
+	assert.NoError(t, binary.Write(&buf, binary.LittleEndian, int64(-2)))
+	dst := AppendUint16(nil, 0x0102)
+	dst = AppendUint32(dst, 0x01020304)
+	v := int64(-2)
+	dst = AppendUint64(dst, uint64(v))
+	assert.Equal(t, buf.Bytes(), dst)
+}
+
+func TestExtensions_AppendBinary(t *testing.T) {
+	ext := Extensions{3: []byte("c"), 1: []byte("a"), 2: nil}
+	dst, offset := BeginExtensions([]byte("x"))
+	dst = ext.AppendBinary(dst)
+	dst = AppendNanos(dst, 4, 0)
+	dst = EndExtensions(dst, offset)
+	assert.Equal(t, uint32(3*extensionHeaderSize+2), binary.LittleEndian.Uint32(dst[1:]))
+
+	var decoded Extensions
+	assert.NoError(t, decoded.UnmarshalBinary(dst[5:]))
+	assert.Equal(t, Extensions{1: []byte("a"), 2: {}, 3: []byte("c")}, decoded)
+	assert.Equal(t, []byte{1, 0}, dst[5:7], "the extensions are ordered by tag")
+}
@@ -0,0 +1,78 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const extensionHeaderSize = 6
+
+var ErrInvalidExtensions = errors.New("codec: invalid extensions data")
+
+// Extensions is the tagged area of the binary containers.
+// Each extension is written as tag uint16, size uint32 and value,
+// so decoders skip the extensions with unknown tags.
+type Extensions map[uint16][]byte
+
+func (e Extensions) Get(tag uint16) ([]byte, bool) {
+	if e == nil {
+		return nil, false
+	}
+	val, found := e[tag]
+	return val, found
+}
+
+func (e Extensions) Size() int {
+	var size int
+	for _, val := range e {
+		size += extensionHeaderSize + len(val)
+	}
+	return size
+}
+
+// MarshalBinary writes the extensions ordered by tag.
+func (e Extensions) MarshalBinary() ([]byte, error) {
+	if len(e) == 0 {
+		return nil, nil
+	}
+	return e.AppendBinary(make([]byte, 0, e.Size())), nil
+}
+
+// AppendBinary appends the extensions ordered by tag.
+func (e Extensions) AppendBinary(dst []byte) []byte {
+	var stack [8]uint16
+	tags := stack[:0]
+	for tag := range e {
+		tags = append(tags, tag)
+	}
+	for i := 1; i < len(tags); i++ {
+		for j := i; j > 0 && tags[j] < tags[j-1]; j-- {
+			tags[j], tags[j-1] = tags[j-1], tags[j]
+		}
+	}
+	for _, tag := range tags {
+		dst = AppendExtension(dst, tag, e[tag])
+	}
+	return dst
+}
+
+func (e *Extensions) UnmarshalBinary(data []byte) error {
+	ext := make(Extensions)
+	for len(data) > 0 {
+		if len(data) < extensionHeaderSize {
+			return ErrInvalidExtensions
+		}
+		tag := binary.LittleEndian.Uint16(data)
+		size := binary.LittleEndian.Uint32(data[2:])
+		data = data[extensionHeaderSize:]
+		if uint32(len(data)) < size {
+			return ErrInvalidExtensions
+		}
+		val := make([]byte, size)
+		copy(val, data[:size])
+		ext[tag] = val
+		data = data[size:]
+	}
+	*e = ext
+	return nil
+}
@@ -0,0 +1,68 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+)
+
+// The marks of the filters of the module.
+const (
+	CompressionMark = byte(1)
+	EncryptionMark  = byte(2)
+)
+
+// ErrSkipFilter is returned by the payload and state filters to leave
+// the data as is, e.g. when it is too small to compress.
+// The mark of the skipped filter is not recorded.
+var ErrSkipFilter = errors.New("codec: filter skipped")
+
+// Marker is implemented by the filters whose output is recorded
+// in the extensions of the container. The marked filter decodes only
+// the data with its mark, so the data written without the filter
+// is never mistaken for its output.
+type Marker interface {
+	// FilterMark returns the mark unique among the filters of the codec.
+	FilterMark() byte
+}
+
+// FilterMarks are the marks of the filters applied to the data
+// in the order of encoding.
+type FilterMarks []byte
+
+func (m FilterMarks) Has(mark byte) bool {
+	return bytes.IndexByte(m, mark) >= 0
+}
+
+// EncodeFilter applies the encode func of the filter and adds its mark.
+func EncodeFilter(
+	filter interface{},
+	marks FilterMarks,
+	data []byte,
+	encode func([]byte) ([]byte, error),
+) ([]byte, FilterMarks, error) {
+	out, err := encode(data)
+	if errors.Is(err, ErrSkipFilter) {
+		return data, marks, nil
+	}
+	if err != nil {
+		return nil, marks, err
+	}
+	if m, ok := filter.(Marker); ok {
+		marks = append(marks, m.FilterMark())
+	}
+	return out, marks, nil
+}
+
+// DecodeFilter applies the decode func of the filter. The marked filter
+// is skipped if the data has not its mark.
+func DecodeFilter(
+	filter interface{},
+	marks FilterMarks,
+	data []byte,
+	decode func([]byte) ([]byte, error),
+) ([]byte, error) {
+	if m, ok := filter.(Marker); ok && !marks.Has(m.FilterMark()) {
+		return data, nil
+	}
+	return decode(data)
+}
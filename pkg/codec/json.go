@@ -0,0 +1,20 @@
+package codec
+
+import "encoding/json"
+
+var JSONMarshaler Marshaler = jsonMarshaler{}
+
+// JSON adapts the pointer to any struct to Codec with the JSON encoding.
+func JSON(v interface{}) *Value {
+	return Wrap(JSONMarshaler, v)
+}
+
+type jsonMarshaler struct{}
+
+func (jsonMarshaler) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonMarshaler) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,39 @@
+package codec
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+)
+
+var ProtobufMarshaler Marshaler = protobufMarshaler{}
+
+// Protobuf adapts the gogo or golang protobuf message to Codec.
+func Protobuf(m proto.Message) *Value {
+	return Wrap(ProtobufMarshaler, m)
+}
+
+type protobufMarshaler struct{}
+
+func (protobufMarshaler) Marshal(v interface{}) ([]byte, error) {
+	switch m := v.(type) {
+	case interface{ Marshal() ([]byte, error) }:
+		// gogo message with the generated marshaler.
+		return m.Marshal()
+	case proto.Message:
+		return proto.Marshal(m)
+	default:
+		return nil, fmt.Errorf("codec: %T is not a protobuf message", v)
+	}
+}
+
+func (protobufMarshaler) Unmarshal(data []byte, v interface{}) error {
+	switch m := v.(type) {
+	case interface{ Unmarshal([]byte) error }:
+		return m.Unmarshal(data)
+	case proto.Message:
+		return proto.Unmarshal(data, m)
+	default:
+		return fmt.Errorf("codec: %T is not a protobuf message", v)
+	}
+}
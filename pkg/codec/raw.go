@@ -0,0 +1,13 @@
+package codec
+
+// Raw is the opaque payload, it is re-encoded to the identical bytes.
+type Raw []byte
+
+func (r Raw) MarshalBinary() ([]byte, error) {
+	return r, nil
+}
+
+func (r *Raw) UnmarshalBinary(data []byte) error {
+	*r = append((*r)[:0], data...)
+	return nil
+}
@@ -0,0 +1,49 @@
+package codec
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// The binary containers keep the timestamps as Unix seconds for
+// compatibility with the older decoders and keep the nanoseconds
+// of the second in the extension.
+
+// SplitTimestamp returns the Unix seconds and the nanoseconds within the second.
+func SplitTimestamp(t time.Time) (int64, uint32) {
+	if t.IsZero() {
+		return 0, 0
+	}
+	return t.Unix(), uint32(t.Nanosecond())
+}
+
+// JoinTimestamp is the reverse of SplitTimestamp.
+func JoinTimestamp(sec int64, nsec uint32) time.Time {
+	return time.Unix(sec, int64(nsec))
+}
+
+// SetNanos adds the nanoseconds extension if the nanoseconds are not zero.
+func (e Extensions) SetNanos(tag uint16, nsec uint32) {
+	if nsec == 0 {
+		return
+	}
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, nsec)
+	e[tag] = data
+}
+
+// Nanos returns the nanoseconds of the extension or zero if it is not found.
+func (e Extensions) Nanos(tag uint16) (uint32, error) {
+	data, found := e.Get(tag)
+	if !found {
+		return 0, nil
+	}
+	if len(data) != 4 {
+		return 0, ErrInvalidExtensions
+	}
+	nsec := binary.LittleEndian.Uint32(data)
+	if nsec >= uint32(time.Second) {
+		return 0, ErrInvalidExtensions
+	}
+	return nsec, nil
+}
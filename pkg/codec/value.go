@@ -0,0 +1,72 @@
+package codec
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Marshaler encodes and decodes the plain values, e.g. JSON or Protobuf.
+type Marshaler interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// Factory is implemented by the codecs which can not be created
+// by the reflection, e.g. the adapters of the plain values.
+type Factory interface {
+	New() Codec
+}
+
+// Value adapts the plain value to Codec with the marshaler.
+type Value struct {
+	marshaler Marshaler
+	value     interface{}
+}
+
+// Wrap adapts the pointer to the plain value to Codec with the marshaler.
+func Wrap(m Marshaler, v interface{}) *Value {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		panic(fmt.Sprintf("codec: Wrap(non-pointer %T)", v))
+	}
+	return &Value{
+		marshaler: m,
+		value:     v,
+	}
+}
+
+func (v *Value) MarshalBinary() ([]byte, error) {
+	return v.marshaler.Marshal(v.value)
+}
+
+func (v *Value) UnmarshalBinary(data []byte) error {
+	return v.marshaler.Unmarshal(data, v.value)
+}
+
+// Unwrap returns the pointer to the plain value.
+func (v *Value) Unwrap() interface{} {
+	return v.value
+}
+
+func (v *Value) New() Codec {
+	return &Value{
+		marshaler: v.marshaler,
+		value:     reflect.New(reflect.TypeOf(v.value).Elem()).Interface(),
+	}
+}
+
+// New returns the new empty codec of the same type as cc.
+func New(cc Codec) Codec {
+	if f, ok := cc.(Factory); ok {
+		return f.New()
+	}
+	return reflect.New(reflect.TypeOf(cc).Elem()).Interface().(Codec)
+}
+
+// Unwrap returns the plain value of the adapter or cc itself.
+func Unwrap(cc Codec) interface{} {
+	if w, ok := cc.(interface{ Unwrap() interface{} }); ok {
+		return w.Unwrap()
+	}
+	return cc
+}
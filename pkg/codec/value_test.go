@@ -0,0 +1,62 @@
+package codec_test
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+)
+
+type userCreated struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func TestJSON(t *testing.T) {
+	c := event.NewCodec()
+	c.Register("created", codec.JSON(&userCreated{}))
+	e := event.New("created", "users", uuid.New(), 1,
+		codec.JSON(&userCreated{Name: "John", Email: "john@example.com"}))
+	data, err := c.Encode(e)
+	assert.NoError(t, err)
+	e2, err := c.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, &userCreated{Name: "John", Email: "john@example.com"},
+		codec.Unwrap(e2.Payload()))
+
+	cc := command.NewCodec()
+	cc.Register("create", codec.JSON(&userCreated{}))
+	cmd := command.New("create", "users", uuid.New(), codec.JSON(&userCreated{Name: "John"}))
+	data, err = cc.Encode(cmd)
+	assert.NoError(t, err)
+	cmd2, err := cc.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, &userCreated{Name: "John"}, codec.Unwrap(cmd2.Payload()))
+}
+
+func TestProtobuf(t *testing.T) {
+	for _, msg := range []codec.Codec{
+		codec.Protobuf(&types.StringValue{Value: "gogo"}),
+		codec.Protobuf(wrapperspb.String("golang")),
+	} {
+		data, err := msg.MarshalBinary()
+		assert.NoError(t, err)
+		msg2 := codec.New(msg)
+		assert.NoError(t, msg2.UnmarshalBinary(data))
+		assert.Equal(t,
+			codec.Unwrap(msg).(interface{ GetValue() string }).GetValue(),
+			codec.Unwrap(msg2).(interface{ GetValue() string }).GetValue())
+	}
+}
+
+func TestWrapNonPointer(t *testing.T) {
+	assert.Panics(t, func() {
+		codec.JSON(userCreated{})
+	})
+}
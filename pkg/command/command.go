@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/go-gulfstream/gulfstream/pkg/clock"
 	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/go-gulfstream/gulfstream/pkg/idgen"
 
 	"github.com/google/uuid"
 )
@@ -14,8 +16,9 @@ type Command struct {
 	streamID   uuid.UUID
 	name       string
 	streamName string
-	createdAt  int64
+	createdAt  time.Time
 	payload    codec.Codec
+	clock      clock.Clock
 }
 
 func New(
@@ -23,28 +26,75 @@ func New(
 	streamName string,
 	streamID uuid.UUID,
 	payload codec.Codec,
+	opts ...Option,
 ) *Command {
-	return &Command{
-		id:         uuid.New(),
+	c := &Command{
 		name:       name,
 		streamID:   streamID,
 		streamName: streamName,
-		createdAt:  time.Now().Unix(),
 		payload:    payload,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.id == uuid.Nil {
+		c.id = uuid.New()
+	}
+	if c.createdAt.IsZero() {
+		c.createdAt = time.Now()
+	}
+	return c
+}
+
+// Option configures the command created by New.
+type Option func(*Command)
+
+// WithClock sets the clock of the creation time of the command and its replies.
+func WithClock(c clock.Clock) Option {
+	return func(cmd *Command) {
+		if c != nil {
+			cmd.clock = c
+			cmd.createdAt = c.Now()
+		}
+	}
+}
+
+// WithIDGenerator sets the generator of the command ID.
+func WithIDGenerator(g idgen.Generator) Option {
+	return func(cmd *Command) {
+		if g != nil {
+			cmd.id = g.NewID()
+		}
+	}
 }
 
 func (c *Command) String() string {
 	return fmt.Sprintf("Command{ID:%s, Name:%s, StreamName:%s, StreamID:%s, CreatedAt:%d, Payload: %v}",
-		c.id, c.name, c.streamName, c.streamID, c.createdAt, c.payload)
+		c.id, c.name, c.streamName, c.streamID, c.createdAt.UnixNano(), c.payload)
 }
 
 func (c *Command) ReplyOk(version int) *Reply {
-	return newReply(c.id, version, nil)
+	return c.newReply(version, nil)
+}
+
+// ReplyResult replies with the result payload, e.g. the generated number
+// of the created entity.
+func (c *Command) ReplyResult(version int, result codec.Codec) *Reply {
+	r := c.newReply(version, nil)
+	r.result = result
+	return r
 }
 
 func (c *Command) ReplyErr(err error) *Reply {
-	return newReply(c.id, 0, err)
+	return c.newReply(0, err)
+}
+
+func (c *Command) newReply(version int, err error) *Reply {
+	r := newReply(c.id, version, err)
+	if c.clock != nil {
+		r.createdAt = c.clock.Now()
+	}
+	return r
 }
 
 func (c *Command) ID() uuid.UUID {
@@ -72,5 +122,10 @@ func (c *Command) Payload() codec.Codec {
 }
 
 func (c *Command) Unix() int64 {
+	return c.createdAt.Unix()
+}
+
+// CreatedAt returns the creation time of the command.
+func (c *Command) CreatedAt() time.Time {
 	return c.createdAt
 }
@@ -2,27 +2,44 @@ package command
 
 import (
 	"bytes"
-	"encoding"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"sort"
 	"unsafe"
 
 	"github.com/go-gulfstream/gulfstream/pkg/util"
 
 	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/google/uuid"
 )
 
 const (
-	commandMagicNumber = uint16(121)
-	replyMagicNumber   = uint16(122)
-	containerSize      = int(unsafe.Sizeof(Command{})) - 24
+	// FormatVersion is the version of the command and reply containers written by the codec.
+	// Version 0 is the legacy container without the version and the extensions.
+	FormatVersion = uint8(1)
+
+	commandMagicNumber       = uint16(121)
+	replyMagicNumber         = uint16(122)
+	commandFormatMagicNumber = uint16(1121)
+	replyFormatMagicNumber   = uint16(1122)
+	containerSize            = int(unsafe.Sizeof(commandMagicNumber) +
+		3*unsafe.Sizeof(uint32(0)) +
+		2*unsafe.Sizeof(uuid.UUID{}) +
+		unsafe.Sizeof(int64(0)))
+
+	extCreatedAtNanos = uint16(1)
+	extPayloadFilters = uint16(2)
+
+	nanosExtensionSize = 10
 )
 
 var (
-	ErrInvalidInputData = errors.New("command: invalid data input for codec")
-	ErrCodecNotFound    = errors.New("command: codec not found")
+	ErrInvalidInputData  = errors.New("command: invalid data input for codec")
+	ErrCodecNotFound     = errors.New("command: codec not found")
+	ErrUnsupportedFormat = errors.New("command: unsupported container format version")
 )
 
 var defaultCodec = NewCodec()
@@ -33,12 +50,26 @@ type Encoding interface {
 }
 
 type Codec struct {
-	codec map[string]codec.Codec
+	codec   map[string]codec.Codec
+	results map[string]codec.Codec
+	filters []PayloadFilter
+	buffers codec.BufferPool
+}
+
+// PayloadFilter transforms the raw payload of the command after it is marshaled
+// and before it is unmarshaled, e.g. to compress it.
+// The filter implementing codec.Marker is recorded in the container
+// and decodes only the payloads it encoded. EncodePayload returns
+// codec.ErrSkipFilter to leave the payload as is.
+type PayloadFilter interface {
+	EncodePayload(c *Command, data []byte) ([]byte, error)
+	DecodePayload(c *Command, data []byte) ([]byte, error)
 }
 
 func NewCodec() *Codec {
 	return &Codec{
-		codec: make(map[string]codec.Codec),
+		codec:   make(map[string]codec.Codec),
+		results: make(map[string]codec.Codec),
 	}
 }
 
@@ -46,10 +77,20 @@ func (c *Codec) Decode(data []byte) (*Command, error) {
 	if len(data) < containerSize {
 		return nil, ErrInvalidInputData
 	}
-	command, rawPayload, err := c.decodeContainer(data)
+	command, rawPayload, ext, err := c.decodeContainer(data)
 	if err != nil {
 		return nil, err
 	}
+	marks, _ := ext.Get(extPayloadFilters)
+	for i := len(c.filters) - 1; i >= 0; i-- {
+		filter := c.filters[i]
+		rawPayload, err = codec.DecodeFilter(filter, marks, rawPayload, func(data []byte) ([]byte, error) {
+			return filter.DecodePayload(command, data)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
 	payload, err := c.decodePayload(command.name, rawPayload)
 	if err != nil {
 		return nil, err
@@ -59,48 +100,82 @@ func (c *Codec) Decode(data []byte) (*Command, error) {
 }
 
 func (c *Codec) Encode(command *Command) ([]byte, error) {
-	payload, err := c.encodePayload(command)
+	payload, marks, err := c.marshalPayload(command)
 	if err != nil {
 		return nil, err
 	}
-	return c.encodeContainer(command, payload)
+	return c.encodeContainer(command, payload, marks)
+}
+
+func (c *Codec) marshalPayload(command *Command) ([]byte, codec.FilterMarks, error) {
+	payload, err := c.encodePayload(command)
+	if err != nil {
+		return nil, nil, err
+	}
+	var marks codec.FilterMarks
+	for _, filter := range c.filters {
+		filter := filter
+		payload, marks, err = codec.EncodeFilter(filter, marks, payload, func(data []byte) ([]byte, error) {
+			return filter.EncodePayload(command, data)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return payload, marks, nil
+}
+
+// Use adds the payload filters. The filters are applied in the order
+// of adding on encoding and in the reverse order on decoding.
+func (c *Codec) Use(filters ...PayloadFilter) {
+	c.filters = append(c.filters, filters...)
 }
 
 func (c *Codec) encodePayload(cmd *Command) ([]byte, error) {
 	if cmd.payload == nil {
 		return nil, nil
 	}
-	_, found := c.codec[cmd.name]
+	_, found := c.lookup(cmd.name)
 	if !found {
 		return nil, fmt.Errorf("%w %s", ErrCodecNotFound, cmd)
 	}
 	return cmd.Payload().MarshalBinary()
 }
 
-func (c *Codec) encodeContainer(command *Command, payload []byte) ([]byte, error) {
-	w := newCommandWriter(command, payload)
-	if err := util.ErrOneOf(
-		w.writeMagicNumber,
-		w.writePayloadSize,
-		w.writeNameSize,
-		w.writeStreamSize,
-		w.writeID,
-		w.writeStreamID,
-		w.writeName,
-		w.writeStreamName,
-		w.writeCreatedAt,
-		w.writePayload,
-	); err != nil {
+// AppendEncode appends the encoded command to dst.
+func (c *Codec) AppendEncode(dst []byte, command *Command) ([]byte, error) {
+	payload, marks, err := c.marshalPayload(command)
+	if err != nil {
 		return nil, err
 	}
-	return w.buf.Bytes(), nil
+	w := newCommandWriter(command, payload, marks)
+	return w.append(dst), nil
+}
+
+// EncodeTo writes the encoded command to the writer using the pooled buffer.
+func (c *Codec) EncodeTo(out io.Writer, command *Command) error {
+	buf := c.buffers.Get()
+	defer c.buffers.Put(buf)
+	data, err := c.AppendEncode(*buf, command)
+	if err != nil {
+		return err
+	}
+	*buf = data
+	_, err = out.Write(data)
+	return err
+}
+
+func (c *Codec) encodeContainer(command *Command, payload []byte, marks codec.FilterMarks) ([]byte, error) {
+	w := newCommandWriter(command, payload, marks)
+	return w.write()
 }
 
-func (c *Codec) decodeContainer(data []byte) (*Command, []byte, error) {
+func (c *Codec) decodeContainer(data []byte) (*Command, []byte, codec.Extensions, error) {
 	reader := newCommandReader(data)
 	reader.container = new(Command)
 	if err := util.ErrOneOf(
 		reader.checkMagicNumber,
+		reader.readFormatVersion,
 		reader.readPayloadSize,
 		reader.readNameSize,
 		reader.readStreamSize,
@@ -109,32 +184,31 @@ func (c *Codec) decodeContainer(data []byte) (*Command, []byte, error) {
 		reader.readName,
 		reader.readStreamName,
 		reader.readCreatedAt,
+		reader.readExtensions,
+		reader.readCreatedAtNanos,
 	); err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	payload, err := reader.readPayload()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
-	return reader.container, payload, nil
+	return reader.container, payload, reader.extensions, nil
 }
 
 func (c *Codec) decodePayload(command string, data []byte) (codec.Codec, error) {
 	if len(data) == 0 {
 		return nil, nil
 	}
-	cc, found := c.codec[command]
+	cc, found := c.lookup(command)
 	if !found {
 		return nil, fmt.Errorf("command: decoder for %s payload not found", command)
 	}
-	val := reflect.New(reflect.TypeOf(cc).Elem())
-	if dec, ok := val.Interface().(encoding.BinaryUnmarshaler); ok {
-		if err := dec.UnmarshalBinary(data); err != nil {
-			return nil, err
-		}
-		return val.Interface().(codec.Codec), nil
+	payload := codec.New(cc)
+	if err := payload.UnmarshalBinary(data); err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("command: decode payload for %s", command)
+	return payload, nil
 }
 
 func (c *Codec) RegisterMap(commands map[string]codec.Codec) {
@@ -143,6 +217,31 @@ func (c *Codec) RegisterMap(commands map[string]codec.Codec) {
 	}
 }
 
+// Names returns the sorted names of the commands with the registered codec.
+func (c *Codec) Names() []string {
+	names := make([]string, 0, len(c.codec))
+	for name := range c.codec {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the codec registered for the command.
+func (c *Codec) Lookup(command string) (codec.Codec, bool) {
+	cc, found := c.codec[command]
+	return cc, found
+}
+
+// lookup returns the registered or the built-in codec of the command.
+func (c *Codec) lookup(command string) (codec.Codec, bool) {
+	if cc, found := c.codec[command]; found {
+		return cc, true
+	}
+	cc, found := builtinCodecs[command]
+	return cc, found
+}
+
 func (c *Codec) Register(command string, cc codec.Codec) {
 	if cc == nil {
 		return
@@ -162,6 +261,10 @@ func RegisterCodecs(commands map[string]codec.Codec) {
 	defaultCodec.RegisterMap(commands)
 }
 
+func Use(filters ...PayloadFilter) {
+	defaultCodec.Use(filters...)
+}
+
 func Encode(command *Command) ([]byte, error) {
 	return defaultCodec.Encode(command)
 }
@@ -170,68 +273,77 @@ func Decode(data []byte) (*Command, error) {
 	return defaultCodec.Decode(data)
 }
 
-type commandWriter struct {
-	buf       *bytes.Buffer
-	prev      uintptr
-	container *Command
-	payload   []byte
-}
-
-func newCommandWriter(c *Command, payload []byte) *commandWriter {
-	return &commandWriter{
-		buf:       bytes.NewBuffer(nil),
-		container: c,
-		payload:   payload,
-	}
-}
-
-func (w *commandWriter) writeMagicNumber() error {
-	return binary.Write(w.buf, binary.LittleEndian, commandMagicNumber)
-}
-
-func (w *commandWriter) writePayloadSize() error {
-	return binary.Write(w.buf, binary.LittleEndian, uint32(len(w.payload)))
-}
-
-func (w *commandWriter) writeNameSize() error {
-	return binary.Write(w.buf, binary.LittleEndian, uint32(len(w.container.name)))
-}
-
-func (w *commandWriter) writeStreamSize() error {
-	return binary.Write(w.buf, binary.LittleEndian, uint32(len(w.container.streamName)))
-}
-
-func (w *commandWriter) writeID() error {
-	return binary.Write(w.buf, binary.LittleEndian, w.container.id)
-}
-
-func (w *commandWriter) writeStreamID() error {
-	return binary.Write(w.buf, binary.LittleEndian, w.container.streamID)
-}
-
-func (w *commandWriter) writeName() error {
-	return binary.Write(w.buf, binary.LittleEndian, []byte(w.container.name))
+func AppendEncode(dst []byte, command *Command) ([]byte, error) {
+	return defaultCodec.AppendEncode(dst, command)
 }
 
-func (w *commandWriter) writeStreamName() error {
-	return binary.Write(w.buf, binary.LittleEndian, []byte(w.container.streamName))
+func EncodeTo(out io.Writer, command *Command) error {
+	return defaultCodec.EncodeTo(out, command)
 }
 
-func (w *commandWriter) writeCreatedAt() error {
-	return binary.Write(w.buf, binary.LittleEndian, w.container.createdAt)
+type commandWriter struct {
+	container  *Command
+	payload    []byte
+	extensions codec.Extensions
 }
 
-func (w *commandWriter) writePayload() error {
-	return binary.Write(w.buf, binary.LittleEndian, w.payload)
+func newCommandWriter(c *Command, payload []byte, marks codec.FilterMarks) *commandWriter {
+	w := &commandWriter{
+		container: c,
+		payload:   payload,
+	}
+	if len(marks) > 0 {
+		w.extensions = codec.Extensions{extPayloadFilters: marks}
+	}
+	return w
+}
+
+func (w *commandWriter) write() ([]byte, error) {
+	return w.append(make([]byte, 0, w.size())), nil
+}
+
+// size returns the capacity enough for the container.
+func (w *commandWriter) size() int {
+	return containerSize + 1 + 4 + nanosExtensionSize + w.extensions.Size() +
+		len(w.container.name) + len(w.container.streamName) + len(w.payload)
+}
+
+func (w *commandWriter) append(dst []byte) []byte {
+	c := w.container
+	sec, nsec := codec.SplitTimestamp(c.createdAt)
+	dst = codec.Grow(dst, w.size())
+	dst = codec.AppendUint16(dst, commandFormatMagicNumber)
+	dst = append(dst, FormatVersion)
+	dst = codec.AppendUint32(dst, uint32(len(w.payload)))
+	dst = codec.AppendUint32(dst, uint32(len(c.name)))
+	dst = codec.AppendUint32(dst, uint32(len(c.streamName)))
+	dst = append(dst, c.id[:]...)
+	dst = append(dst, c.streamID[:]...)
+	dst = append(dst, c.name...)
+	dst = append(dst, c.streamName...)
+	dst = codec.AppendUint64(dst, uint64(sec))
+	dst, offset := codec.BeginExtensions(dst)
+	if len(w.extensions) > 0 {
+		w.extensions.SetNanos(extCreatedAtNanos, nsec)
+		dst = w.extensions.AppendBinary(dst)
+	} else {
+		dst = codec.AppendNanos(dst, extCreatedAtNanos, nsec)
+	}
+	dst = codec.EndExtensions(dst, offset)
+	return append(dst, w.payload...)
 }
 
 type commandReader struct {
 	reader      *bytes.Reader
 	data        []byte
 	prev        uintptr
+	versioned   bool
+	format      uint8
 	nameSize    uint32
 	streamSize  uint32
 	payloadSize uint32
+	createdAt   int64
+	extensions  codec.Extensions
 	container   *Command
 }
 
@@ -243,8 +355,11 @@ func newCommandReader(data []byte) *commandReader {
 }
 
 func (r *commandReader) next(offset uintptr) {
-	r.reader.Reset(r.data[r.prev : r.prev+offset])
-	r.prev += offset
+	r.prev = nextChunk(r.reader, r.data, r.prev, offset)
+}
+
+func (r *commandReader) remaining() uintptr {
+	return uintptr(len(r.data)) - r.prev
 }
 
 func (r *commandReader) checkMagicNumber() error {
@@ -253,12 +368,31 @@ func (r *commandReader) checkMagicNumber() error {
 	if err := binary.Read(r.reader, binary.LittleEndian, &val); err != nil {
 		return err
 	}
-	if val != commandMagicNumber {
+	switch val {
+	case commandMagicNumber:
+	case commandFormatMagicNumber:
+		r.versioned = true
+	default:
 		return ErrInvalidInputData
 	}
 	return nil
 }
 
+func (r *commandReader) readFormatVersion() error {
+	if !r.versioned {
+		return nil
+	}
+	r.next(unsafe.Sizeof(r.format))
+	return readFormatVersion(r.reader, &r.format)
+}
+
+func (r *commandReader) readExtensions() error {
+	if r.format < 1 {
+		return nil
+	}
+	return readExtensions(r.reader, r.next, r.remaining, &r.extensions)
+}
+
 func (r *commandReader) readNameSize() error {
 	r.next(unsafe.Sizeof(r.nameSize))
 	return binary.Read(r.reader, binary.LittleEndian, &r.nameSize)
@@ -285,8 +419,19 @@ func (r *commandReader) readStreamID() error {
 }
 
 func (r *commandReader) readCreatedAt() error {
-	r.next(unsafe.Sizeof(r.container.createdAt))
-	return binary.Read(r.reader, binary.LittleEndian, &r.container.createdAt)
+	r.next(unsafe.Sizeof(r.createdAt))
+	return binary.Read(r.reader, binary.LittleEndian, &r.createdAt)
+}
+
+// readCreatedAtNanos restores the creation time with the nanoseconds
+// from the extensions.
+func (r *commandReader) readCreatedAtNanos() error {
+	nsec, err := r.extensions.Nanos(extCreatedAtNanos)
+	if err != nil {
+		return err
+	}
+	r.container.createdAt = codec.JoinTimestamp(r.createdAt, nsec)
+	return nil
 }
 
 func (r *commandReader) readName() error {
@@ -317,3 +462,45 @@ func (r *commandReader) readPayload() ([]byte, error) {
 	}
 	return b, nil
 }
+
+// nextChunk resets the reader to the next chunk of the data and returns its end.
+// The chunk is truncated by the end of the data so the reading of the broken
+// container fails instead of panicking.
+func nextChunk(reader *bytes.Reader, data []byte, prev uintptr, offset uintptr) uintptr {
+	end := prev + offset
+	if end > uintptr(len(data)) {
+		end = uintptr(len(data))
+	}
+	reader.Reset(data[prev:end])
+	return end
+}
+
+func readFormatVersion(reader *bytes.Reader, format *uint8) error {
+	if err := binary.Read(reader, binary.LittleEndian, format); err != nil {
+		return err
+	}
+	if *format == 0 || *format > FormatVersion {
+		return ErrUnsupportedFormat
+	}
+	return nil
+}
+
+// readExtensions reads the extension area of the container.
+// The extensions with unknown tags are skipped.
+// The remaining func returns the number of the unread bytes of the container.
+func readExtensions(reader *bytes.Reader, next func(uintptr), remaining func() uintptr, ext *codec.Extensions) error {
+	var size uint32
+	next(unsafe.Sizeof(size))
+	if err := binary.Read(reader, binary.LittleEndian, &size); err != nil {
+		return err
+	}
+	if uintptr(size) > remaining() {
+		return ErrInvalidInputData
+	}
+	next(uintptr(size))
+	data := make([]byte, size)
+	if err := binary.Read(reader, binary.LittleEndian, &data); err != nil {
+		return err
+	}
+	return ext.UnmarshalBinary(data)
+}
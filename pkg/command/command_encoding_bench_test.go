@@ -0,0 +1,115 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+)
+
+func benchCommand() *Command {
+	payload := codec.Raw(`{"One":"one","Two":"two"}`)
+	return &Command{
+		id:         uuid.New(),
+		streamID:   uuid.New(),
+		name:       "some",
+		streamName: "users",
+		createdAt:  time.Unix(1634567890, 123456789),
+		payload:    &payload,
+	}
+}
+
+func benchCodec() *Codec {
+	c := NewCodec()
+	c.Register("some", &codec.Raw{})
+	return c
+}
+
+func BenchmarkCodec_Encode(b *testing.B) {
+	c := benchCodec()
+	cmd := benchCommand()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Encode(cmd); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReply_MarshalBinary(b *testing.B) {
+	r := newReply(uuid.New(), 3, errors.New("some error"))
+	r.createdAt = time.Unix(1634567890, 123456789)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.MarshalBinary(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCodec_AppendEncode(b *testing.B) {
+	c := benchCodec()
+	cmd := benchCommand()
+	buf := make([]byte, 0, 256)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var err error
+		if buf, err = c.AppendEncode(buf[:0], cmd); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReply_AppendBinary(b *testing.B) {
+	r := newReply(uuid.New(), 3, errors.New("some error"))
+	r.createdAt = time.Unix(1634567890, 123456789)
+	buf := make([]byte, 0, 256)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var err error
+		if buf, err = r.AppendBinary(buf[:0]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestCodec_AppendEncode(t *testing.T) {
+	c := benchCodec()
+	cmd := benchCommand()
+	expected, err := c.Encode(cmd)
+	assert.NoError(t, err)
+
+	data, err := c.AppendEncode([]byte("prefix"), cmd)
+	assert.NoError(t, err)
+	assert.Equal(t, append([]byte("prefix"), expected...), data)
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.EncodeTo(&buf, cmd))
+	assert.Equal(t, expected, buf.Bytes())
+
+	dst := make([]byte, 0, 256)
+	allocs := testing.AllocsPerRun(100, func() {
+		dst, _ = c.AppendEncode(dst[:0], cmd)
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestReply_AppendBinary(t *testing.T) {
+	r := newReply(uuid.New(), 3, NewValidationError(FieldError{Field: "name", Message: "required"}))
+	r.createdAt = time.Unix(1634567890, 123456789)
+	expected, err := r.MarshalBinary()
+	assert.NoError(t, err)
+	data, err := r.AppendBinary([]byte("prefix"))
+	assert.NoError(t, err)
+	assert.Equal(t, append([]byte("prefix"), expected...), data)
+
+	other := new(Reply)
+	assert.NoError(t, other.UnmarshalBinary(data[len("prefix"):]))
+	assert.Equal(t, r.Err(), other.Err())
+	assert.Equal(t, r.CreatedAt(), other.CreatedAt())
+}
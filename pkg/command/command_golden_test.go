@@ -0,0 +1,174 @@
+package command
+
+import (
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the current format version")
+
+func goldenCommand() *Command {
+	return &Command{
+		id:         uuid.MustParse("6ba7b812-9dad-11d1-80b4-00c04fd430c8"),
+		streamID:   uuid.MustParse("6ba7b811-9dad-11d1-80b4-00c04fd430c8"),
+		name:       "create",
+		streamName: "users",
+		createdAt:  time.Unix(1634567890, 0),
+		payload:    &some{One: "one", Two: "two"},
+	}
+}
+
+func goldenReply() *Reply {
+	return &Reply{
+		command:   uuid.MustParse("6ba7b812-9dad-11d1-80b4-00c04fd430c8"),
+		createdAt: time.Unix(1634567891, 0),
+		err:       errors.New("failed"),
+		version:   3,
+	}
+}
+
+func goldenFile(container string, version uint8) string {
+	return filepath.Join("testdata", fmt.Sprintf("%s.v%d.golden", container, version))
+}
+
+func checkGolden(t *testing.T, container string, data []byte) {
+	if *update {
+		assert.NoError(t, os.WriteFile(goldenFile(container, FormatVersion), data, 0644))
+	}
+	golden, err := os.ReadFile(goldenFile(container, FormatVersion))
+	assert.NoError(t, err)
+	assert.Equal(t, golden, data)
+}
+
+func TestCodec_GoldenFiles(t *testing.T) {
+	c := NewCodec()
+	c.Register("create", &some{})
+	expected := goldenCommand()
+	data, err := c.Encode(expected)
+	assert.NoError(t, err)
+	checkGolden(t, "command", data)
+
+	for version := uint8(0); version <= FormatVersion; version++ {
+		data, err := os.ReadFile(goldenFile("command", version))
+		assert.NoError(t, err)
+		cmd, err := c.Decode(data)
+		if !assert.NoError(t, err, "version %d", version) {
+			continue
+		}
+		assert.Equal(t, expected.ID(), cmd.ID())
+		assert.Equal(t, expected.StreamID(), cmd.StreamID())
+		assert.Equal(t, expected.Name(), cmd.Name())
+		assert.Equal(t, expected.StreamName(), cmd.StreamName())
+		assert.Equal(t, expected.Unix(), cmd.Unix())
+		assert.Equal(t, expected.Payload(), cmd.Payload())
+	}
+}
+
+func TestReply_GoldenFiles(t *testing.T) {
+	expected := goldenReply()
+	data, err := expected.MarshalBinary()
+	assert.NoError(t, err)
+	checkGolden(t, "reply", data)
+
+	for version := uint8(0); version <= FormatVersion; version++ {
+		data, err := os.ReadFile(goldenFile("reply", version))
+		assert.NoError(t, err)
+		reply := new(Reply)
+		if !assert.NoError(t, reply.UnmarshalBinary(data), "version %d", version) {
+			continue
+		}
+		assert.Equal(t, expected.Command(), reply.Command())
+		assert.Equal(t, expected.Unix(), reply.Unix())
+		assert.Equal(t, expected.StreamVersion(), reply.StreamVersion())
+		assert.Equal(t, expected.Err(), reply.Err())
+	}
+}
+
+func TestCodec_DecodeSkipsUnknownExtensions(t *testing.T) {
+	c := NewCodec()
+	c.Register("create", &some{})
+	expected := goldenCommand()
+	payload, err := expected.Payload().MarshalBinary()
+	assert.NoError(t, err)
+	w := newCommandWriter(expected, payload, nil)
+	w.extensions = codec.Extensions{1000: []byte("unknown")}
+	data, err := w.write()
+	assert.NoError(t, err)
+	cmd, err := c.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.ID(), cmd.ID())
+	assert.Equal(t, expected.Payload(), cmd.Payload())
+
+	rw := replyWriter{
+		container:  goldenReply(),
+		extensions: codec.Extensions{1000: []byte("unknown")},
+	}
+	data, err = rw.write()
+	assert.NoError(t, err)
+	reply := new(Reply)
+	assert.NoError(t, reply.UnmarshalBinary(data))
+	assert.Equal(t, rw.container.Err(), reply.Err())
+}
+
+func TestCodec_DecodeUnsupportedFormat(t *testing.T) {
+	for _, container := range []string{"command", "reply"} {
+		golden, err := os.ReadFile(goldenFile(container, FormatVersion))
+		assert.NoError(t, err)
+		data := append([]byte(nil), golden...)
+		data[2] = FormatVersion + 1
+		if container == "command" {
+			_, err = NewCodec().Decode(data)
+		} else {
+			err = new(Reply).UnmarshalBinary(data)
+		}
+		assert.ErrorIs(t, err, ErrUnsupportedFormat)
+	}
+}
+
+func TestCodec_CreatedAtNanos(t *testing.T) {
+	c := NewCodec()
+	c.Register("create", &some{})
+	expected := goldenCommand()
+	expected.createdAt = time.Unix(1634567890, 123456789)
+	data, err := c.Encode(expected)
+	assert.NoError(t, err)
+	cmd, err := c.Decode(data)
+	assert.NoError(t, err)
+	assert.True(t, expected.CreatedAt().Equal(cmd.CreatedAt()))
+
+	reply := goldenReply()
+	reply.createdAt = time.Unix(1634567891, 987654321)
+	data, err = reply.MarshalBinary()
+	assert.NoError(t, err)
+	reply2 := new(Reply)
+	assert.NoError(t, reply2.UnmarshalBinary(data))
+	assert.True(t, reply.CreatedAt().Equal(reply2.CreatedAt()))
+}
+
+func TestCodec_DecodeOversizedExtensions(t *testing.T) {
+	c := NewCodec()
+	c.Register("create", &some{})
+	cmd := goldenCommand()
+	data, err := c.Encode(cmd)
+	assert.NoError(t, err)
+	offset := 2 + 1 + 3*4 + 2*16 + len(cmd.Name()) + len(cmd.StreamName()) + 8
+	binary.LittleEndian.PutUint32(data[offset:], math.MaxUint32)
+	_, err = c.Decode(data)
+	assert.ErrorIs(t, err, ErrInvalidInputData)
+
+	data, err = goldenReply().MarshalBinary()
+	assert.NoError(t, err)
+	binary.LittleEndian.PutUint32(data[2+1+4+16+8+8:], math.MaxUint32)
+	assert.ErrorIs(t, new(Reply).UnmarshalBinary(data), ErrInvalidInputData)
+}
@@ -2,6 +2,10 @@ package command
 
 import (
 	"testing"
+	"time"
+
+	"github.com/go-gulfstream/gulfstream/pkg/clock"
+	"github.com/go-gulfstream/gulfstream/pkg/idgen"
 
 	"github.com/stretchr/testify/assert"
 
@@ -39,3 +43,14 @@ func (a addCard) MarshalBinary() ([]byte, error) {
 func (a addCard) UnmarshalBinary(data []byte) error {
 	return nil
 }
+
+func TestNewWithClock(t *testing.T) {
+	now := time.Date(2021, 10, 18, 12, 0, 0, 0, time.UTC)
+	c := clock.NewFake(now)
+	cmd := New("addCard", "account", uuid.New(), nil,
+		WithClock(c), WithIDGenerator(idgen.NewSequence(1)))
+	assert.Equal(t, now.Unix(), cmd.Unix())
+	assert.Equal(t, "00000000-0000-0001-0000-000000000001", cmd.ID().String())
+	c.Advance(time.Minute)
+	assert.Equal(t, now.Add(time.Minute).Unix(), cmd.ReplyOk(1).Unix())
+}
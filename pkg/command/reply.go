@@ -4,14 +4,17 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+
 	"github.com/google/uuid"
 )
 
 type Reply struct {
 	command   uuid.UUID
-	createdAt int64
+	createdAt time.Time
 	err       error
 	version   int
+	result    codec.Codec
 }
 
 func newReply(commandID uuid.UUID, version int, err error) *Reply {
@@ -19,13 +22,13 @@ func newReply(commandID uuid.UUID, version int, err error) *Reply {
 		version:   version,
 		err:       err,
 		command:   commandID,
-		createdAt: time.Now().Unix(),
+		createdAt: time.Now(),
 	}
 }
 
 func (r *Reply) String() string {
-	return fmt.Sprintf("CommandReply{Command:%s, Version:%d, Err:%v}",
-		r.command, r.version, r.err)
+	return fmt.Sprintf("CommandReply{Command:%s, Version:%d, Err:%v, Result:%v}",
+		r.command, r.version, r.err, r.result)
 }
 
 func (r *Reply) StreamVersion() int {
@@ -37,9 +40,20 @@ func (r *Reply) Command() uuid.UUID {
 }
 
 func (r *Reply) Unix() int64 {
+	return r.createdAt.Unix()
+}
+
+// CreatedAt returns the creation time of the reply.
+func (r *Reply) CreatedAt() time.Time {
 	return r.createdAt
 }
 
 func (r *Reply) Err() error {
 	return r.err
 }
+
+// Result returns the result payload of the command. The result decoded without
+// the registered codec is returned as *codec.Raw.
+func (r *Reply) Result() codec.Codec {
+	return r.result
+}
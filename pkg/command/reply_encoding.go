@@ -2,95 +2,160 @@ package command
 
 import (
 	"bytes"
+	"encoding"
 	"encoding/binary"
 	"errors"
 	"unsafe"
 
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
 	"github.com/go-gulfstream/gulfstream/pkg/util"
 )
 
+const (
+	replyContainerSize = 38
+
+	extensionHeaderSize = 6
+
+	extValidationError   = uint16(1)
+	extUnauthorizedError = uint16(2)
+	extResult            = uint16(3)
+	extReplyCreatedAt    = uint16(4)
+	extVersionNotReached = uint16(5)
+)
+
+type typedError interface {
+	error
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
 func (r *Reply) MarshalBinary() ([]byte, error) {
-	w := newReplyWriter()
-	w.container = r
-	if err := util.ErrOneOf(
-		w.writeMagicNumber,
-		w.writeErrorSize,
-		w.writeCommand,
-		w.writeCreatedAt,
-		w.writeVersion,
-		w.writErr,
-	); err != nil {
-		return nil, err
+	return r.AppendBinary(nil)
+}
+
+// AppendBinary appends the encoded reply to dst.
+func (r *Reply) AppendBinary(dst []byte) ([]byte, error) {
+	w := replyWriter{container: r}
+	var (
+		verr *ValidationError
+		uerr *UnauthorizedError
+		nerr *VersionNotReachedError
+	)
+	var typed typedError
+	switch {
+	case errors.As(r.err, &verr):
+		w.typedTag, typed = extValidationError, verr
+	case errors.As(r.err, &uerr):
+		w.typedTag, typed = extUnauthorizedError, uerr
+	case errors.As(r.err, &nerr):
+		w.typedTag, typed = extVersionNotReached, nerr
+	}
+	if typed != nil {
+		data, err := typed.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		w.typed = data
 	}
-	return w.buf.Bytes(), nil
+	if r.result != nil {
+		data, err := r.result.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		w.result = data
+	}
+	return w.append(dst), nil
 }
 
 func (r *Reply) UnmarshalBinary(data []byte) error {
-	if len(data) < 38 {
+	if len(data) < replyContainerSize {
 		return ErrInvalidInputData
 	}
 	reader := newReplyReader(data)
 	reader.container = r
 	return util.ErrOneOf(
 		reader.checkMagicNumber,
+		reader.readFormatVersion,
 		reader.readErrorSize,
 		reader.readCommand,
 		reader.readCreatedAt,
 		reader.readVersion,
+		reader.readExtensions,
+		reader.readCreatedAtNanos,
 		reader.readErr,
+		reader.readTypedError,
+		reader.readResult,
 	)
 }
 
 type replyWriter struct {
-	buf       *bytes.Buffer
-	prev      uintptr
-	container *Reply
+	container  *Reply
+	typedTag   uint16
+	typed      []byte
+	result     []byte
+	extensions codec.Extensions
 }
 
-func newReplyWriter() *replyWriter {
-	return &replyWriter{
-		buf: bytes.NewBuffer(nil),
-	}
+func (w *replyWriter) write() ([]byte, error) {
+	return w.append(nil), nil
 }
 
-func (w *replyWriter) writeMagicNumber() error {
-	return binary.Write(w.buf, binary.LittleEndian, replyMagicNumber)
-}
-
-func (w *replyWriter) writErr() error {
-	var err []byte
-	if w.container.err != nil {
-		err = []byte(w.container.err.Error())
+func (w *replyWriter) append(dst []byte) []byte {
+	r := w.container
+	var errText string
+	if r.err != nil {
+		errText = r.err.Error()
 	}
-	return binary.Write(w.buf, binary.LittleEndian, err)
-}
-
-func (w *replyWriter) writeErrorSize() error {
-	var size uint32
-	if w.container.err != nil {
-		size = uint32(len(w.container.err.Error()))
+	sec, nsec := codec.SplitTimestamp(r.createdAt)
+	dst = codec.Grow(dst, replyContainerSize+4+3*extensionHeaderSize+nanosExtensionSize+
+		len(w.typed)+len(w.result)+w.extensions.Size()+len(errText))
+	dst = codec.AppendUint16(dst, replyFormatMagicNumber)
+	dst = append(dst, FormatVersion)
+	dst = codec.AppendUint32(dst, uint32(len(errText)))
+	dst = append(dst, r.command[:]...)
+	dst = codec.AppendUint64(dst, uint64(sec))
+	dst = codec.AppendUint64(dst, uint64(r.version))
+	dst, offset := codec.BeginExtensions(dst)
+	if len(w.extensions) > 0 {
+		w.mergeExtensions(nsec)
+		dst = w.extensions.AppendBinary(dst)
+	} else {
+		// the known extensions are appended in the order of the tags.
+		if w.typed != nil && w.typedTag < extResult {
+			dst = codec.AppendExtension(dst, w.typedTag, w.typed)
+		}
+		if w.result != nil {
+			dst = codec.AppendExtension(dst, extResult, w.result)
+		}
+		dst = codec.AppendNanos(dst, extReplyCreatedAt, nsec)
+		if w.typed != nil && w.typedTag > extReplyCreatedAt {
+			dst = codec.AppendExtension(dst, w.typedTag, w.typed)
+		}
 	}
-	return binary.Write(w.buf, binary.LittleEndian, size)
-}
-
-func (w *replyWriter) writeCommand() error {
-	return binary.Write(w.buf, binary.LittleEndian, w.container.command)
-}
-
-func (w *replyWriter) writeVersion() error {
-	return binary.Write(w.buf, binary.LittleEndian, int64(w.container.version))
+	dst = codec.EndExtensions(dst, offset)
+	return append(dst, errText...)
 }
 
-func (w *replyWriter) writeCreatedAt() error {
-	return binary.Write(w.buf, binary.LittleEndian, w.container.createdAt)
+func (w *replyWriter) mergeExtensions(nsec uint32) {
+	if w.typed != nil {
+		w.extensions[w.typedTag] = w.typed
+	}
+	if w.result != nil {
+		w.extensions[extResult] = w.result
+	}
+	w.extensions.SetNanos(extReplyCreatedAt, nsec)
 }
 
 type replyReader struct {
-	reader    *bytes.Reader
-	data      []byte
-	prev      uintptr
-	errSize   uint32
-	container *Reply
+	reader     *bytes.Reader
+	data       []byte
+	prev       uintptr
+	versioned  bool
+	format     uint8
+	errSize    uint32
+	createdAt  int64
+	extensions codec.Extensions
+	container  *Reply
 }
 
 func newReplyReader(data []byte) *replyReader {
@@ -101,8 +166,26 @@ func newReplyReader(data []byte) *replyReader {
 }
 
 func (r *replyReader) next(offset uintptr) {
-	r.reader.Reset(r.data[r.prev : r.prev+offset])
-	r.prev += offset
+	r.prev = nextChunk(r.reader, r.data, r.prev, offset)
+}
+
+func (r *replyReader) remaining() uintptr {
+	return uintptr(len(r.data)) - r.prev
+}
+
+func (r *replyReader) readFormatVersion() error {
+	if !r.versioned {
+		return nil
+	}
+	r.next(unsafe.Sizeof(r.format))
+	return readFormatVersion(r.reader, &r.format)
+}
+
+func (r *replyReader) readExtensions() error {
+	if r.format < 1 {
+		return nil
+	}
+	return readExtensions(r.reader, r.next, r.remaining, &r.extensions)
 }
 
 func (r *replyReader) readErr() error {
@@ -118,6 +201,36 @@ func (r *replyReader) readErr() error {
 	return nil
 }
 
+// readTypedError restores the typed error of the reply from the extensions.
+func (r *replyReader) readTypedError() error {
+	var typed typedError
+	data, found := r.extensions.Get(extValidationError)
+	if found {
+		typed = new(ValidationError)
+	} else if data, found = r.extensions.Get(extUnauthorizedError); found {
+		typed = new(UnauthorizedError)
+	} else if data, found = r.extensions.Get(extVersionNotReached); found {
+		typed = new(VersionNotReachedError)
+	} else {
+		return nil
+	}
+	if err := typed.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	r.container.err = typed
+	return nil
+}
+
+func (r *replyReader) readResult() error {
+	data, found := r.extensions.Get(extResult)
+	if !found {
+		return nil
+	}
+	raw := codec.Raw(data)
+	r.container.result = &raw
+	return nil
+}
+
 func (r *replyReader) readErrorSize() error {
 	r.next(unsafe.Sizeof(r.errSize))
 	return binary.Read(r.reader, binary.LittleEndian, &r.errSize)
@@ -139,8 +252,19 @@ func (r *replyReader) readVersion() error {
 }
 
 func (r *replyReader) readCreatedAt() error {
-	r.next(unsafe.Sizeof(r.container.createdAt))
-	return binary.Read(r.reader, binary.LittleEndian, &r.container.createdAt)
+	r.next(unsafe.Sizeof(r.createdAt))
+	return binary.Read(r.reader, binary.LittleEndian, &r.createdAt)
+}
+
+// readCreatedAtNanos restores the creation time with the nanoseconds
+// from the extensions.
+func (r *replyReader) readCreatedAtNanos() error {
+	nsec, err := r.extensions.Nanos(extReplyCreatedAt)
+	if err != nil {
+		return err
+	}
+	r.container.createdAt = codec.JoinTimestamp(r.createdAt, nsec)
+	return nil
 }
 
 func (r *replyReader) checkMagicNumber() error {
@@ -149,7 +273,11 @@ func (r *replyReader) checkMagicNumber() error {
 	if err := binary.Read(r.reader, binary.LittleEndian, &val); err != nil {
 		return err
 	}
-	if val != replyMagicNumber {
+	switch val {
+	case replyMagicNumber:
+	case replyFormatMagicNumber:
+		r.versioned = true
+	default:
 		return ErrInvalidInputData
 	}
 	return nil
@@ -33,3 +33,14 @@ func TestReply_UnmarshalBinaryWithError(t *testing.T) {
 	assert.Nil(t, reply2.UnmarshalBinary(data))
 	assert.Equal(t, reply.Err(), reply2.Err())
 }
+
+func TestReply_UnmarshalBinaryVersionNotReached(t *testing.T) {
+	nerr := &VersionNotReachedError{Version: 3, Reason: "context deadline exceeded"}
+	reply := newReply(uuid.New(), 0, nerr)
+	data, err := reply.MarshalBinary()
+	assert.NoError(t, err)
+	reply2 := new(Reply)
+	assert.NoError(t, reply2.UnmarshalBinary(data))
+	assert.True(t, IsVersionNotReached(reply2.Err()))
+	assert.Equal(t, nerr, reply2.Err())
+}
@@ -0,0 +1,62 @@
+package command
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+)
+
+// ReplyDecoding decodes the reply of the command with its result payload.
+type ReplyDecoding interface {
+	DecodeReply(cmd *Command, data []byte) (*Reply, error)
+}
+
+// RegisterResult registers the codec of the result payload
+// replied to the command.
+func (c *Codec) RegisterResult(command string, cc codec.Codec) {
+	if cc == nil {
+		return
+	}
+	if reflect.ValueOf(cc).Kind() != reflect.Ptr {
+		panic("command: Codec.RegisterResult(non-pointer " + command + ")")
+	}
+	c.results[command] = cc
+}
+
+// DecodeReply decodes the reply and its result payload by the codec
+// registered for the command. Without the codec the result stays *codec.Raw.
+func (c *Codec) DecodeReply(cmd *Command, data []byte) (*Reply, error) {
+	reply := new(Reply)
+	if err := reply.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	raw, ok := reply.result.(*codec.Raw)
+	if !ok {
+		return reply, nil
+	}
+	cc, found := c.results[cmd.Name()]
+	if !found {
+		return reply, nil
+	}
+	result := codec.New(cc)
+	if err := result.UnmarshalBinary(*raw); err != nil {
+		return nil, fmt.Errorf("command: decode result of %s: %w", cmd.Name(), err)
+	}
+	reply.result = result
+	return reply, nil
+}
+
+func RegisterResult(command string, cc codec.Codec) {
+	defaultCodec.RegisterResult(command, cc)
+}
+
+func DecodeReply(cmd *Command, data []byte) (*Reply, error) {
+	return defaultCodec.DecodeReply(cmd, data)
+}
+
+// LookupResult returns the codec of the result registered for the command.
+func (c *Codec) LookupResult(command string) (codec.Codec, bool) {
+	cc, found := c.results[command]
+	return cc, found
+}
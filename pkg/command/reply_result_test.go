@@ -0,0 +1,42 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type orderCreated struct {
+	Number int
+}
+
+func TestCodec_DecodeReply(t *testing.T) {
+	cmd := New("create", "orders", uuid.New(), nil)
+	reply := cmd.ReplyResult(1, codec.JSON(&orderCreated{Number: 42}))
+	data, err := reply.MarshalBinary()
+	assert.NoError(t, err)
+
+	untyped, err := NewCodec().DecodeReply(cmd, data)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, untyped.StreamVersion())
+	raw := codec.Raw(`{"Number":42}`)
+	assert.Equal(t, &raw, untyped.Result())
+
+	c := NewCodec()
+	c.RegisterResult("create", codec.JSON(&orderCreated{}))
+	typed, err := c.DecodeReply(cmd, data)
+	assert.NoError(t, err)
+	assert.Equal(t, &orderCreated{Number: 42}, codec.Unwrap(typed.Result()))
+
+	typed, err = c.DecodeReply(cmd, mustMarshalReply(t, cmd.ReplyOk(2)))
+	assert.NoError(t, err)
+	assert.Nil(t, typed.Result())
+}
+
+func mustMarshalReply(t *testing.T, r *Reply) []byte {
+	data, err := r.MarshalBinary()
+	assert.NoError(t, err)
+	return data
+}
@@ -0,0 +1,45 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// UnauthorizedError is returned in the reply when the principal
+// may not send the command to the stream.
+type UnauthorizedError struct {
+	Principal  string
+	StreamName string
+	Command    string
+	Reason     string
+}
+
+func (e *UnauthorizedError) Error() string {
+	principal := e.Principal
+	if len(principal) == 0 {
+		principal = "anonymous"
+	}
+	msg := fmt.Sprintf("command: %s is not allowed to send %s.%s", principal, e.StreamName, e.Command)
+	if len(e.Reason) > 0 {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+func (e *UnauthorizedError) MarshalBinary() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := writeStrings(buf, e.Principal, e.StreamName, e.Command, e.Reason); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *UnauthorizedError) UnmarshalBinary(data []byte) error {
+	return readStrings(bytes.NewReader(data), &e.Principal, &e.StreamName, &e.Command, &e.Reason)
+}
+
+func IsUnauthorized(err error) bool {
+	var target *UnauthorizedError
+	return errors.As(err, &target)
+}
@@ -0,0 +1,168 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+)
+
+const (
+	CodeInvalid  = "invalid"
+	CodeRequired = "required"
+)
+
+// Validator is implemented by the command payloads which validate themselves.
+type Validator interface {
+	Validate() error
+}
+
+// ValidatorFunc validates the command before the stream is loaded.
+type ValidatorFunc func(ctx context.Context, cmd *Command) error
+
+// FieldError describes the invalid field of the command payload.
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+func (e FieldError) String() string {
+	if len(e.Field) == 0 {
+		return e.Code + ": " + e.Message
+	}
+	return e.Field + ": " + e.Code + ": " + e.Message
+}
+
+// ValidationError is the structured error of the command validation.
+// It is encoded in the command reply and restored by the clients.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func NewValidationError(fields ...FieldError) *ValidationError {
+	return &ValidationError{Fields: fields}
+}
+
+// Add adds the field error and returns the validation error itself.
+func (e *ValidationError) Add(field, code, message string) *ValidationError {
+	e.Fields = append(e.Fields, FieldError{
+		Field:   field,
+		Code:    code,
+		Message: message,
+	})
+	return e
+}
+
+// ErrorOrNil returns nil if there are no field errors.
+func (e *ValidationError) ErrorOrNil() error {
+	if e == nil || len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		fields[i] = field.String()
+	}
+	return "command: validation failed: " + strings.Join(fields, "; ")
+}
+
+func (e *ValidationError) MarshalBinary() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := binary.Write(buf, binary.LittleEndian, uint32(len(e.Fields))); err != nil {
+		return nil, err
+	}
+	for _, field := range e.Fields {
+		if err := writeStrings(buf, field.Field, field.Code, field.Message); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *ValidationError) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	var size uint32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return ErrInvalidInputData
+	}
+	if int(size) > r.Len() {
+		return ErrInvalidInputData
+	}
+	fields := make([]FieldError, size)
+	for i := range fields {
+		if err := readStrings(r, &fields[i].Field, &fields[i].Code, &fields[i].Message); err != nil {
+			return err
+		}
+	}
+	e.Fields = fields
+	return nil
+}
+
+func writeStrings(buf *bytes.Buffer, vals ...string) error {
+	for _, s := range vals {
+		if err := binary.Write(buf, binary.LittleEndian, uint32(len(s))); err != nil {
+			return err
+		}
+		buf.WriteString(s)
+	}
+	return nil
+}
+
+func readStrings(r *bytes.Reader, vals ...*string) error {
+	for _, s := range vals {
+		var n uint32
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return ErrInvalidInputData
+		}
+		if int(n) > r.Len() {
+			return ErrInvalidInputData
+		}
+		v := make([]byte, n)
+		if _, err := r.Read(v); err != nil && n > 0 {
+			return ErrInvalidInputData
+		}
+		*s = string(v)
+	}
+	return nil
+}
+
+// Validate runs the payload validator and the validator functions of the command.
+// The plain values wrapped by codec.Wrap are validated too.
+// The errors are returned as *ValidationError.
+func Validate(ctx context.Context, cmd *Command, validators ...ValidatorFunc) error {
+	verr := new(ValidationError)
+	if v, ok := payloadValidator(cmd.Payload()); ok {
+		appendValidationError(verr, v.Validate())
+	}
+	for _, validate := range validators {
+		appendValidationError(verr, validate(ctx, cmd))
+	}
+	return verr.ErrorOrNil()
+}
+
+func payloadValidator(payload codec.Codec) (Validator, bool) {
+	if v, ok := payload.(Validator); ok {
+		return v, true
+	}
+	v, ok := codec.Unwrap(payload).(Validator)
+	return v, ok
+}
+
+func appendValidationError(verr *ValidationError, err error) {
+	if err == nil {
+		return
+	}
+	var target *ValidationError
+	if errors.As(err, &target) {
+		verr.Fields = append(verr.Fields, target.Fields...)
+		return
+	}
+	verr.Add("", CodeInvalid, err.Error())
+}
@@ -0,0 +1,99 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+)
+
+type createUser struct {
+	Name  string
+	Email string
+}
+
+func (p *createUser) MarshalBinary() ([]byte, error) { return nil, nil }
+func (p *createUser) UnmarshalBinary(_ []byte) error { return nil }
+
+func (p *createUser) Validate() error {
+	verr := NewValidationError()
+	if len(p.Name) == 0 {
+		verr.Add("name", CodeRequired, "name is required")
+	}
+	return verr.ErrorOrNil()
+}
+
+func TestValidate(t *testing.T) {
+	cmd := New("create", "users", uuid.New(), &createUser{Email: "john"})
+	err := Validate(context.Background(), cmd,
+		func(ctx context.Context, cmd *Command) error {
+			return NewValidationError(FieldError{Field: "email", Code: "email", Message: "invalid email"})
+		},
+		func(ctx context.Context, cmd *Command) error {
+			return errors.New("user exists")
+		},
+	)
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, []FieldError{
+		{Field: "name", Code: CodeRequired, Message: "name is required"},
+		{Field: "email", Code: "email", Message: "invalid email"},
+		{Code: CodeInvalid, Message: "user exists"},
+	}, verr.Fields)
+
+	cmd = New("create", "users", uuid.New(), &createUser{Name: "John"})
+	assert.NoError(t, Validate(context.Background(), cmd))
+}
+
+type renameUser struct {
+	Name string
+}
+
+func (p *renameUser) Validate() error {
+	if len(p.Name) == 0 {
+		return NewValidationError(FieldError{Field: "name", Code: CodeRequired, Message: "name is required"})
+	}
+	return nil
+}
+
+func TestValidate_WrappedPayload(t *testing.T) {
+	cmd := New("rename", "users", uuid.New(), codec.JSON(&renameUser{}))
+	err := Validate(context.Background(), cmd)
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+	assert.Equal(t, []FieldError{
+		{Field: "name", Code: CodeRequired, Message: "name is required"},
+	}, verr.Fields)
+
+	cmd = New("rename", "users", uuid.New(), codec.JSON(&renameUser{Name: "John"}))
+	assert.NoError(t, Validate(context.Background(), cmd))
+}
+
+func TestReply_ValidationError(t *testing.T) {
+	verr := NewValidationError().
+		Add("name", CodeRequired, "name is required").
+		Add("email", "email", "")
+	reply := newReply(uuid.New(), 0, verr)
+	data, err := reply.MarshalBinary()
+	assert.NoError(t, err)
+	reply2 := new(Reply)
+	assert.NoError(t, reply2.UnmarshalBinary(data))
+	assert.Equal(t, verr, reply2.Err())
+	assert.Equal(t, verr.Error(), reply2.Err().Error())
+}
+
+func TestReply_UnauthorizedError(t *testing.T) {
+	uerr := &UnauthorizedError{Principal: "john", StreamName: "users", Command: "rename", Reason: "not the owner"}
+	reply := newReply(uuid.New(), 0, uerr)
+	data, err := reply.MarshalBinary()
+	assert.NoError(t, err)
+	reply2 := new(Reply)
+	assert.NoError(t, reply2.UnmarshalBinary(data))
+	assert.Equal(t, uerr, reply2.Err())
+	assert.True(t, IsUnauthorized(reply2.Err()))
+	assert.Equal(t, "command: john is not allowed to send users.rename: not the owner", reply2.Err().Error())
+}
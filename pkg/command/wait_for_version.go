@@ -0,0 +1,86 @@
+package command
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+)
+
+// WaitForVersionCommand is the built-in command asking the server to wait
+// until the projections apply the version of the stream.
+// The codecs encode it without the registration.
+const WaitForVersionCommand = "gulfstream.waitForVersion"
+
+// VersionWait is the payload of WaitForVersionCommand.
+// The server stops waiting after the timeout if it is set.
+type VersionWait struct {
+	Version int
+	Timeout time.Duration
+}
+
+func NewWaitForVersion(
+	streamName string,
+	streamID uuid.UUID,
+	version int,
+	timeout time.Duration,
+	opts ...Option,
+) *Command {
+	return New(WaitForVersionCommand, streamName, streamID,
+		&VersionWait{Version: version, Timeout: timeout}, opts...)
+}
+
+func (w *VersionWait) MarshalBinary() ([]byte, error) {
+	data := codec.AppendUint64(make([]byte, 0, 16), uint64(w.Version))
+	return codec.AppendUint64(data, uint64(w.Timeout)), nil
+}
+
+func (w *VersionWait) UnmarshalBinary(data []byte) error {
+	if len(data) != 16 {
+		return ErrInvalidInputData
+	}
+	w.Version = int(binary.LittleEndian.Uint64(data))
+	w.Timeout = time.Duration(binary.LittleEndian.Uint64(data[8:]))
+	return nil
+}
+
+// VersionNotReachedError is returned in the reply of WaitForVersionCommand
+// when the server stops waiting before the version is applied.
+type VersionNotReachedError struct {
+	Version int
+	Reason  string
+}
+
+func (e *VersionNotReachedError) Error() string {
+	return fmt.Sprintf("command: version %d not reached: %s", e.Version, e.Reason)
+}
+
+func (e *VersionNotReachedError) MarshalBinary() ([]byte, error) {
+	buf := bytes.NewBuffer(codec.AppendUint64(nil, uint64(e.Version)))
+	if err := writeStrings(buf, e.Reason); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *VersionNotReachedError) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return ErrInvalidInputData
+	}
+	e.Version = int(binary.LittleEndian.Uint64(data))
+	return readStrings(bytes.NewReader(data[8:]), &e.Reason)
+}
+
+func IsVersionNotReached(err error) bool {
+	var target *VersionNotReachedError
+	return errors.As(err, &target)
+}
+
+var builtinCodecs = map[string]codec.Codec{
+	WaitForVersionCommand: &VersionWait{},
+}
@@ -7,8 +7,11 @@ import (
 	"github.com/go-gulfstream/gulfstream/pkg/commandbus/grpc/proto"
 
 	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/resilience"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type ClientRequestFunc func(metadata.MD, *command.Command)
@@ -87,14 +90,14 @@ func (c *Client) CommandSink(ctx context.Context, cmd *command.Command) (*comman
 	ctx = metadata.NewOutgoingContext(ctx, md)
 	resp, err := c.client.CommandSink(ctx, &proto.Request{Data: data}, c.callOpts...)
 	if err != nil {
-		return nil, err
+		return nil, callError(err)
 	}
 	if len(resp.Error) > 0 {
 		return nil, c.decodeError(resp.Error)
 	}
 
-	reply := new(command.Reply)
-	if err := reply.UnmarshalBinary(resp.Data); err != nil {
+	reply, err := c.decodeReply(cmd, resp.Data)
+	if err != nil {
 		return nil, err
 	}
 	for _, respFunc := range c.responseFunc {
@@ -103,6 +106,24 @@ func (c *Client) CommandSink(ctx context.Context, cmd *command.Command) (*comman
 	return reply, nil
 }
 
+// callError marks the errors after which the command may be sent again.
+// Only the calls that did not reach the server are transient,
+// the other codes can be retried with resilience.WithRetryOn.
+func callError(err error) error {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return resilience.Transient(err)
+	}
+	return err
+}
+
+func (c *Client) decodeReply(cmd *command.Command, data []byte) (*command.Reply, error) {
+	if codec, ok := c.commandCodec.(command.ReplyDecoding); ok {
+		return codec.DecodeReply(cmd, data)
+	}
+	return command.DecodeReply(cmd, data)
+}
+
 func (c *Client) encodeCommand(cmd *command.Command) ([]byte, error) {
 	if c.commandCodec != nil {
 		return c.commandCodec.Encode(cmd)
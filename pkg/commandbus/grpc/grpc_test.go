@@ -28,6 +28,7 @@ import (
 	"github.com/go-gulfstream/gulfstream/pkg/stream"
 
 	"github.com/go-gulfstream/gulfstream/pkg/event"
+	"github.com/go-gulfstream/gulfstream/pkg/resilience"
 )
 
 func TestClientServer(t *testing.T) {
@@ -135,6 +136,19 @@ func TestServerInterceptors(t *testing.T) {
 	assert.Nil(t, reply)
 }
 
+func TestCallError(t *testing.T) {
+	for code, transient := range map[codes.Code]bool{
+		codes.Unavailable:       true,
+		codes.DeadlineExceeded:  true,
+		codes.ResourceExhausted: false,
+		codes.Aborted:           false,
+		codes.Internal:          false,
+	} {
+		err := callError(status.Error(code, "fail"))
+		assert.Equal(t, transient, resilience.IsTransient(err), code.String())
+	}
+}
+
 func freePort(t *testing.T) int {
 	addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
 	if err != nil {
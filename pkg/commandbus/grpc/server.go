@@ -3,6 +3,10 @@ package commandbusgrpc
 import (
 	"context"
 
+	"github.com/go-gulfstream/gulfstream/pkg/auth"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+
 	"github.com/go-gulfstream/gulfstream/pkg/commandbus/grpc/proto"
 
 	"github.com/go-gulfstream/gulfstream/pkg/stream"
@@ -23,6 +27,7 @@ type Server struct {
 	contextFunc  []ContextFunc
 	requestFunc  []ServerRequestFunc
 	errorHandler []ServerErrorHandler
+	extractor    auth.Extractor
 }
 
 func NewServer(
@@ -68,6 +73,15 @@ func WithServerErrorHandler(fn ServerErrorHandler) ServerOption {
 	}
 }
 
+// WithServerPrincipalExtractor authenticates the requests and puts the principal
+// into the context of the command. The failed requests are replied
+// with command.UnauthorizedError.
+func WithServerPrincipalExtractor(e auth.Extractor) ServerOption {
+	return func(srv *Server) {
+		srv.extractor = e
+	}
+}
+
 func (s *Server) CommandSink(ctx context.Context, req *proto.Request) (*proto.Response, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
@@ -88,9 +102,19 @@ func (s *Server) CommandSink(ctx context.Context, req *proto.Request) (*proto.Re
 	if err != nil {
 		return s.writeError(err), nil
 	}
-	reply, err := s.mutator.CommandSink(ctx, cmd)
-	if err != nil {
-		return s.writeError(err), nil
+	var reply *command.Reply
+	if s.extractor != nil {
+		ctx, err = s.authenticate(ctx, md)
+		if err != nil {
+			s.handleError(err)
+			reply = auth.ReplyUnauthorized(cmd)
+		}
+	}
+	if reply == nil {
+		reply, err = s.mutator.CommandSink(ctx, cmd)
+		if err != nil {
+			return s.writeError(err), nil
+		}
 	}
 	rawReply, err := reply.MarshalBinary()
 	if err != nil {
@@ -99,6 +123,20 @@ func (s *Server) CommandSink(ctx context.Context, req *proto.Request) (*proto.Re
 	return s.write(rawReply), nil
 }
 
+func (s *Server) authenticate(ctx context.Context, md metadata.MD) (context.Context, error) {
+	req := auth.Request{Headers: auth.MD(md)}
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			req.TLS = &info.State
+		}
+	}
+	principal, err := s.extractor(ctx, req)
+	if err != nil {
+		return ctx, err
+	}
+	return auth.NewContext(ctx, principal), nil
+}
+
 func (s *Server) decodeCommand(data []byte) (*command.Command, error) {
 	if s.commandCodec != nil {
 		return s.commandCodec.Decode(data)
@@ -111,9 +149,13 @@ func (s *Server) write(b []byte) *proto.Response {
 	return &proto.Response{Data: b}
 }
 
-func (s *Server) writeError(err error) *proto.Response {
+func (s *Server) handleError(err error) {
 	for _, errFunc := range s.errorHandler {
 		errFunc(err)
 	}
+}
+
+func (s *Server) writeError(err error) *proto.Response {
+	s.handleError(err)
 	return &proto.Response{Error: err.Error()}
 }
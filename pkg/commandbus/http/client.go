@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/resilience"
 )
 
 const defaultClientTimeout = 15 * time.Second
@@ -96,16 +97,27 @@ func (c *Client) CommandSink(ctx context.Context, cmd *command.Command) (*comman
 
 	resp, err := c.client.Do(req)
 	if err != nil {
+		if ctx.Err() == nil {
+			err = resilience.Transient(err)
+		}
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	rawResp, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resilience.Transient(err)
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, c.decodeError(rawResp)
+		err = c.decodeError(rawResp)
+		if isTransientStatus(resp.StatusCode) {
+			err = resilience.Transient(err)
+		}
+		return nil, err
 	}
 
-	reply := new(command.Reply)
-	if err := reply.UnmarshalBinary(rawResp); err != nil {
+	reply, err := c.decodeReply(cmd, rawResp)
+	if err != nil {
 		return nil, err
 	}
 	for _, respFunc := range c.responseFunc {
@@ -118,6 +130,24 @@ func (c *Client) decodeError(b []byte) error {
 	return errors.New(string(b))
 }
 
+func isTransientStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func (c *Client) decodeReply(cmd *command.Command, data []byte) (*command.Reply, error) {
+	if codec, ok := c.commandCodec.(command.ReplyDecoding); ok {
+		return codec.DecodeReply(cmd, data)
+	}
+	return command.DecodeReply(cmd, data)
+}
+
 func (c *Client) encodeCommand(cmd *command.Command) ([]byte, error) {
 	if c.commandCodec != nil {
 		return c.commandCodec.Encode(cmd)
@@ -2,16 +2,21 @@ package commandbushttp
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	mockstream "github.com/go-gulfstream/gulfstream/mocks/stream"
 	"github.com/golang/mock/gomock"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/go-gulfstream/gulfstream/pkg/auth"
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
 	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/resilience"
 	"github.com/google/uuid"
 
 	"github.com/go-gulfstream/gulfstream/pkg/stream"
@@ -43,6 +48,102 @@ func TestClientServer(t *testing.T) {
 	assert.Nil(t, reply.Err())
 }
 
+func TestClientServerValidation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mutation := newMutation(ctrl)
+	mutation.AddCommandController("action",
+		stream.ControllerFunc(func(ctx context.Context, s *stream.Stream, c *command.Command) (*command.Reply, error) {
+			t.Fatal("controller called with invalid command")
+			return nil, nil
+		}),
+		stream.WithCommandControllerCreateIfNotExists(),
+		stream.WithCommandControllerValidator(func(ctx context.Context, c *command.Command) error {
+			return command.NewValidationError().Add("owner", command.CodeRequired, "owner is required")
+		}))
+	server := httptest.NewServer(NewServer(mutation))
+	defer server.Close()
+	client := NewClient(server.URL)
+
+	cmd := command.New("action", "order", uuid.New(), nil)
+	reply, err := client.CommandSink(context.Background(), cmd)
+	assert.NoError(t, err)
+	assert.Equal(t, cmd.ID(), reply.Command())
+	verr, ok := reply.Err().(*command.ValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, []command.FieldError{
+		{Field: "owner", Code: command.CodeRequired, Message: "owner is required"},
+	}, verr.Fields)
+}
+
+func TestClientServerAuthorization(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mutation := newMutation(ctrl)
+	mutation.AddCommandController("action",
+		stream.ControllerFunc(func(ctx context.Context, s *stream.Stream, c *command.Command) (*command.Reply, error) {
+			return c.ReplyOk(1), nil
+		}), stream.WithCommandControllerCreateIfNotExists())
+	policy := auth.NewPolicy().Allow("order", auth.Any, auth.RequireRole("admin"))
+	sinker := stream.WithCommandSinkerInterceptor(mutation, auth.NewCommandSinkerAuthorization(policy))
+	extractor := auth.BearerToken(func(ctx context.Context, token string) (*auth.Principal, error) {
+		if token != "secret" {
+			return nil, auth.ErrInvalidCredentials
+		}
+		return &auth.Principal{ID: "john", Roles: []string{"admin"}}, nil
+	})
+	var serverErrs []error
+	server := httptest.NewServer(NewServer(sinker,
+		WithServerPrincipalExtractor(extractor),
+		WithServerErrorHandler(func(err error) {
+			serverErrs = append(serverErrs, err)
+		})))
+	defer server.Close()
+
+	for token, allowed := range map[string]bool{"secret": true, "invalid": false, "": false} {
+		token := token
+		client := NewClient(server.URL, WithClientRequestFunc(func(r *http.Request, c *command.Command) {
+			if len(token) > 0 {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+		}))
+		cmd := command.New("action", "order", uuid.New(), nil)
+		reply, err := client.CommandSink(context.Background(), cmd)
+		assert.NoError(t, err)
+		if allowed {
+			assert.NoError(t, reply.Err())
+		} else {
+			assert.True(t, command.IsUnauthorized(reply.Err()), token)
+		}
+		if token == "invalid" {
+			var uerr *command.UnauthorizedError
+			assert.True(t, errors.As(reply.Err(), &uerr))
+			assert.Equal(t, auth.ReasonUnauthenticated, uerr.Reason)
+		}
+	}
+	assert.Contains(t, serverErrs, auth.ErrInvalidCredentials)
+}
+
+func TestClientServerResult(t *testing.T) {
+	type created struct {
+		Number int
+	}
+	ctrl := gomock.NewController(t)
+	mutation := newMutation(ctrl)
+	mutation.AddCommandController("action",
+		stream.ControllerFunc(func(ctx context.Context, s *stream.Stream, c *command.Command) (*command.Reply, error) {
+			return c.ReplyResult(1, codec.JSON(&created{Number: 42})), nil
+		}), stream.WithCommandControllerCreateIfNotExists())
+	server := httptest.NewServer(NewServer(mutation))
+	defer server.Close()
+
+	commandCodec := command.NewCodec()
+	commandCodec.RegisterResult("action", codec.JSON(&created{}))
+	client := NewClient(server.URL, WithClientCodec(commandCodec))
+
+	reply, err := client.CommandSink(context.Background(), command.New("action", "order", uuid.New(), nil))
+	assert.NoError(t, err)
+	assert.Equal(t, &created{Number: 42}, codec.Unwrap(reply.Result()))
+}
+
 func TestServerMiddleware(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	validID := uuid.New()
@@ -91,3 +192,87 @@ func newMutation(ctrl *gomock.Controller) *stream.Mutator {
 	})
 	return stream.NewMutator(store, publisher)
 }
+
+func TestClientServerWaitForVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	checkpoints := stream.NewCheckpoints()
+	sinker := stream.WithCommandSinkerInterceptor(newMutation(ctrl),
+		stream.NewVersionWaiterInterceptor(checkpoints))
+	server := httptest.NewServer(NewServer(sinker))
+	defer server.Close()
+	waiter := stream.NewRemoteVersionWaiter(NewClient(server.URL))
+
+	streamID := uuid.New()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = checkpoints.SaveCheckpoint(context.Background(), "order", streamID, 3)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, waiter.WaitForVersion(ctx, "order", streamID, 3))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := waiter.WaitForVersion(ctx, "order", streamID, 4)
+	assert.True(t, errors.Is(err, stream.ErrVersionNotReached), err)
+
+	// the server deadline fires first
+	client := NewClient(server.URL)
+	waiter = stream.NewRemoteVersionWaiter(sinkerFunc(
+		func(_ context.Context, cmd *command.Command) (*command.Reply, error) {
+			return client.CommandSink(context.Background(), cmd)
+		}))
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = waiter.WaitForVersion(ctx, "order", streamID, 4)
+	assert.True(t, errors.Is(err, stream.ErrVersionNotReached), err)
+}
+
+func TestClientRetry(t *testing.T) {
+	var executed, requests int
+	sinker := stream.WithCommandSinkerInterceptor(
+		sinkerFunc(func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			executed++
+			return cmd.ReplyOk(1), nil
+		}),
+		resilience.NewCommandSinkerIdempotency(),
+	)
+	handler := NewServer(sinker)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch requests {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		case 2:
+			// the command is executed, but the reply is lost
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	cmd := command.New("create", "order", uuid.New(), nil)
+	_, err := client.CommandSink(context.Background(), cmd)
+	assert.True(t, errors.Is(err, resilience.ErrTransient))
+
+	requests = 0
+	retrying := stream.WithCommandSinkerInterceptor(client,
+		resilience.NewCommandSinkerRetry(resilience.WithRetryBackoff(time.Millisecond, time.Millisecond)))
+	reply, err := retrying.CommandSink(context.Background(), cmd)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, reply.Err())
+	assert.Equal(t, 3, requests)
+	assert.Equal(t, 1, executed)
+}
+
+type sinkerFunc func(ctx context.Context, cmd *command.Command) (*command.Reply, error)
+
+func (fn sinkerFunc) CommandSink(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+	return fn(ctx, cmd)
+}
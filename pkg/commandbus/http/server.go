@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"net/http"
 
+	"github.com/go-gulfstream/gulfstream/pkg/auth"
 	"github.com/go-gulfstream/gulfstream/pkg/stream"
 
 	"github.com/go-gulfstream/gulfstream/pkg/command"
@@ -23,6 +24,7 @@ type Server struct {
 	responseFunc []ServerResponseFunc
 	contextFunc  []ContextFunc
 	errorHandler []ServerErrorHandler
+	extractor    auth.Extractor
 }
 
 func NewServer(
@@ -72,6 +74,15 @@ func WithServerErrorHandler(fn ServerErrorHandler) ServerOption {
 	}
 }
 
+// WithServerPrincipalExtractor authenticates the requests and puts the principal
+// into the context of the command. The failed requests are replied
+// with command.UnauthorizedError.
+func WithServerPrincipalExtractor(e auth.Extractor) ServerOption {
+	return func(srv *Server) {
+		srv.extractor = e
+	}
+}
+
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithCancel(r.Context())
 	defer cancel()
@@ -102,10 +113,20 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		reqFunc(r, cmd)
 	}
 
-	reply, err := s.mutator.CommandSink(ctx, cmd)
-	if err != nil {
-		s.writeError(w, err)
-		return
+	var reply *command.Reply
+	if s.extractor != nil {
+		ctx, err = s.authenticate(ctx, r)
+		if err != nil {
+			s.handleError(err)
+			reply = auth.ReplyUnauthorized(cmd)
+		}
+	}
+	if reply == nil {
+		reply, err = s.mutator.CommandSink(ctx, cmd)
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
 	}
 
 	for _, respFunc := range s.responseFunc {
@@ -121,6 +142,14 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(rawReply)
 }
 
+func (s *Server) authenticate(ctx context.Context, r *http.Request) (context.Context, error) {
+	principal, err := s.extractor(ctx, auth.Request{Headers: r.Header, TLS: r.TLS})
+	if err != nil {
+		return ctx, err
+	}
+	return auth.NewContext(ctx, principal), nil
+}
+
 func (s *Server) decodeCommand(data []byte) (*command.Command, error) {
 	if s.commandCodec != nil {
 		return s.commandCodec.Decode(data)
@@ -129,10 +158,14 @@ func (s *Server) decodeCommand(data []byte) (*command.Command, error) {
 	}
 }
 
-func (s *Server) writeError(w http.ResponseWriter, err error) {
+func (s *Server) handleError(err error) {
 	for _, errFunc := range s.errorHandler {
 		errFunc(err)
 	}
+}
+
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	s.handleError(err)
 	w.WriteHeader(http.StatusInternalServerError)
 	_, _ = w.Write([]byte(err.Error()))
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/resilience"
 	"github.com/nats-io/nats.go"
 )
 
@@ -73,9 +74,12 @@ func WithClientResponseFunc(fn ClientResponseFunc) ClientOption {
 }
 
 func (c *Client) CommandSink(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
-	if c.conn.Status() != nats.CONNECTED {
+	if c.conn.IsClosed() {
 		return nil, nats.ErrConnectionClosed
 	}
+	if c.conn.Status() != nats.CONNECTED {
+		return nil, resilience.Transient(nats.ErrDisconnected)
+	}
 
 	data, err := c.encodeCommand(cmd)
 	if err != nil {
@@ -91,9 +95,11 @@ func (c *Client) CommandSink(ctx context.Context, cmd *command.Command) (*comman
 	for _, reqFunc := range c.requestFunc {
 		reqFunc(inMsg.Header, cmd)
 	}
-	outMsg, err := c.conn.RequestMsg(inMsg, c.timeout)
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	outMsg, err := c.conn.RequestMsgWithContext(ctx, inMsg)
 	if err != nil {
-		return nil, c.conn.LastError()
+		return nil, requestError(err)
 	}
 	if outMsg.Header == nil {
 		outMsg.Header = make(nats.Header)
@@ -102,8 +108,8 @@ func (c *Client) CommandSink(ctx context.Context, cmd *command.Command) (*comman
 	if outMsg.Header.Get(errKey) == errKey {
 		return nil, errors.New(string(outMsg.Data))
 	}
-	reply := new(command.Reply)
-	if err := reply.UnmarshalBinary(outMsg.Data); err != nil {
+	reply, err := c.decodeReply(cmd, outMsg.Data)
+	if err != nil {
 		return nil, err
 	}
 	for _, respFunc := range c.responseFunc {
@@ -112,6 +118,13 @@ func (c *Client) CommandSink(ctx context.Context, cmd *command.Command) (*comman
 	return reply, nil
 }
 
+func (c *Client) decodeReply(cmd *command.Command, data []byte) (*command.Reply, error) {
+	if c.commandCodec != nil {
+		return c.commandCodec.DecodeReply(cmd, data)
+	}
+	return command.DecodeReply(cmd, data)
+}
+
 func (c *Client) encodeCommand(cmd *command.Command) ([]byte, error) {
 	if c.commandCodec != nil {
 		return c.commandCodec.Encode(cmd)
@@ -120,6 +133,17 @@ func (c *Client) encodeCommand(cmd *command.Command) ([]byte, error) {
 	}
 }
 
+// requestError marks the errors after which the command may be sent again.
+func requestError(err error) error {
+	switch {
+	case errors.Is(err, nats.ErrTimeout),
+		errors.Is(err, nats.ErrNoResponders),
+		errors.Is(err, context.DeadlineExceeded):
+		return resilience.Transient(err)
+	}
+	return err
+}
+
 func toSubj(s string) string {
 	return s + "-gulfstream"
 }
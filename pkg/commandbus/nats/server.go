@@ -3,6 +3,7 @@ package commandbusnats
 import (
 	"context"
 
+	"github.com/go-gulfstream/gulfstream/pkg/auth"
 	"github.com/go-gulfstream/gulfstream/pkg/stream"
 
 	"github.com/go-gulfstream/gulfstream/pkg/command"
@@ -24,6 +25,7 @@ type Server struct {
 	responseFunc []ServerResponseFunc
 	contextFunc  []ContextFunc
 	errorHandler []ServerErrorHandler
+	extractor    auth.Extractor
 }
 
 func NewServer(
@@ -73,6 +75,15 @@ func WithServerErrorHandler(fn ServerErrorHandler) ServerOption {
 	}
 }
 
+// WithServerPrincipalExtractor authenticates the requests and puts the principal
+// into the context of the command. The failed requests are replied
+// with command.UnauthorizedError.
+func WithServerPrincipalExtractor(e auth.Extractor) ServerOption {
+	return func(srv *Server) {
+		srv.extractor = e
+	}
+}
+
 func (s *Server) Listen(conn *nats.Conn) error {
 	if _, err := conn.QueueSubscribe(s.subject, s.subject, func(msg *nats.Msg) {
 		rawReply := s.handleMsg(msg)
@@ -108,9 +119,19 @@ func (s *Server) handleMsg(msg *nats.Msg) []byte {
 		reqFunc(msg.Header, cmd)
 	}
 
-	reply, err := s.mutator.CommandSink(ctx, cmd)
-	if err != nil {
-		return s.writeError(msg, err)
+	var reply *command.Reply
+	if s.extractor != nil {
+		ctx, err = s.authenticate(ctx, msg.Header)
+		if err != nil {
+			s.handleError(msg, err)
+			reply = auth.ReplyUnauthorized(cmd)
+		}
+	}
+	if reply == nil {
+		reply, err = s.mutator.CommandSink(ctx, cmd)
+		if err != nil {
+			return s.writeError(msg, err)
+		}
 	}
 
 	for _, respFunc := range s.responseFunc {
@@ -139,6 +160,14 @@ func (s *Server) writeError(msg *nats.Msg, err error) []byte {
 	return []byte(err.Error())
 }
 
+func (s *Server) authenticate(ctx context.Context, h nats.Header) (context.Context, error) {
+	principal, err := s.extractor(ctx, auth.Request{Headers: h})
+	if err != nil {
+		return ctx, err
+	}
+	return auth.NewContext(ctx, principal), nil
+}
+
 func (s *Server) decodeCommand(data []byte) (*command.Command, error) {
 	if s.commandCodec != nil {
 		return s.commandCodec.Decode(data)
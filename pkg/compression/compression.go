@@ -0,0 +1,214 @@
+package compression
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+const (
+	headerSize       = 1
+	DefaultThreshold = 512
+)
+
+const (
+	GzipID  = byte(1)
+	FlateID = byte(2)
+)
+
+// Algorithm is a compression algorithm. The ID is written with the compressed data
+// and must be unique and stable.
+type Algorithm interface {
+	ID() byte
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// Compressor compresses the data larger than the threshold. Its filters mark
+// the compressed data in the container, so the compressed and the uncompressed
+// data can be read side by side.
+type Compressor struct {
+	algorithm  Algorithm
+	algorithms map[byte]Algorithm
+	threshold  int
+}
+
+type Option func(*Compressor)
+
+func New(algorithm Algorithm, opts ...Option) *Compressor {
+	c := &Compressor{
+		algorithm:  algorithm,
+		algorithms: map[byte]Algorithm{algorithm.ID(): algorithm},
+		threshold:  DefaultThreshold,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithThreshold sets the minimal size of the data to be compressed.
+func WithThreshold(n int) Option {
+	return func(c *Compressor) {
+		c.threshold = n
+	}
+}
+
+// WithDecompressor registers the algorithm used only to read the data
+// compressed earlier, e.g. after the switch to another algorithm.
+func WithDecompressor(algorithm Algorithm) Option {
+	return func(c *Compressor) {
+		c.algorithms[algorithm.ID()] = algorithm
+	}
+}
+
+// Compress returns the algorithm ID followed by the compressed data or
+// codec.ErrSkipFilter if the data is smaller than the threshold
+// or does not shrink.
+func (c *Compressor) Compress(data []byte) ([]byte, error) {
+	if len(data) < c.threshold {
+		return nil, codec.ErrSkipFilter
+	}
+	compressed, err := c.algorithm.Compress(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(compressed)+headerSize >= len(data) {
+		return nil, codec.ErrSkipFilter
+	}
+	out := make([]byte, 0, len(compressed)+headerSize)
+	out = append(out, c.algorithm.ID())
+	return append(out, compressed...), nil
+}
+
+// Decompress reads the data returned by Compress.
+func (c *Compressor) Decompress(data []byte) ([]byte, error) {
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("compression: invalid data input")
+	}
+	algorithm, found := c.algorithms[data[0]]
+	if !found {
+		return nil, fmt.Errorf("compression: algorithm %d not found", data[0])
+	}
+	return algorithm.Decompress(data[headerSize:])
+}
+
+func (c *Compressor) EventFilter() event.PayloadFilter {
+	return eventFilter{c}
+}
+
+func (c *Compressor) CommandFilter() command.PayloadFilter {
+	return commandFilter{c}
+}
+
+func (c *Compressor) StateFilter() stream.StateFilter {
+	return stateFilter{c}
+}
+
+type eventFilter struct{ c *Compressor }
+
+func (eventFilter) FilterMark() byte { return codec.CompressionMark }
+
+func (f eventFilter) EncodePayload(_ *event.Event, data []byte) ([]byte, error) {
+	return f.c.Compress(data)
+}
+
+func (f eventFilter) DecodePayload(_ *event.Event, data []byte) ([]byte, error) {
+	return f.c.Decompress(data)
+}
+
+type commandFilter struct{ c *Compressor }
+
+func (commandFilter) FilterMark() byte { return codec.CompressionMark }
+
+func (f commandFilter) EncodePayload(_ *command.Command, data []byte) ([]byte, error) {
+	return f.c.Compress(data)
+}
+
+func (f commandFilter) DecodePayload(_ *command.Command, data []byte) ([]byte, error) {
+	return f.c.Decompress(data)
+}
+
+type stateFilter struct{ c *Compressor }
+
+func (stateFilter) FilterMark() byte { return codec.CompressionMark }
+
+func (f stateFilter) EncodeState(_ *stream.Stream, data []byte) ([]byte, error) {
+	return f.c.Compress(data)
+}
+
+func (f stateFilter) DecodeState(_ *stream.Stream, data []byte) ([]byte, error) {
+	return f.c.Decompress(data)
+}
+
+func Gzip(level int) Algorithm {
+	return gzipAlgorithm{level: level}
+}
+
+func Flate(level int) Algorithm {
+	return flateAlgorithm{level: level}
+}
+
+type gzipAlgorithm struct {
+	level int
+}
+
+func (gzipAlgorithm) ID() byte { return GzipID }
+
+func (a gzipAlgorithm) Compress(data []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	w, err := gzip.NewWriterLevel(buf, a.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipAlgorithm) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+type flateAlgorithm struct {
+	level int
+}
+
+func (flateAlgorithm) ID() byte { return FlateID }
+
+func (a flateAlgorithm) Compress(data []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	w, err := flate.NewWriter(buf, a.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (flateAlgorithm) Decompress(data []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(data))
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
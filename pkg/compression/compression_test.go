@@ -0,0 +1,129 @@
+package compression
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+func TestCompressor_Threshold(t *testing.T) {
+	c := New(Gzip(gzip.BestSpeed), WithThreshold(64))
+	_, err := c.Compress([]byte("small"))
+	assert.ErrorIs(t, err, codec.ErrSkipFilter)
+
+	large := []byte(strings.Repeat("large", 200))
+	data, err := c.Compress(large)
+	assert.NoError(t, err)
+	assert.Equal(t, GzipID, data[0])
+	assert.Less(t, len(data), len(large))
+	plain, err := c.Decompress(data)
+	assert.NoError(t, err)
+	assert.Equal(t, large, plain)
+}
+
+func TestCompressor_SwitchAlgorithm(t *testing.T) {
+	large := []byte(strings.Repeat("large", 200))
+	data, err := New(Gzip(gzip.DefaultCompression)).Compress(large)
+	assert.NoError(t, err)
+
+	_, err = New(Flate(gzip.DefaultCompression)).Decompress(data)
+	assert.Error(t, err)
+
+	c := New(Flate(gzip.DefaultCompression), WithDecompressor(Gzip(gzip.DefaultCompression)))
+	plain, err := c.Decompress(data)
+	assert.NoError(t, err)
+	assert.Equal(t, large, plain)
+}
+
+func TestCompressor_Filters(t *testing.T) {
+	c := New(Gzip(gzip.DefaultCompression), WithThreshold(16))
+	text := strings.Repeat("text", 100)
+
+	eventCodec := event.NewCodec()
+	eventCodec.Register("created", &payload{})
+	eventCodec.Use(c.EventFilter())
+	e := event.New("created", "users", uuid.New(), 1, &payload{Text: text})
+	data, err := eventCodec.Encode(e)
+	assert.NoError(t, err)
+	assert.Less(t, len(data), len(text))
+	e2, err := eventCodec.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, text, e2.Payload().(*payload).Text)
+
+	commandCodec := command.NewCodec()
+	commandCodec.Register("create", &payload{})
+	commandCodec.Use(c.CommandFilter())
+	cmd := command.New("create", "users", uuid.New(), &payload{Text: text})
+	data, err = commandCodec.Encode(cmd)
+	assert.NoError(t, err)
+	assert.Less(t, len(data), len(text))
+	cmd2, err := commandCodec.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, text, cmd2.Payload().(*payload).Text)
+
+	s := stream.New("users", uuid.New(), &state{payload{Text: text}}, stream.WithStateFilter(c.StateFilter()))
+	data, err = s.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Less(t, len(data), len(text))
+	s2 := stream.Blank("users", &state{}, stream.WithStateFilter(c.StateFilter()))
+	assert.NoError(t, s2.UnmarshalBinary(data))
+	assert.Equal(t, text, s2.State().(*state).Text)
+}
+
+func TestCompressor_UnmarkedPayload(t *testing.T) {
+	c := New(Gzip(gzip.DefaultCompression), WithThreshold(1))
+	// the header of the compressed payloads of the earlier versions
+	text := string([]byte{0x6d, 0x03, GzipID}) + "text"
+
+	plainCodec := event.NewCodec()
+	plainCodec.Register("created", &rawPayload{})
+	data, err := plainCodec.Encode(event.New("created", "users", uuid.New(), 1, &rawPayload{Data: []byte(text)}))
+	assert.NoError(t, err)
+
+	eventCodec := event.NewCodec()
+	eventCodec.Register("created", &rawPayload{})
+	eventCodec.Use(c.EventFilter())
+	e, err := eventCodec.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, text, string(e.Payload().(*rawPayload).Data))
+}
+
+type rawPayload struct {
+	Data []byte
+}
+
+func (p *rawPayload) MarshalBinary() ([]byte, error) {
+	return p.Data, nil
+}
+
+func (p *rawPayload) UnmarshalBinary(data []byte) error {
+	p.Data = data
+	return nil
+}
+
+type payload struct {
+	Text string
+}
+
+func (p *payload) MarshalBinary() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+func (p *payload) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, p)
+}
+
+type state struct {
+	payload
+}
+
+func (s *state) Mutate(*event.Event) {}
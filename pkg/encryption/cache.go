@@ -0,0 +1,143 @@
+package encryption
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/go-gulfstream/gulfstream/pkg/clock"
+)
+
+const DefaultKeyCacheSize = 10000
+
+var _ KeyStore = (*KeyCache)(nil)
+
+// KeyCache keeps the keys loaded from the store for the ttl, so the events
+// of the same stream are encoded and decoded without the round-trips.
+// The key deleted through the cache is evicted at once, the key deleted
+// by the other process is used until the ttl expires.
+type KeyCache struct {
+	keys    KeyStore
+	ttl     time.Duration
+	size    int
+	clock   clock.Clock
+	mu      sync.Mutex
+	entries map[streamKey]cachedKey
+}
+
+type streamKey struct {
+	streamName string
+	streamID   uuid.UUID
+}
+
+type cachedKey struct {
+	key     []byte
+	expires time.Time
+}
+
+type KeyCacheOption func(*KeyCache)
+
+// WithKeyCacheSize limits the number of the cached keys.
+func WithKeyCacheSize(n int) KeyCacheOption {
+	return func(c *KeyCache) {
+		if n > 0 {
+			c.size = n
+		}
+	}
+}
+
+func WithKeyCacheClock(c clock.Clock) KeyCacheOption {
+	return func(cache *KeyCache) {
+		cache.clock = clock.OrSystem(c)
+	}
+}
+
+func NewKeyCache(keys KeyStore, ttl time.Duration, opts ...KeyCacheOption) *KeyCache {
+	c := &KeyCache{
+		keys:    keys,
+		ttl:     ttl,
+		size:    DefaultKeyCacheSize,
+		clock:   clock.System(),
+		entries: make(map[streamKey]cachedKey),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *KeyCache) Key(ctx context.Context, streamName string, streamID uuid.UUID) ([]byte, error) {
+	if key, found := c.get(streamName, streamID); found {
+		return key, nil
+	}
+	key, err := c.keys.Key(ctx, streamName, streamID)
+	if err != nil {
+		return nil, err
+	}
+	c.put(streamName, streamID, key)
+	return key, nil
+}
+
+func (c *KeyCache) CreateKey(ctx context.Context, streamName string, streamID uuid.UUID) ([]byte, error) {
+	if key, found := c.get(streamName, streamID); found {
+		return key, nil
+	}
+	key, err := c.keys.CreateKey(ctx, streamName, streamID)
+	if err != nil {
+		return nil, err
+	}
+	c.put(streamName, streamID, key)
+	return key, nil
+}
+
+func (c *KeyCache) DeleteKey(ctx context.Context, streamName string, streamID uuid.UUID) error {
+	c.mu.Lock()
+	delete(c.entries, streamKey{streamName: streamName, streamID: streamID})
+	c.mu.Unlock()
+	return c.keys.DeleteKey(ctx, streamName, streamID)
+}
+
+func (c *KeyCache) get(streamName string, streamID uuid.UUID) ([]byte, bool) {
+	id := streamKey{streamName: streamName, streamID: streamID}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, found := c.entries[id]
+	if !found {
+		return nil, false
+	}
+	if !c.clock.Now().Before(entry.expires) {
+		delete(c.entries, id)
+		return nil, false
+	}
+	return entry.key, true
+}
+
+func (c *KeyCache) put(streamName string, streamID uuid.UUID, key []byte) {
+	now := c.clock.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= c.size {
+		c.evict(now)
+	}
+	c.entries[streamKey{streamName: streamName, streamID: streamID}] = cachedKey{
+		key:     key,
+		expires: now.Add(c.ttl),
+	}
+}
+
+// evict drops the expired keys or any key if none is expired.
+func (c *KeyCache) evict(now time.Time) {
+	for id, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, id)
+		}
+	}
+	for id := range c.entries {
+		if len(c.entries) < c.size {
+			return
+		}
+		delete(c.entries, id)
+	}
+}
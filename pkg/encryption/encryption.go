@@ -0,0 +1,178 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+)
+
+const KeySize = 32
+
+var (
+	ErrKeyNotFound = errors.New("encryption: key not found")
+	ErrShredded    = errors.New("encryption: payload is shredded")
+)
+
+// KeyStore keeps the data keys of the streams.
+// Deleting the key of a stream makes all its encrypted payloads unreadable.
+type KeyStore interface {
+	// Key returns the key of the stream or ErrKeyNotFound.
+	Key(ctx context.Context, streamName string, streamID uuid.UUID) ([]byte, error)
+	// CreateKey returns the key of the stream and creates it if needed.
+	// It returns ErrShredded if the key of the stream is deleted.
+	CreateKey(ctx context.Context, streamName string, streamID uuid.UUID) ([]byte, error)
+	// DeleteKey deletes the key and keeps the tombstone,
+	// so the key of the stream is never created again.
+	DeleteKey(ctx context.Context, streamName string, streamID uuid.UUID) error
+}
+
+// NewKey generates a random AES-256 data key.
+func NewKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Shredded is the payload of the event decoded after the key of its stream is deleted.
+// It keeps the encrypted bytes of the payload.
+type Shredded struct {
+	Data []byte
+}
+
+func (s *Shredded) MarshalBinary() ([]byte, error) {
+	return s.Data, nil
+}
+
+func (s *Shredded) UnmarshalBinary(data []byte) error {
+	s.Data = data
+	return nil
+}
+
+func IsShredded(e *event.Event) bool {
+	_, ok := e.Payload().(*Shredded)
+	return ok
+}
+
+// NewPayloadFilter returns the filter that encrypts the event payloads
+// with AES-GCM using the data key of the event stream:
+//
+//	codec := event.NewCodec()
+//	codec.Use(encryption.NewPayloadFilter(encryption.NewKeyCache(keys, time.Minute)))
+//
+// The encrypted payloads are marked in the event container, the payloads
+// written before the filter was added are decoded as is.
+// The shredded payloads are encoded as is, the new events of the shredded
+// stream fail to encode with ErrShredded.
+func NewPayloadFilter(keys KeyStore, opts ...FilterOption) event.PayloadFilter {
+	f := payloadFilter{keys: keys}
+	for _, opt := range opts {
+		opt(&f)
+	}
+	return f
+}
+
+type FilterOption func(*payloadFilter)
+
+// WithKeyTimeout bounds each call of the key store made by the filter.
+func WithKeyTimeout(d time.Duration) FilterOption {
+	return func(f *payloadFilter) {
+		f.timeout = d
+	}
+}
+
+type payloadFilter struct {
+	keys    KeyStore
+	timeout time.Duration
+}
+
+func (f payloadFilter) FilterMark() byte {
+	return codec.EncryptionMark
+}
+
+func (f payloadFilter) EncodePayload(e *event.Event, data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, codec.ErrSkipFilter
+	}
+	if IsShredded(e) {
+		return data, nil
+	}
+	ctx, cancel := f.context()
+	defer cancel()
+	key, err := f.keys.CreateKey(ctx, e.StreamName(), e.StreamID())
+	if err != nil {
+		return nil, err
+	}
+	return Encrypt(key, additionalData(e), data)
+}
+
+func (f payloadFilter) DecodePayload(e *event.Event, data []byte) ([]byte, error) {
+	ctx, cancel := f.context()
+	defer cancel()
+	key, err := f.keys.Key(ctx, e.StreamName(), e.StreamID())
+	if err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			return nil, &event.PayloadSubstitute{
+				Payload: &Shredded{Data: data},
+				Err:     ErrShredded,
+			}
+		}
+		return nil, err
+	}
+	return Decrypt(key, additionalData(e), data)
+}
+
+func (f payloadFilter) context() (context.Context, context.CancelFunc) {
+	if f.timeout > 0 {
+		return context.WithTimeout(context.Background(), f.timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// Encrypt seals the data and returns the nonce followed by the sealed data.
+func Encrypt(key []byte, additionalData []byte, data []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, data, additionalData), nil
+}
+
+func Decrypt(key []byte, additionalData []byte, data []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("encryption: invalid data input")
+	}
+	nonce, sealed := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, additionalData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func additionalData(e *event.Event) []byte {
+	return []byte(e.StreamName() + e.StreamID().String())
+}
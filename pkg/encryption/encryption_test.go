@@ -0,0 +1,159 @@
+package encryption
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/clock"
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+)
+
+func TestPayloadFilter_Shredding(t *testing.T) {
+	keys := NewMemoryKeyStore()
+	c := event.NewCodec()
+	c.Register("userCreated", &userCreated{})
+	c.Use(NewPayloadFilter(keys))
+
+	streamID := uuid.New()
+	e := event.New("userCreated", "users", streamID, 1, &userCreated{Email: "user@example.com"})
+	data, err := c.Encode(e)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "user@example.com")
+
+	e2, err := c.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "user@example.com", e2.Payload().(*userCreated).Email)
+	assert.False(t, IsShredded(e2))
+
+	assert.NoError(t, keys.DeleteKey(context.Background(), "users", streamID))
+	e3, err := c.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, e.ID(), e3.ID())
+	assert.True(t, IsShredded(e3))
+
+	data2, err := c.Encode(e3)
+	assert.NoError(t, err)
+	_, err = keys.Key(context.Background(), "users", streamID)
+	assert.ErrorIs(t, err, ErrKeyNotFound, "the key of the shredded stream is not created again")
+	e4, err := c.Decode(data2)
+	assert.NoError(t, err)
+	assert.True(t, IsShredded(e4))
+	assert.Equal(t, e3.Payload(), e4.Payload(), "the shredded payload is not encrypted twice")
+
+	_, err = c.Encode(event.New("userCreated", "users", streamID, 2, &userCreated{Email: "user@example.com"}))
+	assert.ErrorIs(t, err, ErrShredded, "the shredded stream gets no new key")
+	e5, err := c.Decode(data)
+	assert.NoError(t, err)
+	assert.True(t, IsShredded(e5), "the old events are still decoded after the publish")
+}
+
+func TestMemoryKeyStore_Tombstone(t *testing.T) {
+	ctx := context.Background()
+	keys := NewMemoryKeyStore()
+	streamID := uuid.New()
+	assert.NoError(t, keys.DeleteKey(ctx, "users", streamID))
+	_, err := keys.Key(ctx, "users", streamID)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	_, err = keys.CreateKey(ctx, "users", streamID)
+	assert.ErrorIs(t, err, ErrShredded)
+}
+
+func TestPayloadFilter_PlainPayload(t *testing.T) {
+	plain := event.NewCodec()
+	plain.Register("userCreated", &userCreated{})
+	e := event.New("userCreated", "users", uuid.New(), 1, &userCreated{Email: "user@example.com"})
+	data, err := plain.Encode(e)
+	assert.NoError(t, err)
+
+	c := event.NewCodec()
+	c.Register("userCreated", &userCreated{})
+	c.Use(NewPayloadFilter(NewMemoryKeyStore()))
+	e2, err := c.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "user@example.com", e2.Payload().(*userCreated).Email)
+
+	// the plain payload looking like the encrypted one is decoded as is
+	blob := codec.Raw{0x65, 0x02, 0x01, 0x02, 0x03}
+	plain.Register("blobAdded", &codec.Raw{})
+	c.Register("blobAdded", &codec.Raw{})
+	data, err = plain.Encode(event.New("blobAdded", "users", uuid.New(), 1, &blob))
+	assert.NoError(t, err)
+	e3, err := c.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, &blob, e3.Payload())
+}
+
+func TestKeyCache(t *testing.T) {
+	ctx := context.Background()
+	store := &countingKeyStore{KeyStore: NewMemoryKeyStore()}
+	now := clock.NewFake(time.Now())
+	keys := NewKeyCache(store, time.Minute, WithKeyCacheClock(now), WithKeyCacheSize(1))
+	streamID := uuid.New()
+
+	key, err := keys.CreateKey(ctx, "users", streamID)
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		cached, err := keys.Key(ctx, "users", streamID)
+		assert.NoError(t, err)
+		assert.Equal(t, key, cached)
+	}
+	assert.Equal(t, 1, store.calls)
+
+	now.Advance(time.Minute)
+	_, err = keys.Key(ctx, "users", streamID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, store.calls, "the expired key is loaded again")
+
+	_, err = keys.CreateKey(ctx, "orders", uuid.New())
+	assert.NoError(t, err)
+	assert.Len(t, keys.entries, 1)
+
+	assert.NoError(t, keys.DeleteKey(ctx, "users", streamID))
+	_, err = keys.Key(ctx, "users", streamID)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+type countingKeyStore struct {
+	KeyStore
+	calls int
+}
+
+func (s *countingKeyStore) Key(ctx context.Context, streamName string, streamID uuid.UUID) ([]byte, error) {
+	s.calls++
+	return s.KeyStore.Key(ctx, streamName, streamID)
+}
+
+func (s *countingKeyStore) CreateKey(ctx context.Context, streamName string, streamID uuid.UUID) ([]byte, error) {
+	s.calls++
+	return s.KeyStore.CreateKey(ctx, streamName, streamID)
+}
+
+func TestDecrypt_WrongAdditionalData(t *testing.T) {
+	key, err := NewKey()
+	assert.NoError(t, err)
+	data, err := Encrypt(key, []byte("a"), []byte("payload"))
+	assert.NoError(t, err)
+	_, err = Decrypt(key, []byte("b"), data)
+	assert.Error(t, err)
+	plain, err := Decrypt(key, []byte("a"), data)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("payload"), plain)
+}
+
+type userCreated struct {
+	Email string
+}
+
+func (p *userCreated) MarshalBinary() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+func (p *userCreated) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, p)
+}
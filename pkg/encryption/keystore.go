@@ -0,0 +1,57 @@
+package encryption
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+var _ KeyStore = (*MemoryKeyStore)(nil)
+
+// MemoryKeyStore keeps the nil key as the tombstone of the deleted key.
+type MemoryKeyStore struct {
+	mu   sync.RWMutex
+	keys map[string][]byte
+}
+
+func NewMemoryKeyStore() *MemoryKeyStore {
+	return &MemoryKeyStore{
+		keys: make(map[string][]byte),
+	}
+}
+
+func (s *MemoryKeyStore) Key(_ context.Context, streamName string, streamID uuid.UUID) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, found := s.keys[streamName+streamID.String()]
+	if !found || key == nil {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+func (s *MemoryKeyStore) CreateKey(_ context.Context, streamName string, streamID uuid.UUID) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := streamName + streamID.String()
+	if key, found := s.keys[id]; found {
+		if key == nil {
+			return nil, ErrShredded
+		}
+		return key, nil
+	}
+	key, err := NewKey()
+	if err != nil {
+		return nil, err
+	}
+	s.keys[id] = key
+	return key, nil
+}
+
+func (s *MemoryKeyStore) DeleteKey(_ context.Context, streamName string, streamID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[streamName+streamID.String()] = nil
+	return nil
+}
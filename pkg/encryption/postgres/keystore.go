@@ -0,0 +1,104 @@
+package encryptionpostgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/go-gulfstream/gulfstream/pkg/encryption"
+)
+
+const Schema = `
+CREATE SCHEMA IF NOT EXISTS gulfstream;
+
+CREATE TABLE IF NOT EXISTS gulfstream.keys
+(
+    stream_id      uuid         NOT NULL,
+    stream_name    VARCHAR(128) NOT NULL,
+    key bytea,
+    PRIMARY KEY (stream_name, stream_id)
+);
+
+ALTER TABLE gulfstream.keys ALTER COLUMN key DROP NOT NULL;
+`
+
+const (
+	insertKeySQL = `
+INSERT INTO gulfstream.keys (stream_name, stream_id, key)
+VALUES ($1, $2, $3)
+ON CONFLICT (stream_name, stream_id) DO NOTHING`
+
+	selectKeySQL = `
+SELECT key
+FROM gulfstream.keys
+WHERE stream_name=$1 AND stream_id=$2`
+
+	deleteKeySQL = `
+INSERT INTO gulfstream.keys (stream_name, stream_id, key)
+VALUES ($1, $2, NULL)
+ON CONFLICT (stream_name, stream_id) DO UPDATE SET key = NULL`
+)
+
+var _ encryption.KeyStore = (*KeyStore)(nil)
+
+// KeyStore keeps the NULL key as the tombstone of the deleted key.
+type KeyStore struct {
+	pool *pgxpool.Pool
+}
+
+func New(pool *pgxpool.Pool) KeyStore {
+	return KeyStore{pool: pool}
+}
+
+func CreateSchema(ctx context.Context, pool *pgxpool.Pool) error {
+	_, err := pool.Exec(ctx, Schema)
+	return err
+}
+
+func (s KeyStore) Key(ctx context.Context, streamName string, streamID uuid.UUID) ([]byte, error) {
+	key, err := s.get(ctx, streamName, streamID)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, encryption.ErrKeyNotFound
+	}
+	return key, nil
+}
+
+func (s KeyStore) CreateKey(ctx context.Context, streamName string, streamID uuid.UUID) ([]byte, error) {
+	key, err := encryption.NewKey()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.pool.Exec(ctx, insertKeySQL, streamName, streamID.String(), key); err != nil {
+		return nil, err
+	}
+	key, err = s.get(ctx, streamName, streamID)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, encryption.ErrShredded
+	}
+	return key, nil
+}
+
+func (s KeyStore) DeleteKey(ctx context.Context, streamName string, streamID uuid.UUID) error {
+	_, err := s.pool.Exec(ctx, deleteKeySQL, streamName, streamID.String())
+	return err
+}
+
+func (s KeyStore) get(ctx context.Context, streamName string, streamID uuid.UUID) ([]byte, error) {
+	var key []byte
+	if err := s.pool.QueryRow(ctx, selectKeySQL, streamName, streamID.String()).Scan(&key); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, encryption.ErrKeyNotFound
+		}
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,75 @@
+package encryptionredis
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+
+	"github.com/go-gulfstream/gulfstream/pkg/encryption"
+)
+
+const keyPrefix = "k"
+
+var _ encryption.KeyStore = (*KeyStore)(nil)
+
+// KeyStore keeps the empty value as the tombstone of the deleted key.
+type KeyStore struct {
+	rds redis.UniversalClient
+}
+
+func New(rds redis.UniversalClient) KeyStore {
+	return KeyStore{rds: rds}
+}
+
+func (s KeyStore) Key(ctx context.Context, streamName string, streamID uuid.UUID) ([]byte, error) {
+	key, err := s.get(ctx, streamName, streamID)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, encryption.ErrKeyNotFound
+	}
+	return key, nil
+}
+
+func (s KeyStore) CreateKey(ctx context.Context, streamName string, streamID uuid.UUID) ([]byte, error) {
+	key, err := encryption.NewKey()
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.rds.SetNX(ctx, toKey(streamName, streamID.String()), key, 0).Result()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return key, nil
+	}
+	key, err = s.get(ctx, streamName, streamID)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, encryption.ErrShredded
+	}
+	return key, nil
+}
+
+func (s KeyStore) DeleteKey(ctx context.Context, streamName string, streamID uuid.UUID) error {
+	return s.rds.Set(ctx, toKey(streamName, streamID.String()), []byte{}, 0).Err()
+}
+
+func (s KeyStore) get(ctx context.Context, streamName string, streamID uuid.UUID) ([]byte, error) {
+	key, err := s.rds.Get(ctx, toKey(streamName, streamID.String())).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, encryption.ErrKeyNotFound
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+func toKey(name string, id string) string {
+	return "gs." + keyPrefix + "." + name + id
+}
@@ -2,21 +2,34 @@ package event
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/go-gulfstream/gulfstream/pkg/clock"
 	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/go-gulfstream/gulfstream/pkg/idgen"
 
 	"github.com/google/uuid"
 )
 
 type Event struct {
-	id         uuid.UUID
-	streamID   uuid.UUID
-	streamName string
-	name       string
-	payload    codec.Codec
-	version    int
-	createdAt  int64
+	id            uuid.UUID
+	streamID      uuid.UUID
+	streamName    string
+	name          string
+	payload       codec.Codec
+	lazy          *lazyPayload
+	rawMarks      codec.FilterMarks
+	schemaVersion int
+	version       int
+	createdAt     time.Time
+}
+
+type lazyPayload struct {
+	once    sync.Once
+	decode  func() (codec.Codec, error)
+	payload codec.Codec
+	err     error
 }
 
 func New(
@@ -25,21 +38,51 @@ func New(
 	streamID uuid.UUID,
 	version int,
 	payload codec.Codec,
+	opts ...Option,
 ) *Event {
-	return &Event{
-		id:         uuid.New(),
+	e := &Event{
 		streamName: streamName,
 		streamID:   streamID,
 		name:       name,
 		payload:    payload,
 		version:    version,
-		createdAt:  time.Now().Unix(),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.id == uuid.Nil {
+		e.id = uuid.New()
+	}
+	if e.createdAt.IsZero() {
+		e.createdAt = time.Now()
+	}
+	return e
+}
+
+// Option configures the event created by New.
+type Option func(*Event)
+
+// WithClock sets the clock of the creation time of the event.
+func WithClock(c clock.Clock) Option {
+	return func(e *Event) {
+		if c != nil {
+			e.createdAt = c.Now()
+		}
+	}
+}
+
+// WithIDGenerator sets the generator of the event ID.
+func WithIDGenerator(g idgen.Generator) Option {
+	return func(e *Event) {
+		if g != nil {
+			e.id = g.NewID()
+		}
 	}
 }
 
 func (e *Event) String() string {
 	return fmt.Sprintf("Event{ID:%s, Name:%s, Version:%d, StreamName:%s, StreamID:%s, CreatedAt:%d, Payload: %v}",
-		e.id, e.name, e.version, e.streamName, e.streamID, e.createdAt, e.payload)
+		e.id, e.name, e.version, e.streamName, e.streamID, e.createdAt.UnixNano(), e.payload)
 }
 
 func (e *Event) ID() uuid.UUID {
@@ -58,8 +101,24 @@ func (e *Event) Version() int {
 	return e.version
 }
 
+// Payload returns the payload of the event. The lazily decoded payload
+// is decoded on the first call and is nil if the decoding fails.
+// The handlers of the lazy subscribers call DecodePayload to get the error.
 func (e *Event) Payload() codec.Codec {
-	return e.payload
+	payload, _ := e.DecodePayload()
+	return payload
+}
+
+// DecodePayload returns the payload of the event and the error
+// of the lazy decoding.
+func (e *Event) DecodePayload() (codec.Codec, error) {
+	if e.lazy == nil {
+		return e.payload, nil
+	}
+	e.lazy.once.Do(func() {
+		e.lazy.payload, e.lazy.err = e.lazy.decode()
+	})
+	return e.lazy.payload, e.lazy.err
 }
 
 func (e *Event) Name() string {
@@ -67,5 +126,33 @@ func (e *Event) Name() string {
 }
 
 func (e *Event) Unix() int64 {
+	return e.createdAt.Unix()
+}
+
+// CreatedAt returns the creation time of the event. The events decoded
+// from the legacy containers have the second precision.
+func (e *Event) CreatedAt() time.Time {
 	return e.createdAt
 }
+
+// Restore creates the event with the decoded fields.
+// It is used by the external encodings of the event.
+func Restore(
+	id uuid.UUID,
+	name string,
+	streamName string,
+	streamID uuid.UUID,
+	version int,
+	createdAt time.Time,
+	payload codec.Codec,
+) *Event {
+	return &Event{
+		id:         id,
+		streamName: streamName,
+		streamID:   streamID,
+		name:       name,
+		payload:    payload,
+		version:    version,
+		createdAt:  createdAt,
+	}
+}
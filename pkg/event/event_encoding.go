@@ -2,26 +2,44 @@ package event
 
 import (
 	"bytes"
-	"encoding"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"sort"
 	"unsafe"
 
 	"github.com/go-gulfstream/gulfstream/pkg/util"
 
 	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/google/uuid"
 )
 
 const (
-	containerSize = int(unsafe.Sizeof(Event{})) - 26
-	magicNumber   = uint16(129)
+	// FormatVersion is the version of the container written by the codec.
+	// Version 0 is the legacy container without the version and the extensions.
+	FormatVersion = uint8(1)
+
+	magicNumber       = uint16(129)
+	formatMagicNumber = uint16(1129)
+	containerSize     = int(unsafe.Sizeof(magicNumber) +
+		3*unsafe.Sizeof(uint32(0)) +
+		2*unsafe.Sizeof(uuid.UUID{}) +
+		2*unsafe.Sizeof(int64(0)))
+
+	extCreatedAtNanos = uint16(2)
+	extPayloadFilters = uint16(3)
+
+	// fixedExtensionSize is the size of the fixed-width extension,
+	// e.g. the nanoseconds or the schema version.
+	fixedExtensionSize = 10
 )
 
 var (
-	ErrInvalidInputData = errors.New("event: invalid data input for codec")
-	ErrCodecNotFound    = errors.New("event: codec not found")
+	ErrInvalidInputData  = errors.New("event: invalid data input for codec")
+	ErrCodecNotFound     = errors.New("event: codec not found")
+	ErrUnsupportedFormat = errors.New("event: unsupported container format version")
 )
 
 var defaultCodec = NewCodec()
@@ -32,36 +50,133 @@ type Encoding interface {
 }
 
 type Codec struct {
-	codec map[string]codec.Codec
+	codec   map[string]codec.Codec
+	schemas map[string]*schema
+	filters []PayloadFilter
+	raw     bool
+	buffers codec.BufferPool
 }
 
-func NewCodec() *Codec {
-	return &Codec{
-		codec: make(map[string]codec.Codec),
+type CodecOption func(*Codec)
+
+// WithRawPayloads makes the codec keep the payloads of the events without
+// the registered codec as codec.Raw instead of failing. The raw payloads
+// are re-encoded to the identical bytes bypassing the payload filters.
+func WithRawPayloads() CodecOption {
+	return func(c *Codec) {
+		c.raw = true
 	}
 }
 
+// PayloadFilter transforms the raw payload of the event after it is marshaled
+// and before it is unmarshaled, e.g. to encrypt or to compress it.
+// The filter implementing codec.Marker is recorded in the container
+// and decodes only the payloads it encoded. EncodePayload returns
+// codec.ErrSkipFilter to leave the payload as is.
+type PayloadFilter interface {
+	EncodePayload(e *Event, data []byte) ([]byte, error)
+	DecodePayload(e *Event, data []byte) ([]byte, error)
+}
+
+// PayloadSubstitute is returned by PayloadFilter.DecodePayload when the raw
+// payload can not be restored and the event is decoded with the substitute payload.
+type PayloadSubstitute struct {
+	Payload codec.Codec
+	Err     error
+}
+
+func (e *PayloadSubstitute) Error() string {
+	return fmt.Sprintf("event: payload substituted: %v", e.Err)
+}
+
+func (e *PayloadSubstitute) Unwrap() error {
+	return e.Err
+}
+
+func NewCodec(opts ...CodecOption) *Codec {
+	c := &Codec{
+		codec:   make(map[string]codec.Codec),
+		schemas: make(map[string]*schema),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
 func (c *Codec) Decode(data []byte) (*Event, error) {
+	e, err := c.DecodeLazy(data)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := e.DecodePayload()
+	if err != nil {
+		return nil, err
+	}
+	e.payload = payload
+	e.lazy = nil
+	return e, nil
+}
+
+// DecodeLazy decodes the event container and defers the decoding of the payload
+// until the first Event.Payload or Event.DecodePayload call.
+func (c *Codec) DecodeLazy(data []byte) (*Event, error) {
 	if len(data) < containerSize {
 		return nil, ErrInvalidInputData
 	}
-	command, rawPayload, err := c.decodeContainer(data)
+	e, rawPayload, ext, err := c.decodeContainer(data)
 	if err != nil {
 		return nil, err
 	}
-	payload, err := c.decodePayload(command.name, rawPayload)
+	schemaVersion, err := decodeSchemaVersion(ext)
 	if err != nil {
 		return nil, err
 	}
-	command.payload = payload
-	return command, nil
+	marks, _ := ext.Get(extPayloadFilters)
+	if _, found := c.codec[e.name]; c.raw && !found {
+		e.schemaVersion = schemaVersion
+		if len(rawPayload) > 0 {
+			raw := codec.Raw(rawPayload)
+			e.payload = &raw
+			e.rawMarks = marks
+		}
+		return e, nil
+	}
+	e.lazy = &lazyPayload{
+		decode: func() (codec.Codec, error) {
+			return c.UnmarshalPayload(e, schemaVersion, rawPayload, marks)
+		},
+	}
+	return e, nil
+}
+
+// UnmarshalPayload decodes the raw payload of the event written with the schema version
+// and the marks of the payload filters.
+// It is used by the external encodings of the event container.
+func (c *Codec) UnmarshalPayload(e *Event, schemaVersion int, data []byte, marks codec.FilterMarks) (codec.Codec, error) {
+	var err error
+	for i := len(c.filters) - 1; i >= 0; i-- {
+		filter := c.filters[i]
+		data, err = codec.DecodeFilter(filter, marks, data, func(data []byte) ([]byte, error) {
+			return filter.DecodePayload(e, data)
+		})
+		if err != nil {
+			var substitute *PayloadSubstitute
+			if errors.As(err, &substitute) {
+				return substitute.Payload, nil
+			}
+			return nil, err
+		}
+	}
+	return c.upcast(e, schemaVersion, data)
 }
 
-func (c *Codec) decodeContainer(data []byte) (*Event, []byte, error) {
+func (c *Codec) decodeContainer(data []byte) (*Event, []byte, codec.Extensions, error) {
 	reader := newReader(data)
 	reader.container = new(Event)
 	if err := util.ErrOneOf(
 		reader.checkMagicNumber,
+		reader.readFormatVersion,
 		reader.readPayloadSize,
 		reader.readNameSize,
 		reader.readStreamSize,
@@ -71,14 +186,16 @@ func (c *Codec) decodeContainer(data []byte) (*Event, []byte, error) {
 		reader.readStreamName,
 		reader.readCreatedAt,
 		reader.readVersion,
+		reader.readExtensions,
+		reader.readCreatedAtNanos,
 	); err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	payload, err := reader.readPayload()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
-	return reader.container, payload, nil
+	return reader.container, payload, reader.extensions, nil
 }
 
 func (c *Codec) decodePayload(event string, data []byte) (codec.Codec, error) {
@@ -89,22 +206,56 @@ func (c *Codec) decodePayload(event string, data []byte) (codec.Codec, error) {
 	if !found {
 		return nil, fmt.Errorf("event: decoder for %s payload not found", event)
 	}
-	val := reflect.New(reflect.TypeOf(cc).Elem())
-	if dec, ok := val.Interface().(encoding.BinaryUnmarshaler); ok {
-		if err := dec.UnmarshalBinary(data); err != nil {
-			return nil, err
-		}
-		return val.Interface().(codec.Codec), nil
+	return unmarshalPayload(cc, data)
+}
+
+func unmarshalPayload(cc codec.Codec, data []byte) (codec.Codec, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	payload := codec.New(cc)
+	if err := payload.UnmarshalBinary(data); err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("event: decode payload for %s", event)
+	return payload, nil
 }
 
 func (c *Codec) Encode(e *Event) ([]byte, error) {
-	payload, err := c.encodePayload(e)
+	payload, marks, err := c.MarshalPayload(e)
 	if err != nil {
 		return nil, err
 	}
-	return c.encodeContainer(e, payload)
+	return c.encodeContainer(e, payload, marks)
+}
+
+// MarshalPayload encodes the payload of the event with the current schema version
+// and returns the marks of the applied payload filters.
+// It is used by the external encodings of the event container.
+func (c *Codec) MarshalPayload(e *Event) ([]byte, codec.FilterMarks, error) {
+	if raw, ok := e.payload.(*codec.Raw); ok {
+		return *raw, e.rawMarks, nil
+	}
+	payload, err := c.encodePayload(e)
+	if err != nil {
+		return nil, nil, err
+	}
+	var marks codec.FilterMarks
+	for _, filter := range c.filters {
+		filter := filter
+		payload, marks, err = codec.EncodeFilter(filter, marks, payload, func(data []byte) ([]byte, error) {
+			return filter.EncodePayload(e, data)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return payload, marks, nil
+}
+
+// Use adds the payload filters. The filters are applied in the order
+// of adding on encoding and in the reverse order on decoding.
+func (c *Codec) Use(filters ...PayloadFilter) {
+	c.filters = append(c.filters, filters...)
 }
 
 func (c *Codec) RegisterMap(commands map[string]codec.Codec) {
@@ -113,6 +264,22 @@ func (c *Codec) RegisterMap(commands map[string]codec.Codec) {
 	}
 }
 
+// Names returns the sorted names of the events with the registered codec.
+func (c *Codec) Names() []string {
+	names := make([]string, 0, len(c.codec))
+	for name := range c.codec {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the codec registered for the event.
+func (c *Codec) Lookup(event string) (codec.Codec, bool) {
+	cc, found := c.codec[event]
+	return cc, found
+}
+
 func (c *Codec) Register(event string, cc codec.Codec) {
 	if cc == nil {
 		return
@@ -125,118 +292,151 @@ func (c *Codec) Register(event string, cc codec.Codec) {
 }
 
 func (c *Codec) encodePayload(e *Event) ([]byte, error) {
-	if e.payload == nil {
+	payload, err := e.DecodePayload()
+	if err != nil {
+		return nil, err
+	}
+	if payload == nil {
 		return nil, nil
 	}
 	_, found := c.codec[e.name]
 	if !found {
 		return nil, fmt.Errorf("%w %s", ErrCodecNotFound, e)
 	}
-	return e.Payload().MarshalBinary()
+	return payload.MarshalBinary()
 }
 
-func (c *Codec) encodeContainer(e *Event, payload []byte) ([]byte, error) {
-	w := newWriter(e, payload)
-	if err := util.ErrOneOf(
-		w.writeMagicNumber,
-		w.writePayloadSize,
-		w.writeNameSize,
-		w.writeStreamSize,
-		w.writeID,
-		w.writeStreamID,
-		w.writeName,
-		w.writeStreamName,
-		w.writeCreatedAt,
-		w.writeVersion,
-		w.writePayload,
-	); err != nil {
+// AppendEncode appends the encoded event to dst.
+func (c *Codec) AppendEncode(dst []byte, e *Event) ([]byte, error) {
+	payload, marks, err := c.MarshalPayload(e)
+	if err != nil {
 		return nil, err
 	}
-	return w.buf.Bytes(), nil
-}
-
-func RegisterCodec(event string, cc codec.Codec) {
-	defaultCodec.Register(event, cc)
-}
-
-func RegisterCodecs(events map[string]codec.Codec) {
-	defaultCodec.RegisterMap(events)
-}
-
-func Encode(e *Event) ([]byte, error) {
-	return defaultCodec.Encode(e)
+	w := c.newWriter(e, payload, marks)
+	return w.append(dst), nil
 }
 
-func Decode(data []byte) (*Event, error) {
-	return defaultCodec.Decode(data)
+// EncodeTo writes the encoded event to the writer using the pooled buffer.
+func (c *Codec) EncodeTo(out io.Writer, e *Event) error {
+	buf := c.buffers.Get()
+	defer c.buffers.Put(buf)
+	data, err := c.AppendEncode(*buf, e)
+	if err != nil {
+		return err
+	}
+	*buf = data
+	_, err = out.Write(data)
+	return err
 }
 
-type writer struct {
-	buf       *bytes.Buffer
-	prev      uintptr
-	container *Event
-	payload   []byte
+func (c *Codec) encodeContainer(e *Event, payload []byte, marks codec.FilterMarks) ([]byte, error) {
+	w := c.newWriter(e, payload, marks)
+	return w.write()
 }
 
-func newWriter(e *Event, payload []byte) *writer {
-	return &writer{
-		buf:       bytes.NewBuffer(nil),
-		container: e,
-		payload:   payload,
+func (c *Codec) newWriter(e *Event, payload []byte, marks codec.FilterMarks) writer {
+	w := writer{container: e, payload: payload}
+	if version := c.EventSchemaVersion(e); version != DefaultSchemaVersion {
+		w.extensions = codec.Extensions{extSchemaVersion: encodeSchemaVersion(version)}
 	}
+	if len(marks) > 0 {
+		if w.extensions == nil {
+			w.extensions = make(codec.Extensions, 2)
+		}
+		w.extensions[extPayloadFilters] = marks
+	}
+	return w
 }
 
-func (w *writer) writeMagicNumber() error {
-	return binary.Write(w.buf, binary.LittleEndian, magicNumber)
-}
-
-func (w *writer) writePayloadSize() error {
-	return binary.Write(w.buf, binary.LittleEndian, uint32(len(w.payload)))
+func RegisterCodec(event string, cc codec.Codec) {
+	defaultCodec.Register(event, cc)
 }
 
-func (w *writer) writeVersion() error {
-	return binary.Write(w.buf, binary.LittleEndian, int64(w.container.version))
+func RegisterCodecs(events map[string]codec.Codec) {
+	defaultCodec.RegisterMap(events)
 }
 
-func (w *writer) writeNameSize() error {
-	return binary.Write(w.buf, binary.LittleEndian, uint32(len(w.container.name)))
+func Use(filters ...PayloadFilter) {
+	defaultCodec.Use(filters...)
 }
 
-func (w *writer) writeStreamSize() error {
-	return binary.Write(w.buf, binary.LittleEndian, uint32(len(w.container.streamName)))
+func Encode(e *Event) ([]byte, error) {
+	return defaultCodec.Encode(e)
 }
 
-func (w *writer) writeID() error {
-	return binary.Write(w.buf, binary.LittleEndian, w.container.id)
+func Decode(data []byte) (*Event, error) {
+	return defaultCodec.Decode(data)
 }
 
-func (w *writer) writeStreamID() error {
-	return binary.Write(w.buf, binary.LittleEndian, w.container.streamID)
+func AppendEncode(dst []byte, e *Event) ([]byte, error) {
+	return defaultCodec.AppendEncode(dst, e)
 }
 
-func (w *writer) writeName() error {
-	return binary.Write(w.buf, binary.LittleEndian, []byte(w.container.name))
+func EncodeTo(out io.Writer, e *Event) error {
+	return defaultCodec.EncodeTo(out, e)
 }
 
-func (w *writer) writeStreamName() error {
-	return binary.Write(w.buf, binary.LittleEndian, []byte(w.container.streamName))
+func DecodeLazy(data []byte) (*Event, error) {
+	return defaultCodec.DecodeLazy(data)
 }
 
-func (w *writer) writeCreatedAt() error {
-	return binary.Write(w.buf, binary.LittleEndian, w.container.createdAt)
+func DefaultCodec() *Codec {
+	return defaultCodec
 }
 
-func (w *writer) writePayload() error {
-	return binary.Write(w.buf, binary.LittleEndian, w.payload)
+type writer struct {
+	container  *Event
+	payload    []byte
+	extensions codec.Extensions
+}
+
+func (w *writer) write() ([]byte, error) {
+	return w.append(make([]byte, 0, w.size())), nil
+}
+
+// size returns the capacity enough for the container.
+func (w *writer) size() int {
+	return containerSize + 1 + 4 + 2*fixedExtensionSize + w.extensions.Size() +
+		len(w.container.name) + len(w.container.streamName) + len(w.payload)
+}
+
+func (w *writer) append(dst []byte) []byte {
+	e := w.container
+	sec, nsec := codec.SplitTimestamp(e.createdAt)
+	dst = codec.Grow(dst, w.size())
+	dst = codec.AppendUint16(dst, formatMagicNumber)
+	dst = append(dst, FormatVersion)
+	dst = codec.AppendUint32(dst, uint32(len(w.payload)))
+	dst = codec.AppendUint32(dst, uint32(len(e.name)))
+	dst = codec.AppendUint32(dst, uint32(len(e.streamName)))
+	dst = append(dst, e.id[:]...)
+	dst = append(dst, e.streamID[:]...)
+	dst = append(dst, e.name...)
+	dst = append(dst, e.streamName...)
+	dst = codec.AppendUint64(dst, uint64(sec))
+	dst = codec.AppendUint64(dst, uint64(e.version))
+	dst, offset := codec.BeginExtensions(dst)
+	if len(w.extensions) > 0 {
+		w.extensions.SetNanos(extCreatedAtNanos, nsec)
+		dst = w.extensions.AppendBinary(dst)
+	} else {
+		dst = codec.AppendNanos(dst, extCreatedAtNanos, nsec)
+	}
+	dst = codec.EndExtensions(dst, offset)
+	return append(dst, w.payload...)
 }
 
 type reader struct {
 	reader      *bytes.Reader
 	data        []byte
 	prev        uintptr
+	versioned   bool
+	format      uint8
 	nameSize    uint32
 	streamSize  uint32
 	payloadSize uint32
+	createdAt   int64
+	extensions  codec.Extensions
 	container   *Event
 }
 
@@ -248,8 +448,12 @@ func newReader(data []byte) *reader {
 }
 
 func (r *reader) next(offset uintptr) {
-	r.reader.Reset(r.data[r.prev : r.prev+offset])
-	r.prev += offset
+	end := r.prev + offset
+	if end > uintptr(len(r.data)) {
+		end = uintptr(len(r.data))
+	}
+	r.reader.Reset(r.data[r.prev:end])
+	r.prev = end
 }
 
 func (r *reader) checkMagicNumber() error {
@@ -258,12 +462,52 @@ func (r *reader) checkMagicNumber() error {
 	if err := binary.Read(r.reader, binary.LittleEndian, &val); err != nil {
 		return err
 	}
-	if val != magicNumber {
+	switch val {
+	case magicNumber:
+	case formatMagicNumber:
+		r.versioned = true
+	default:
 		return ErrInvalidInputData
 	}
 	return nil
 }
 
+func (r *reader) readFormatVersion() error {
+	if !r.versioned {
+		return nil
+	}
+	r.next(unsafe.Sizeof(r.format))
+	if err := binary.Read(r.reader, binary.LittleEndian, &r.format); err != nil {
+		return err
+	}
+	if r.format == 0 || r.format > FormatVersion {
+		return ErrUnsupportedFormat
+	}
+	return nil
+}
+
+// readExtensions reads the extension area of the container.
+// The extensions with unknown tags are skipped.
+func (r *reader) readExtensions() error {
+	if r.format < 1 {
+		return nil
+	}
+	var size uint32
+	r.next(unsafe.Sizeof(size))
+	if err := binary.Read(r.reader, binary.LittleEndian, &size); err != nil {
+		return err
+	}
+	if uintptr(size) > uintptr(len(r.data))-r.prev {
+		return ErrInvalidInputData
+	}
+	r.next(uintptr(size))
+	data := make([]byte, size)
+	if err := binary.Read(r.reader, binary.LittleEndian, &data); err != nil {
+		return err
+	}
+	return r.extensions.UnmarshalBinary(data)
+}
+
 func (r *reader) readNameSize() error {
 	r.next(unsafe.Sizeof(r.nameSize))
 	return binary.Read(r.reader, binary.LittleEndian, &r.nameSize)
@@ -300,8 +544,19 @@ func (r *reader) readStreamID() error {
 }
 
 func (r *reader) readCreatedAt() error {
-	r.next(unsafe.Sizeof(r.container.createdAt))
-	return binary.Read(r.reader, binary.LittleEndian, &r.container.createdAt)
+	r.next(unsafe.Sizeof(r.createdAt))
+	return binary.Read(r.reader, binary.LittleEndian, &r.createdAt)
+}
+
+// readCreatedAtNanos restores the creation time with the nanoseconds
+// from the extensions.
+func (r *reader) readCreatedAtNanos() error {
+	nsec, err := r.extensions.Nanos(extCreatedAtNanos)
+	if err != nil {
+		return err
+	}
+	r.container.createdAt = codec.JoinTimestamp(r.createdAt, nsec)
+	return nil
 }
 
 func (r *reader) readName() error {
@@ -0,0 +1,82 @@
+package event
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+)
+
+func benchEvent() *Event {
+	payload := codec.Raw(`{"Test":"benchmark"}`)
+	return &Event{
+		id:         uuid.New(),
+		streamID:   uuid.New(),
+		name:       "created",
+		streamName: "users",
+		version:    3,
+		createdAt:  time.Unix(1634567890, 123456789),
+		payload:    &payload,
+	}
+}
+
+func BenchmarkCodec_Encode(b *testing.B) {
+	c := NewCodec()
+	e := benchEvent()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Encode(e); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCodec_AppendEncode(b *testing.B) {
+	c := NewCodec()
+	e := benchEvent()
+	buf := make([]byte, 0, 256)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var err error
+		if buf, err = c.AppendEncode(buf[:0], e); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCodec_EncodeTo(b *testing.B) {
+	c := NewCodec()
+	e := benchEvent()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := c.EncodeTo(ioutil.Discard, e); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestCodec_AppendEncode(t *testing.T) {
+	c := NewCodec()
+	e := benchEvent()
+	expected, err := c.Encode(e)
+	assert.NoError(t, err)
+
+	data, err := c.AppendEncode([]byte("prefix"), e)
+	assert.NoError(t, err)
+	assert.Equal(t, append([]byte("prefix"), expected...), data)
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.EncodeTo(&buf, e))
+	assert.Equal(t, expected, buf.Bytes())
+
+	dst := make([]byte, 0, 256)
+	allocs := testing.AllocsPerRun(100, func() {
+		dst, _ = c.AppendEncode(dst[:0], e)
+	})
+	assert.Zero(t, allocs)
+}
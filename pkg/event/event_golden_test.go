@@ -0,0 +1,137 @@
+package event
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update the golden file of the current format version")
+
+func goldenEvent() *Event {
+	return &Event{
+		id:         uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+		streamID:   uuid.MustParse("6ba7b811-9dad-11d1-80b4-00c04fd430c8"),
+		name:       "created",
+		streamName: "users",
+		version:    3,
+		createdAt:  time.Unix(1634567890, 0),
+		payload:    &somePayload{Test: "golden"},
+	}
+}
+
+func goldenFile(version uint8) string {
+	return filepath.Join("testdata", fmt.Sprintf("event.v%d.golden", version))
+}
+
+func TestCodec_GoldenFiles(t *testing.T) {
+	c := NewCodec()
+	c.Register("created", &somePayload{})
+	expected := goldenEvent()
+	data, err := c.Encode(expected)
+	assert.NoError(t, err)
+	if *update {
+		assert.NoError(t, os.WriteFile(goldenFile(FormatVersion), data, 0644))
+	}
+	golden, err := os.ReadFile(goldenFile(FormatVersion))
+	assert.NoError(t, err)
+	assert.Equal(t, golden, data)
+
+	for version := uint8(0); version <= FormatVersion; version++ {
+		data, err := os.ReadFile(goldenFile(version))
+		assert.NoError(t, err)
+		e, err := c.Decode(data)
+		if !assert.NoError(t, err, "version %d", version) {
+			continue
+		}
+		assert.Equal(t, expected.ID(), e.ID())
+		assert.Equal(t, expected.StreamID(), e.StreamID())
+		assert.Equal(t, expected.Name(), e.Name())
+		assert.Equal(t, expected.StreamName(), e.StreamName())
+		assert.Equal(t, expected.Version(), e.Version())
+		assert.Equal(t, expected.Unix(), e.Unix())
+		assert.Equal(t, expected.Payload(), e.Payload())
+	}
+}
+
+func TestCodec_DecodeSkipsUnknownExtensions(t *testing.T) {
+	c := NewCodec()
+	c.Register("created", &somePayload{})
+	expected := goldenEvent()
+	payload, err := expected.Payload().MarshalBinary()
+	assert.NoError(t, err)
+	w := writer{
+		container: expected,
+		payload:   payload,
+		extensions: codec.Extensions{
+			1000: []byte("unknown"),
+			1001: nil,
+		},
+	}
+	data, err := w.write()
+	assert.NoError(t, err)
+	e, err := c.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.ID(), e.ID())
+	assert.Equal(t, expected.Payload(), e.Payload())
+}
+
+func TestCodec_DecodeUnsupportedFormat(t *testing.T) {
+	golden, err := os.ReadFile(goldenFile(FormatVersion))
+	assert.NoError(t, err)
+	data := append([]byte(nil), golden...)
+	data[2] = FormatVersion + 1
+	_, err = NewCodec().Decode(data)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestCodec_DecodeTruncated(t *testing.T) {
+	golden, err := os.ReadFile(goldenFile(FormatVersion))
+	assert.NoError(t, err)
+	c := NewCodec()
+	c.Register("created", &somePayload{})
+	for size := containerSize; size < len(golden); size++ {
+		_, err := c.Decode(golden[:size])
+		assert.Error(t, err, "size %d", size)
+	}
+}
+
+func TestCodec_CreatedAtNanos(t *testing.T) {
+	c := NewCodec()
+	c.Register("created", &somePayload{})
+	expected := goldenEvent()
+	expected.createdAt = time.Unix(1634567890, 123456789)
+	data, err := c.Encode(expected)
+	assert.NoError(t, err)
+	e, err := c.Decode(data)
+	assert.NoError(t, err)
+	assert.True(t, expected.CreatedAt().Equal(e.CreatedAt()))
+	assert.Equal(t, int64(1634567890), e.Unix())
+
+	legacy, err := os.ReadFile(goldenFile(0))
+	assert.NoError(t, err)
+	e, err = c.Decode(legacy)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, e.CreatedAt().Nanosecond())
+}
+
+func TestCodec_DecodeOversizedExtensions(t *testing.T) {
+	c := NewCodec()
+	c.Register("created", &somePayload{})
+	e := goldenEvent()
+	data, err := c.Encode(e)
+	assert.NoError(t, err)
+	offset := 2 + 1 + 3*4 + 2*16 + len(e.Name()) + len(e.StreamName()) + 2*8
+	binary.LittleEndian.PutUint32(data[offset:], math.MaxUint32)
+	_, err = c.Decode(data)
+	assert.ErrorIs(t, err, ErrInvalidInputData)
+}
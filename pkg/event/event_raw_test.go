@@ -0,0 +1,134 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type xorFilter struct{}
+
+func (xorFilter) EncodePayload(_ *Event, data []byte) ([]byte, error) {
+	return xor(data), nil
+}
+
+func (xorFilter) DecodePayload(_ *Event, data []byte) ([]byte, error) {
+	return xor(data), nil
+}
+
+func xor(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = b ^ 0x5a
+	}
+	return out
+}
+
+func TestCodec_RawPayloads(t *testing.T) {
+	domain := NewCodec()
+	domain.RegisterSchema("created", 2, &somePayload{})
+	domain.Use(xorFilter{})
+	e := New("created", "users", uuid.New(), 1, &somePayload{Test: "raw"})
+	data, err := domain.Encode(e)
+	assert.NoError(t, err)
+
+	_, err = NewCodec().Decode(data)
+	assert.Error(t, err)
+
+	bridge := NewCodec(WithRawPayloads())
+	e2, err := bridge.Decode(data)
+	assert.NoError(t, err)
+	assert.IsType(t, &codec.Raw{}, e2.Payload())
+	assert.Equal(t, e.ID(), e2.ID())
+	data2, err := bridge.Encode(e2)
+	assert.NoError(t, err)
+	assert.Equal(t, data, data2)
+
+	e3, err := domain.Decode(data2)
+	assert.NoError(t, err)
+	assert.Equal(t, e.Payload(), e3.Payload())
+}
+
+type countingPayload struct {
+	Test string
+}
+
+var countingDecodes int
+
+func (p *countingPayload) MarshalBinary() ([]byte, error) {
+	return []byte(p.Test), nil
+}
+
+func (p *countingPayload) UnmarshalBinary(data []byte) error {
+	countingDecodes++
+	p.Test = string(data)
+	return nil
+}
+
+func TestCodec_DecodeLazy(t *testing.T) {
+	countingDecodes = 0
+	c := NewCodec()
+	c.Register("created", &countingPayload{})
+	e := New("created", "users", uuid.New(), 1, &countingPayload{Test: "lazy"})
+	data, err := c.Encode(e)
+	assert.NoError(t, err)
+
+	e2, err := c.DecodeLazy(data)
+	assert.NoError(t, err)
+	assert.Equal(t, e.ID(), e2.ID())
+	assert.Equal(t, 0, countingDecodes)
+	assert.Equal(t, e.Payload(), e2.Payload())
+	assert.Equal(t, e.Payload(), e2.Payload())
+	assert.Equal(t, 1, countingDecodes)
+
+	e3, err := NewCodec().DecodeLazy(data)
+	assert.NoError(t, err)
+	assert.Nil(t, e3.Payload())
+	_, err = e3.DecodePayload()
+	assert.Error(t, err)
+}
+
+type markedFilter struct {
+	xorFilter
+}
+
+func (markedFilter) FilterMark() byte { return 9 }
+
+func (f markedFilter) EncodePayload(e *Event, data []byte) ([]byte, error) {
+	if len(data) < 4 {
+		return nil, codec.ErrSkipFilter
+	}
+	return f.xorFilter.EncodePayload(e, data)
+}
+
+func TestCodec_MarkedFilters(t *testing.T) {
+	domain := NewCodec()
+	domain.Register("created", &somePayload{})
+	domain.Use(markedFilter{})
+	plain := NewCodec()
+	plain.Register("created", &somePayload{})
+
+	for _, test := range []string{"raw", "marked"} {
+		e := New("created", "users", uuid.New(), 1, &somePayload{Test: test})
+		data, err := plain.Encode(e)
+		assert.NoError(t, err)
+		e2, err := domain.Decode(data)
+		assert.NoError(t, err)
+		assert.Equal(t, e.Payload(), e2.Payload(), "the unmarked payload is decoded as is")
+
+		data, err = domain.Encode(e)
+		assert.NoError(t, err)
+		e2, err = domain.Decode(data)
+		assert.NoError(t, err)
+		assert.Equal(t, e.Payload(), e2.Payload())
+
+		bridge := NewCodec(WithRawPayloads())
+		e3, err := bridge.Decode(data)
+		assert.NoError(t, err)
+		data2, err := bridge.Encode(e3)
+		assert.NoError(t, err)
+		assert.Equal(t, data, data2, "the marks of the raw payload are kept")
+	}
+}
@@ -0,0 +1,177 @@
+package event
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+)
+
+const (
+	// DefaultSchemaVersion is the schema version of the event payload
+	// registered without RegisterSchema.
+	DefaultSchemaVersion = 1
+
+	extSchemaVersion = uint16(1)
+)
+
+// UpcasterFunc transforms the raw payload of the event
+// from the schema version N to N+1.
+type UpcasterFunc func(e *Event, data []byte) ([]byte, error)
+
+// PayloadUpcasterFunc transforms the decoded payload of the event
+// from the schema version N to N+1.
+type PayloadUpcasterFunc func(e *Event, payload codec.Codec) (codec.Codec, error)
+
+type schema struct {
+	version   int
+	upcasters map[int]upcaster
+}
+
+type upcaster struct {
+	raw     UpcasterFunc
+	from    codec.Codec
+	payload PayloadUpcasterFunc
+}
+
+// RegisterSchema registers the payload codec of the current schema version of the event.
+// The payloads of the older versions are upcasted to it on decoding.
+func (c *Codec) RegisterSchema(event string, version int, cc codec.Codec) {
+	if version < DefaultSchemaVersion {
+		panic(fmt.Sprintf("event: Codec.RegisterSchema(invalid version %d of %s)", version, event))
+	}
+	c.Register(event, cc)
+	c.schema(event).version = version
+}
+
+// AddUpcaster adds the upcaster of the raw payload of the event
+// from the schema version to the next one.
+func (c *Codec) AddUpcaster(event string, fromVersion int, fn UpcasterFunc) {
+	c.addUpcaster(event, fromVersion, upcaster{raw: fn})
+}
+
+// AddPayloadUpcaster adds the upcaster of the payload of the event
+// from the schema version to the next one. The raw payload of the version
+// is decoded with the from codec.
+func (c *Codec) AddPayloadUpcaster(event string, fromVersion int, from codec.Codec, fn PayloadUpcasterFunc) {
+	if reflect.ValueOf(from).Kind() != reflect.Ptr {
+		panic("event: Codec.AddPayloadUpcaster(non-pointer " + event + ")")
+	}
+	c.addUpcaster(event, fromVersion, upcaster{from: from, payload: fn})
+}
+
+// SchemaVersion returns the current schema version of the event payload.
+func (c *Codec) SchemaVersion(event string) int {
+	s, found := c.schemas[event]
+	if !found || s.version == 0 {
+		return DefaultSchemaVersion
+	}
+	return s.version
+}
+
+// EventSchemaVersion returns the schema version of the event payload:
+// the version of the kept raw payload or the current one.
+func (c *Codec) EventSchemaVersion(e *Event) int {
+	if e.schemaVersion > 0 {
+		return e.schemaVersion
+	}
+	return c.SchemaVersion(e.name)
+}
+
+func (c *Codec) addUpcaster(event string, fromVersion int, u upcaster) {
+	if fromVersion < DefaultSchemaVersion {
+		panic(fmt.Sprintf("event: Codec.AddUpcaster(invalid version %d of %s)", fromVersion, event))
+	}
+	c.schema(event).upcasters[fromVersion] = u
+}
+
+func (c *Codec) schema(event string) *schema {
+	s, found := c.schemas[event]
+	if !found {
+		s = &schema{upcasters: make(map[int]upcaster)}
+		c.schemas[event] = s
+	}
+	return s
+}
+
+func (c *Codec) upcast(e *Event, version int, data []byte) (codec.Codec, error) {
+	current := c.SchemaVersion(e.name)
+	if version > current {
+		return nil, fmt.Errorf("event: schema version %d of %s is newer than %d",
+			version, e.name, current)
+	}
+	var (
+		payload codec.Codec
+		decoded bool
+		err     error
+	)
+	for ; version < current; version++ {
+		u, found := c.schemas[e.name].upcasters[version]
+		if !found {
+			return nil, fmt.Errorf("event: upcaster for %s from schema version %d not found",
+				e.name, version)
+		}
+		if u.raw != nil {
+			if decoded {
+				if data, err = marshalPayload(payload); err != nil {
+					return nil, err
+				}
+				decoded = false
+			}
+			if data, err = u.raw(e, data); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !decoded {
+			if payload, err = unmarshalPayload(u.from, data); err != nil {
+				return nil, err
+			}
+			decoded = true
+		}
+		if payload, err = u.payload(e, payload); err != nil {
+			return nil, err
+		}
+	}
+	if decoded {
+		return payload, nil
+	}
+	return c.decodePayload(e.name, data)
+}
+
+func marshalPayload(payload codec.Codec) ([]byte, error) {
+	if payload == nil {
+		return nil, nil
+	}
+	return payload.MarshalBinary()
+}
+
+func encodeSchemaVersion(version int) []byte {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, uint32(version))
+	return data
+}
+
+func decodeSchemaVersion(ext codec.Extensions) (int, error) {
+	data, found := ext.Get(extSchemaVersion)
+	if !found {
+		return DefaultSchemaVersion, nil
+	}
+	if len(data) != 4 {
+		return 0, ErrInvalidInputData
+	}
+	return int(binary.LittleEndian.Uint32(data)), nil
+}
+
+func RegisterSchema(event string, version int, cc codec.Codec) {
+	defaultCodec.RegisterSchema(event, version, cc)
+}
+
+func AddUpcaster(event string, fromVersion int, fn UpcasterFunc) {
+	defaultCodec.AddUpcaster(event, fromVersion, fn)
+}
+
+func AddPayloadUpcaster(event string, fromVersion int, from codec.Codec, fn PayloadUpcasterFunc) {
+	defaultCodec.AddPayloadUpcaster(event, fromVersion, from, fn)
+}
@@ -0,0 +1,94 @@
+package event
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodec_Upcast(t *testing.T) {
+	v1 := NewCodec()
+	v1.Register("created", &userCreatedV1{})
+	e := New("created", "users", uuid.New(), 1, &userCreatedV1{Name: "John Smith"})
+	data, err := v1.Encode(e)
+	assert.NoError(t, err)
+
+	v3 := newUserCreatedCodec()
+	e2, err := v3.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, e.ID(), e2.ID())
+	assert.Equal(t, &userCreatedV3{FirstName: "John", LastName: "Smith"}, e2.Payload())
+
+	// the payload of the current version is decoded as is.
+	data, err = v3.Encode(e2)
+	assert.NoError(t, err)
+	e3, err := v3.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, e2.Payload(), e3.Payload())
+
+	// the payload of the newer version can not be decoded.
+	_, err = v1.Decode(data)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is newer than")
+}
+
+func TestCodec_UpcastNotFound(t *testing.T) {
+	v1 := NewCodec()
+	v1.Register("created", &userCreatedV1{})
+	data, err := v1.Encode(New("created", "users", uuid.New(), 1, &userCreatedV1{Name: "John"}))
+	assert.NoError(t, err)
+
+	v2 := NewCodec()
+	v2.RegisterSchema("created", 2, &userCreatedV2{})
+	_, err = v2.Decode(data)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "upcaster for created from schema version 1 not found")
+}
+
+func newUserCreatedCodec() *Codec {
+	c := NewCodec()
+	c.RegisterSchema("created", 3, &userCreatedV3{})
+	c.AddUpcaster("created", 1, func(e *Event, data []byte) ([]byte, error) {
+		return bytes.Replace(data, []byte(`"Name"`), []byte(`"FullName"`), 1), nil
+	})
+	c.AddPayloadUpcaster("created", 2, &userCreatedV2{},
+		func(e *Event, payload codec.Codec) (codec.Codec, error) {
+			names := strings.SplitN(payload.(*userCreatedV2).FullName, " ", 2)
+			p := &userCreatedV3{FirstName: names[0]}
+			if len(names) > 1 {
+				p.LastName = names[1]
+			}
+			return p, nil
+		})
+	return c
+}
+
+type userCreatedV1 struct {
+	Name string
+}
+
+func (p *userCreatedV1) MarshalBinary() ([]byte, error) { return json.Marshal(p) }
+
+func (p *userCreatedV1) UnmarshalBinary(data []byte) error { return json.Unmarshal(data, p) }
+
+type userCreatedV2 struct {
+	FullName string
+}
+
+func (p *userCreatedV2) MarshalBinary() ([]byte, error) { return json.Marshal(p) }
+
+func (p *userCreatedV2) UnmarshalBinary(data []byte) error { return json.Unmarshal(data, p) }
+
+type userCreatedV3 struct {
+	FirstName string
+	LastName  string
+}
+
+func (p *userCreatedV3) MarshalBinary() ([]byte, error) { return json.Marshal(p) }
+
+func (p *userCreatedV3) UnmarshalBinary(data []byte) error { return json.Unmarshal(data, p) }
@@ -2,8 +2,10 @@ package eventbus
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"github.com/go-gulfstream/gulfstream/pkg/stream"
 
@@ -15,8 +17,42 @@ const (
 	DefaultPartitions = 32
 	MinPartitions     = 1
 	MaxPartitions     = 1024
+
+	DefaultBufferSize = 1
+	MaxBufferSize     = 1 << 16
+)
+
+var (
+	ErrChannelClosed = errors.New("eventbus: channel closed")
+	ErrChannelFull   = errors.New("eventbus: channel partition is full")
 )
 
+// OverflowPolicy defines the behaviour of Channel.Publish
+// when the partition buffer of a stream is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the publisher until the partition has free space.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest queued event of the partition.
+	OverflowDropOldest
+	// OverflowError rejects the event with ErrChannelFull.
+	OverflowError
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
 type eventHandlerFunc struct {
 	handler         func(context.Context, *event.Event) error
 	rollbackHandler func(context.Context, *event.Event) error
@@ -41,6 +77,10 @@ func (fn eventHandlerFunc) Rollback(ctx context.Context, e *event.Event) error {
 	return fn.rollbackHandler(ctx, e)
 }
 
+func (fn eventHandlerFunc) EventNames() []string {
+	return []string{fn.eventName}
+}
+
 func HandlerFunc(eventName string, handler, rollbackHandler func(context.Context, *event.Event) error) stream.EventHandler {
 	return eventHandlerFunc{
 		handler:         handler,
@@ -50,20 +90,34 @@ func HandlerFunc(eventName string, handler, rollbackHandler func(context.Context
 }
 
 type Channel struct {
-	channels     map[string]*channel
-	errorHandler stream.EventErrorHandler
-	wg           *sync.WaitGroup
-	closed       bool
-	partitions   int
+	mu            sync.RWMutex
+	channels      map[string]*channel
+	subscriptions *Subscriptions
+	ctx           context.Context
+	errorHandler  stream.EventErrorHandler
+	wg            *sync.WaitGroup
+	closeOnce     sync.Once
+	closeSig      chan struct{}
+	drainSig      chan struct{}
+	closed        bool
+	listening     bool
+	partitions    int
+	bufferSize    int
+	overflow      OverflowPolicy
 }
 
 type Option func(*Channel)
 
 func NewChannel(o ...Option) *Channel {
 	eb := &Channel{
-		partitions: DefaultPartitions,
-		channels:   make(map[string]*channel),
-		wg:         new(sync.WaitGroup),
+		partitions:    DefaultPartitions,
+		bufferSize:    DefaultBufferSize,
+		overflow:      OverflowBlock,
+		channels:      make(map[string]*channel),
+		subscriptions: NewSubscriptions(),
+		closeSig:      make(chan struct{}),
+		drainSig:      make(chan struct{}),
+		wg:            new(sync.WaitGroup),
 	}
 	for _, f := range o {
 		f(eb)
@@ -79,6 +133,20 @@ func WithChannelPartitions(n int) Option {
 	}
 }
 
+func WithChannelBufferSize(n int) Option {
+	return func(eb *Channel) {
+		if n >= 1 && n <= MaxBufferSize {
+			eb.bufferSize = n
+		}
+	}
+}
+
+func WithChannelOverflowPolicy(p OverflowPolicy) Option {
+	return func(eb *Channel) {
+		eb.overflow = p
+	}
+}
+
 func WithChannelErrorHandler(h stream.EventErrorHandler) Option {
 	return func(eb *Channel) {
 		eb.errorHandler = h
@@ -86,83 +154,157 @@ func WithChannelErrorHandler(h stream.EventErrorHandler) Option {
 }
 
 func (b *Channel) Publish(events []*event.Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return ErrChannelClosed
+	}
 	for _, e := range events {
 		channel, ok := b.channels[e.StreamName()]
 		if !ok {
 			return fmt.Errorf("channel for stream %s not found",
 				e.StreamName())
 		}
-		channel.publish(e)
+		if err := channel.publish(e, b.overflow, b.closeSig); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func (b *Channel) Subscribe(streamName string, handlers ...stream.EventHandler) {
-	channel, ok := b.channels[streamName]
-	if !ok {
-		channel = newChannel(b.partitions, streamName)
-		b.wg.Add(1)
-	}
-	for _, h := range handlers {
-		channel.addRecv(h)
+// Subscribe adds the handlers of the stream. It is safe to subscribe
+// while the channel is listening, the handlers receive the events
+// published after the call.
+func (b *Channel) Subscribe(streamName string, handlers ...stream.EventHandler) stream.Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.channels[streamName]; !ok {
+		channel := newChannel(b.partitions, b.bufferSize, streamName, b.subscriptions)
+		b.channels[streamName] = channel
+		if b.listening && !b.closed {
+			channel.setErrorHandler(b.errorHandler)
+			channel.listen(b.ctx, b.wg, b.drainSig)
+		}
 	}
-	b.channels[streamName] = channel
+	return b.subscriptions.Add(streamName, handlers...)
 }
 
+// Describe returns the events consumed by the subscriptions of the channel.
+func (b *Channel) Describe() []SubscriptionInfo {
+	return b.subscriptions.Describe()
+}
+
+// Listen starts the partition workers of all subscribed streams and
+// blocks until the context is done or the channel is shut down.
+// In both cases the events already queued are handled before return.
 func (b *Channel) Listen(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrChannelClosed
+	}
 	for _, channel := range b.channels {
 		channel.setErrorHandler(b.errorHandler)
-		channel.listen(ctx)
+		channel.listen(ctx, b.wg, b.drainSig)
+	}
+	b.listening = true
+	b.ctx = ctx
+	b.mu.Unlock()
+	select {
+	case <-ctx.Done():
+		b.stop(ctx)
+	case <-b.drainSig:
 	}
 	b.wg.Wait()
 	return nil
 }
 
-func (b *Channel) Close() error {
-	if b.closed {
+// Shutdown stops accepting new events and waits until all queued
+// events are handled or the context is done.
+// If the channel has never been listened, the queued events are handled
+// with the given context.
+func (b *Channel) Shutdown(ctx context.Context) error {
+	b.stop(ctx)
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
 		return nil
 	}
-	b.closed = true
-	for _, channel := range b.channels {
-		channel.close()
+}
+
+func (b *Channel) Close() error {
+	return b.Shutdown(context.Background())
+}
+
+// Stats returns a snapshot of the queues state for each subscribed stream.
+func (b *Channel) Stats() map[string]QueueStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	stats := make(map[string]QueueStats, len(b.channels))
+	for streamName, channel := range b.channels {
+		stats[streamName] = channel.stats()
 	}
-	b.wg.Done()
-	return nil
+	return stats
+}
+
+// stop unblocks the waiting publishers first, then waits until
+// all of them leave Publish and only after that lets the partitions drain.
+func (b *Channel) stop(ctx context.Context) {
+	b.closeOnce.Do(func() {
+		close(b.closeSig)
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.closed = true
+		close(b.drainSig)
+		if b.listening {
+			return
+		}
+		for _, channel := range b.channels {
+			channel.setErrorHandler(b.errorHandler)
+			channel.listen(ctx, b.wg, b.drainSig)
+		}
+	})
+}
+
+type QueueStats struct {
+	Depth      int
+	Capacity   int
+	Partitions []int
+	Published  uint64
+	Dropped    uint64
 }
 
 type channel struct {
 	topic      string
-	recv       []stream.EventHandler
+	recv       *Subscriptions
 	partitions []chan *event.Event
 	pn         int
-	closeSig   chan struct{}
 	seed       uint32
-	once       sync.Once
-	wg         sync.WaitGroup
 	eh         stream.EventErrorHandler
+	published  uint64
+	dropped    uint64
 }
 
-func newChannel(partitions int, topic string) *channel {
+func newChannel(partitions int, bufferSize int, topic string, recv *Subscriptions) *channel {
 	ch := &channel{
 		pn:         partitions,
 		seed:       util.SeedUint32(),
 		topic:      topic,
-		recv:       []stream.EventHandler{},
-		closeSig:   make(chan struct{}),
+		recv:       recv,
 		partitions: make([]chan *event.Event, partitions),
 	}
 	for i := 0; i < partitions; i++ {
-		ch.partitions[i] = make(chan *event.Event, 1)
-		ch.wg.Add(1)
+		ch.partitions[i] = make(chan *event.Event, bufferSize)
 	}
 	return ch
 }
 
-func (ch *channel) addRecv(handler stream.EventHandler) *channel {
-	ch.recv = append(ch.recv, handler)
-	return ch
-}
-
 func (ch *channel) setErrorHandler(h stream.EventErrorHandler) *channel {
 	if h == nil {
 		return ch
@@ -171,72 +313,122 @@ func (ch *channel) setErrorHandler(h stream.EventErrorHandler) *channel {
 	return ch
 }
 
-func (ch *channel) close() {
-	ch.once.Do(func() {
-		close(ch.closeSig)
-	})
-	ch.wg.Wait()
+func (ch *channel) listen(ctx context.Context, wg *sync.WaitGroup, drainSig <-chan struct{}) {
+	for _, partition := range ch.partitions {
+		wg.Add(1)
+		go ch.listenPartition(ctx, wg, partition, drainSig)
+	}
 }
 
-func (ch *channel) listen(ctx context.Context) {
-	for i, partition := range ch.partitions {
-		go ch.listenPartition(ctx, i, partition)
+func (ch *channel) listenPartition(
+	ctx context.Context,
+	wg *sync.WaitGroup,
+	partition chan *event.Event,
+	drainSig <-chan struct{},
+) {
+	defer wg.Done()
+	for {
+		select {
+		case <-drainSig:
+			ch.drain(ctx, partition)
+			return
+		case e := <-partition:
+			ch.handle(ctx, e)
+		}
 	}
 }
 
-func (ch *channel) listenPartition(ctx context.Context, pn int, channel chan *event.Event) {
-	defer ch.wg.Done()
-	var closed bool
+func (ch *channel) drain(ctx context.Context, partition chan *event.Event) {
 	for {
 		select {
-		case <-ctx.Done():
-			closed = true
-			if len(ch.partitions[pn]) == 0 {
-				return
-			}
+		case e := <-partition:
+			ch.handle(ctx, e)
+		default:
 			return
-		case <-ch.closeSig:
-			closed = true
-			if len(ch.partitions[pn]) == 0 {
-				return
-			}
-		case e := <-channel:
-			rollback := -1
-			for i, recv := range ch.recv {
-				if !recv.Match(e.Name()) {
-					continue
-				}
-				if err := recv.Handle(ctx, e); err != nil {
-					rollback = i
-					if ch.eh != nil {
-						ch.eh.HandleError(ctx, e, err)
-					}
-					break
-				}
-			}
-			if rollback >= 0 {
-				for i := rollback; i >= 0; i-- {
-					recv := ch.recv[i]
-					if !recv.Match(e.Name()) {
-						continue
-					}
-					if err := recv.Rollback(ctx, e); err != nil {
-						if ch.eh != nil {
-							err = fmt.Errorf("eventHandler rollback: %w", err)
-							ch.eh.HandleError(ctx, e, err)
-						}
-					}
-				}
+		}
+	}
+}
+
+func (ch *channel) handle(ctx context.Context, e *event.Event) {
+	handlers := ch.recv.Handlers(ch.topic)
+	rollback := -1
+	for i, recv := range handlers {
+		if !recv.Match(e.Name()) {
+			continue
+		}
+		if err := recv.Handle(ctx, e); err != nil {
+			rollback = i
+			if ch.eh != nil {
+				ch.eh.HandleError(ctx, e, err)
 			}
-			if len(ch.partitions[pn]) == 0 && closed {
-				return
+			break
+		}
+	}
+	if rollback < 0 {
+		return
+	}
+	for i := rollback; i >= 0; i-- {
+		recv := handlers[i]
+		if !recv.Match(e.Name()) {
+			continue
+		}
+		if err := recv.Rollback(ctx, e); err != nil {
+			if ch.eh != nil {
+				err = fmt.Errorf("eventHandler rollback: %w", err)
+				ch.eh.HandleError(ctx, e, err)
 			}
 		}
 	}
 }
 
-func (ch *channel) publish(e *event.Event) {
+func (ch *channel) publish(e *event.Event, policy OverflowPolicy, closeSig <-chan struct{}) error {
 	key := e.StreamID().String() + e.StreamName()
 	idx := util.DJB2(ch.seed, key) % uint32(ch.pn)
-	ch.partitions[idx] <- e
+	partition := ch.partitions[idx]
+	switch policy {
+	case OverflowDropOldest:
+		for {
+			select {
+			case partition <- e:
+				atomic.AddUint64(&ch.published, 1)
+				return nil
+			default:
+			}
+			select {
+			case <-partition:
+				atomic.AddUint64(&ch.dropped, 1)
+			default:
+			}
+		}
+	case OverflowError:
+		select {
+		case partition <- e:
+		default:
+			return fmt.Errorf("%w: stream %s, partition %d",
+				ErrChannelFull, ch.topic, idx)
+		}
+	default:
+		select {
+		case partition <- e:
+		case <-closeSig:
+			return ErrChannelClosed
+		}
+	}
+	atomic.AddUint64(&ch.published, 1)
+	return nil
+}
+
+func (ch *channel) stats() QueueStats {
+	stats := QueueStats{
+		Partitions: make([]int, len(ch.partitions)),
+		Published:  atomic.LoadUint64(&ch.published),
+		Dropped:    atomic.LoadUint64(&ch.dropped),
+	}
+	for i, partition := range ch.partitions {
+		depth := len(partition)
+		stats.Partitions[i] = depth
+		stats.Depth += depth
+		stats.Capacity += cap(partition)
+	}
+	return stats
 }
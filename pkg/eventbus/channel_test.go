@@ -0,0 +1,223 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+)
+
+func TestChannel_ShutdownDrainsQueuedEvents(t *testing.T) {
+	var total uint32
+	bus := NewChannel(WithChannelBufferSize(64))
+	bus.Subscribe("users", HandlerFunc("created",
+		func(ctx context.Context, e *event.Event) error {
+			time.Sleep(time.Millisecond)
+			atomic.AddUint32(&total, 1)
+			return nil
+		}, nil))
+	streamID := uuid.New()
+	for i := 0; i < 50; i++ {
+		assert.NoError(t, bus.Publish([]*event.Event{
+			event.New("created", "users", streamID, i+1, nil),
+		}))
+	}
+	assert.NoError(t, bus.Shutdown(context.Background()))
+	assert.Equal(t, uint32(50), atomic.LoadUint32(&total))
+	assert.ErrorIs(t, bus.Listen(context.Background()), ErrChannelClosed)
+	assert.ErrorIs(t, bus.Publish([]*event.Event{
+		event.New("created", "users", streamID, 51, nil),
+	}), ErrChannelClosed)
+}
+
+func TestChannel_ListenContextDoneDrainsQueuedEvents(t *testing.T) {
+	var total uint32
+	bus := NewChannel(WithChannelBufferSize(16))
+	bus.Subscribe("users", HandlerFunc("created",
+		func(ctx context.Context, e *event.Event) error {
+			atomic.AddUint32(&total, 1)
+			return nil
+		}, nil))
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, bus.Publish([]*event.Event{
+			event.New("created", "users", uuid.New(), 1, nil),
+		}))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, bus.Listen(ctx))
+	assert.Equal(t, uint32(10), atomic.LoadUint32(&total))
+}
+
+func TestChannel_ShutdownDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	bus := NewChannel()
+	bus.Subscribe("users", HandlerFunc("created",
+		func(ctx context.Context, e *event.Event) error {
+			<-release
+			return nil
+		}, nil))
+	go bus.Listen(context.Background())
+	assert.NoError(t, bus.Publish([]*event.Event{
+		event.New("created", "users", uuid.New(), 1, nil),
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.True(t, errors.Is(bus.Shutdown(ctx), context.DeadlineExceeded))
+}
+
+func TestChannel_OverflowError(t *testing.T) {
+	bus := NewChannel(
+		WithChannelPartitions(2),
+		WithChannelBufferSize(2),
+		WithChannelOverflowPolicy(OverflowError),
+	)
+	bus.Subscribe("users")
+	streamID := uuid.New()
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, bus.Publish([]*event.Event{
+			event.New("created", "users", streamID, i+1, nil),
+		}))
+	}
+	err := bus.Publish([]*event.Event{
+		event.New("created", "users", streamID, 3, nil),
+	})
+	assert.ErrorIs(t, err, ErrChannelFull)
+	stats := bus.Stats()["users"]
+	assert.Equal(t, 2, stats.Depth)
+	assert.Equal(t, 4, stats.Capacity)
+	assert.Equal(t, uint64(2), stats.Published)
+}
+
+func TestChannel_OverflowDropOldest(t *testing.T) {
+	var versions []int
+	bus := NewChannel(
+		WithChannelPartitions(2),
+		WithChannelBufferSize(3),
+		WithChannelOverflowPolicy(OverflowDropOldest),
+	)
+	bus.Subscribe("users", HandlerFunc("created",
+		func(ctx context.Context, e *event.Event) error {
+			versions = append(versions, e.Version())
+			return nil
+		}, nil))
+	streamID := uuid.New()
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, bus.Publish([]*event.Event{
+			event.New("created", "users", streamID, i+1, nil),
+		}))
+	}
+	stats := bus.Stats()["users"]
+	assert.Equal(t, 3, stats.Depth)
+	assert.Equal(t, uint64(2), stats.Dropped)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, bus.Listen(ctx))
+	assert.Equal(t, []int{3, 4, 5}, versions)
+}
+
+func TestChannel_SubscribeWhileListening(t *testing.T) {
+	var first, second uint32
+	bus := NewChannel(WithChannelPartitions(4))
+	sub := bus.Subscribe("users", HandlerFunc("created",
+		func(ctx context.Context, e *event.Event) error {
+			atomic.AddUint32(&first, 1)
+			return nil
+		}, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- bus.Listen(ctx)
+	}()
+	bus.Subscribe("orders", HandlerFunc("created",
+		func(ctx context.Context, e *event.Event) error {
+			atomic.AddUint32(&second, 1)
+			return nil
+		}, nil))
+	assert.NoError(t, bus.Publish([]*event.Event{
+		event.New("created", "users", uuid.New(), 1, nil),
+		event.New("created", "orders", uuid.New(), 1, nil),
+	}))
+	assert.Eventually(t, func() bool {
+		return atomic.LoadUint32(&first) == 1 && atomic.LoadUint32(&second) == 1
+	}, time.Second, time.Millisecond)
+	sub.Unsubscribe()
+	assert.NoError(t, bus.Publish([]*event.Event{
+		event.New("created", "users", uuid.New(), 2, nil),
+	}))
+	cancel()
+	assert.NoError(t, <-done)
+	assert.Equal(t, uint32(1), atomic.LoadUint32(&first))
+}
+
+type errorHandlerFunc func(ctx context.Context, e *event.Event, err error)
+
+func (fn errorHandlerFunc) HandleError(ctx context.Context, e *event.Event, err error) {
+	fn(ctx, e, err)
+}
+
+func TestChannel_LazyPayload(t *testing.T) {
+	type created struct{ Name string }
+	events := event.NewCodec()
+	events.Register("created", codec.JSON(&created{}))
+	data, err := events.Encode(event.New("created", "users", uuid.New(), 1, codec.JSON(&created{Name: "John"})))
+	assert.NoError(t, err)
+	filter := &countingFilter{}
+	lazyEvents := event.NewCodec()
+	lazyEvents.Use(filter)
+	headerOnly, err := lazyEvents.DecodeLazy(data)
+	assert.NoError(t, err)
+	undecodable, err := lazyEvents.DecodeLazy(data)
+	assert.NoError(t, err)
+
+	var handled, failed uint32
+	bus := NewChannel(WithChannelErrorHandler(errorHandlerFunc(
+		func(ctx context.Context, e *event.Event, err error) {
+			atomic.AddUint32(&failed, 1)
+		})))
+	bus.Subscribe("users", HandlerFunc("created",
+		func(ctx context.Context, e *event.Event) error {
+			atomic.AddUint32(&handled, 1)
+			return nil
+		}, nil))
+	assert.NoError(t, bus.Publish([]*event.Event{headerOnly}))
+	assert.NoError(t, bus.Shutdown(context.Background()))
+	assert.Equal(t, uint32(1), atomic.LoadUint32(&handled))
+	assert.Zero(t, atomic.LoadUint32(&filter.decoded), "the header-only handler never decodes the payload")
+	assert.Zero(t, atomic.LoadUint32(&failed))
+
+	bus = NewChannel(WithChannelErrorHandler(errorHandlerFunc(
+		func(ctx context.Context, e *event.Event, err error) {
+			atomic.AddUint32(&failed, 1)
+		})))
+	bus.Subscribe("users", HandlerFunc("created",
+		func(ctx context.Context, e *event.Event) error {
+			_, err := e.DecodePayload()
+			return err
+		}, nil))
+	assert.NoError(t, bus.Publish([]*event.Event{undecodable}))
+	assert.NoError(t, bus.Shutdown(context.Background()))
+	assert.Equal(t, uint32(1), atomic.LoadUint32(&filter.decoded))
+	assert.Equal(t, uint32(1), atomic.LoadUint32(&failed), "the decode error of the handler is reported")
+}
+
+type countingFilter struct {
+	decoded uint32
+}
+
+func (f *countingFilter) EncodePayload(_ *event.Event, data []byte) ([]byte, error) {
+	return data, nil
+}
+
+func (f *countingFilter) DecodePayload(_ *event.Event, data []byte) ([]byte, error) {
+	atomic.AddUint32(&f.decoded, 1)
+	return data, nil
+}
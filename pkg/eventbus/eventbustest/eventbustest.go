@@ -0,0 +1,364 @@
+// Package eventbustest is the conformance suite of the stream.Publisher
+// and stream.Subscriber implementations. The subscriber must be listening
+// before Run is called, each case subscribes to its own stream.
+package eventbustest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+const (
+	DefaultTimeout = 5 * time.Second
+	tick           = 5 * time.Millisecond
+)
+
+type config struct {
+	timeout time.Duration
+	settle  time.Duration
+}
+
+type Option func(*config)
+
+// WithTimeout sets the time to wait for the delivery of the events.
+func WithTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.timeout = d
+	}
+}
+
+// WithSettle sets the pause between subscribing and publishing,
+// e.g. to let the consumer group of the broker rebalance.
+func WithSettle(d time.Duration) Option {
+	return func(c *config) {
+		c.settle = d
+	}
+}
+
+// Run runs the conformance suite against the publisher and the subscriber.
+func Run(t *testing.T, pub stream.Publisher, sub stream.Subscriber, opts ...Option) {
+	cfg := config{timeout: DefaultTimeout}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, b *bus)
+	}{
+		{"Delivery", testDelivery},
+		{"OrderingPerStream", testOrderingPerStream},
+		{"Rollback", testRollback},
+		{"Unsubscribe", testUnsubscribe},
+		{"MultipleSubscriptions", testMultipleSubscriptions},
+		{"ConcurrentPublish", testConcurrentPublish},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			c.fn(t, &bus{
+				pub:        pub,
+				sub:        sub,
+				cfg:        cfg,
+				streamName: "eventbustest" + uuid.New().String(),
+			})
+		})
+	}
+}
+
+type bus struct {
+	pub        stream.Publisher
+	sub        stream.Subscriber
+	cfg        config
+	streamName string
+}
+
+func (b *bus) subscribe(h ...stream.EventHandler) stream.Subscription {
+	sub := b.sub.Subscribe(b.streamName, h...)
+	if b.cfg.settle > 0 {
+		time.Sleep(b.cfg.settle)
+	}
+	return sub
+}
+
+func (b *bus) publish(t *testing.T, events ...*event.Event) bool {
+	return assert.NoError(t, b.pub.Publish(events))
+}
+
+func (b *bus) newEvent(name string, streamID uuid.UUID, version int) *event.Event {
+	return event.New(name, b.streamName, streamID, version, nil)
+}
+
+func (b *bus) wait(t *testing.T, cond func() bool, msg string) bool {
+	return assert.Eventually(t, cond, b.cfg.timeout, tick, msg)
+}
+
+// recorder is the handler recording the handled and the rolled back events.
+// The events are recorded once, the redelivered ones are skipped.
+type recorder struct {
+	mu         sync.Mutex
+	name       string
+	events     []string
+	handled    []*event.Event
+	seen       map[uuid.UUID]struct{}
+	rolledBack []*event.Event
+	fail       func(*event.Event) error
+	onRollback func(name string)
+}
+
+func newRecorder(name string, events ...string) *recorder {
+	return &recorder{
+		name:   name,
+		events: events,
+		seen:   make(map[uuid.UUID]struct{}),
+	}
+}
+
+func (r *recorder) Match(eventName string) bool {
+	if len(r.events) == 0 {
+		return true
+	}
+	for _, name := range r.events {
+		if name == eventName {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *recorder) Handle(_ context.Context, e *event.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail != nil {
+		if err := r.fail(e); err != nil {
+			return err
+		}
+	}
+	if _, found := r.seen[e.ID()]; found {
+		return nil
+	}
+	r.seen[e.ID()] = struct{}{}
+	r.handled = append(r.handled, e)
+	return nil
+}
+
+func (r *recorder) Rollback(_ context.Context, e *event.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rolledBack = append(r.rolledBack, e)
+	if r.onRollback != nil {
+		r.onRollback(r.name)
+	}
+	return nil
+}
+
+func (r *recorder) handledEvents() []*event.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*event.Event(nil), r.handled...)
+}
+
+func (r *recorder) rolledBackEvents() []*event.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*event.Event(nil), r.rolledBack...)
+}
+
+func (r *recorder) hasHandled(e *event.Event) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, found := r.seen[e.ID()]
+	return found
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.handled)
+}
+
+func names(events []*event.Event) []string {
+	out := make([]string, 0, len(events))
+	for _, e := range events {
+		out = append(out, e.Name())
+	}
+	return out
+}
+
+func testDelivery(t *testing.T, b *bus) {
+	h := newRecorder("created", "created")
+	defer b.subscribe(h).Unsubscribe()
+	streamID := uuid.New()
+	created := b.newEvent("created", streamID, 1)
+	if !b.publish(t, b.newEvent("ignored", streamID, 2), created) {
+		return
+	}
+	if !b.wait(t, func() bool { return h.hasHandled(created) }, "the event is not delivered") {
+		return
+	}
+	handled := h.handledEvents()
+	assert.Equal(t, []string{"created"}, names(handled))
+	got := handled[0]
+	assert.Equal(t, created.ID(), got.ID())
+	assert.Equal(t, created.StreamName(), got.StreamName())
+	assert.Equal(t, created.StreamID(), got.StreamID())
+	assert.Equal(t, created.Version(), got.Version())
+}
+
+func testOrderingPerStream(t *testing.T, b *bus) {
+	const (
+		streams  = 4
+		versions = 25
+	)
+	h := newRecorder("all")
+	defer b.subscribe(h).Unsubscribe()
+	streamIDs := make([]uuid.UUID, streams)
+	for i := range streamIDs {
+		streamIDs[i] = uuid.New()
+	}
+	for version := 1; version <= versions; version++ {
+		for _, streamID := range streamIDs {
+			if !b.publish(t, b.newEvent("changed", streamID, version)) {
+				return
+			}
+		}
+	}
+	if !b.wait(t, func() bool { return h.count() == streams*versions }, "not all events are delivered") {
+		return
+	}
+	last := make(map[uuid.UUID]int)
+	for _, e := range h.handledEvents() {
+		if !assert.Equal(t, last[e.StreamID()]+1, e.Version(),
+			"the events of the stream %s are out of order", e.StreamID()) {
+			return
+		}
+		last[e.StreamID()] = e.Version()
+	}
+}
+
+func testRollback(t *testing.T, b *bus) {
+	var (
+		mu        sync.Mutex
+		rollbacks []string
+		failed    bool
+	)
+	errFailed := errors.New("eventbustest: handler failed")
+	first := newRecorder("first", "created")
+	failing := newRecorder("failing", "created")
+	last := newRecorder("last", "created")
+	onRollback := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		rollbacks = append(rollbacks, name)
+	}
+	first.onRollback = onRollback
+	failing.onRollback = onRollback
+	failing.fail = func(e *event.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if failed {
+			return nil
+		}
+		failed = true
+		return errFailed
+	}
+	defer b.subscribe(first, failing, last).Unsubscribe()
+	created := b.newEvent("created", uuid.New(), 1)
+	if !b.publish(t, created) {
+		return
+	}
+	rolledBack := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(rollbacks) >= 2
+	}
+	if !b.wait(t, rolledBack, "the handlers are not rolled back") {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"failing", "first"}, rollbacks[:2],
+		"the handlers must be rolled back in the reverse order")
+	assert.Equal(t, created.ID(), first.rolledBackEvents()[0].ID())
+	assert.Empty(t, last.rolledBackEvents(), "the handlers after the failed one must not be rolled back")
+}
+
+func testUnsubscribe(t *testing.T, b *bus) {
+	h := newRecorder("unsubscribed")
+	sub := b.subscribe(h)
+	streamID := uuid.New()
+	before := b.newEvent("before", streamID, 1)
+	if !b.publish(t, before) {
+		sub.Unsubscribe()
+		return
+	}
+	if !b.wait(t, func() bool { return h.hasHandled(before) }, "the event is not delivered") {
+		sub.Unsubscribe()
+		return
+	}
+	sub.Unsubscribe()
+
+	witness := newRecorder("witness")
+	defer b.subscribe(witness).Unsubscribe()
+	after := b.newEvent("after", streamID, 2)
+	if !b.publish(t, after) {
+		return
+	}
+	if !b.wait(t, func() bool { return witness.hasHandled(after) }, "the event is not delivered") {
+		return
+	}
+	assert.False(t, h.hasHandled(after), "the event is delivered after unsubscribe")
+}
+
+func testMultipleSubscriptions(t *testing.T, b *bus) {
+	first := newRecorder("first")
+	second := newRecorder("second")
+	defer b.subscribe(first).Unsubscribe()
+	defer b.subscribe(second).Unsubscribe()
+	created := b.newEvent("created", uuid.New(), 1)
+	if !b.publish(t, created) {
+		return
+	}
+	b.wait(t, func() bool {
+		return first.hasHandled(created) && second.hasHandled(created)
+	}, "the event is not delivered to all subscriptions")
+}
+
+func testConcurrentPublish(t *testing.T, b *bus) {
+	const (
+		publishers = 8
+		versions   = 25
+	)
+	h := newRecorder("all")
+	defer b.subscribe(h).Unsubscribe()
+	var wg sync.WaitGroup
+	errs := make(chan error, publishers)
+	for i := 0; i < publishers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			streamID := uuid.New()
+			for version := 1; version <= versions; version++ {
+				if err := b.pub.Publish([]*event.Event{b.newEvent("changed", streamID, version)}); err != nil {
+					errs <- fmt.Errorf("publish %s v%d: %w", streamID, version, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	b.wait(t, func() bool { return h.count() == publishers*versions }, "not all events are delivered")
+}
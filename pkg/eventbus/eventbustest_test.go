@@ -0,0 +1,16 @@
+package eventbus
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-gulfstream/gulfstream/pkg/eventbus/eventbustest"
+)
+
+func TestChannel_Conformance(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus := NewChannel(WithChannelBufferSize(64))
+	go bus.Listen(ctx)
+	eventbustest.Run(t, bus, bus)
+}
@@ -0,0 +1,198 @@
+package eventbuskafka
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/hashicorp/go-multierror"
+)
+
+const (
+	DefaultTopicPartitions        = 16
+	DefaultTopicReplicationFactor = 1
+)
+
+type Admin struct {
+	admin             sarama.ClusterAdmin
+	naming            TopicNaming
+	partitions        int32
+	replicationFactor int16
+	retention         time.Duration
+	compact           bool
+	configEntries     map[string]*string
+}
+
+type AdminOption func(*Admin)
+
+// NewAdmin creates the helper that creates or validates the topics of the streams.
+func NewAdmin(
+	addr []string,
+	conf *sarama.Config,
+	opts ...AdminOption,
+) (*Admin, error) {
+	if conf == nil {
+		conf = DefaultConfig()
+	}
+	admin, err := sarama.NewClusterAdmin(addr, conf)
+	if err != nil {
+		return nil, err
+	}
+	return NewAdminFromClusterAdmin(admin, opts...), nil
+}
+
+func NewAdminFromClusterAdmin(admin sarama.ClusterAdmin, opts ...AdminOption) *Admin {
+	a := &Admin{
+		admin:             admin,
+		naming:            StreamTopic(),
+		partitions:        DefaultTopicPartitions,
+		replicationFactor: DefaultTopicReplicationFactor,
+		configEntries:     make(map[string]*string),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+func WithAdminTopicNaming(n TopicNaming) AdminOption {
+	return func(a *Admin) {
+		a.naming = n
+	}
+}
+
+func WithAdminPartitions(n int32) AdminOption {
+	return func(a *Admin) {
+		a.partitions = n
+	}
+}
+
+func WithAdminReplicationFactor(n int16) AdminOption {
+	return func(a *Admin) {
+		a.replicationFactor = n
+	}
+}
+
+func WithAdminRetention(d time.Duration) AdminOption {
+	return func(a *Admin) {
+		a.retention = d
+	}
+}
+
+func WithAdminCompaction() AdminOption {
+	return func(a *Admin) {
+		a.compact = true
+	}
+}
+
+func WithAdminConfigEntry(name, value string) AdminOption {
+	return func(a *Admin) {
+		a.configEntries[name] = &value
+	}
+}
+
+// EnsureTopics creates the missing topics of the streams and checks that
+// the existing topics have the desired number of partitions, replication factor
+// and config entries, e.g. the retention and the cleanup policy.
+func (a *Admin) EnsureTopics(streams ...string) error {
+	topics, err := resolveTopics(a.naming, streams)
+	if err != nil {
+		return err
+	}
+	existing, err := a.admin.ListTopics()
+	if err != nil {
+		return err
+	}
+	detail := a.topicDetail()
+	var result error
+	for _, topic := range topics {
+		current, found := existing[topic]
+		if !found {
+			if err := a.admin.CreateTopic(topic, detail, false); err != nil {
+				result = multierror.Append(result, err)
+			}
+			continue
+		}
+		if err := a.validate(topic, current); err != nil {
+			result = multierror.Append(result, err)
+			continue
+		}
+		if err := a.validateConfig(topic, detail.ConfigEntries); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+	return result
+}
+
+func (a *Admin) Close() error {
+	return a.admin.Close()
+}
+
+func (a *Admin) validate(topic string, current sarama.TopicDetail) error {
+	if current.NumPartitions != a.partitions {
+		return fmt.Errorf("eventbuskafka: topic %s has %d partitions, expected %d",
+			topic, current.NumPartitions, a.partitions)
+	}
+	if current.ReplicationFactor != a.replicationFactor {
+		return fmt.Errorf("eventbuskafka: topic %s has replication factor %d, expected %d",
+			topic, current.ReplicationFactor, a.replicationFactor)
+	}
+	return nil
+}
+
+func (a *Admin) validateConfig(topic string, expected map[string]*string) error {
+	if len(expected) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	entries, err := a.admin.DescribeConfig(sarama.ConfigResource{
+		Type:        sarama.TopicResource,
+		Name:        topic,
+		ConfigNames: names,
+	})
+	if err != nil {
+		return err
+	}
+	current := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		current[entry.Name] = entry.Value
+	}
+	var result error
+	for _, name := range names {
+		if expected[name] == nil {
+			continue
+		}
+		value, found := current[name]
+		if !found || value != *expected[name] {
+			result = multierror.Append(result, fmt.Errorf("eventbuskafka: topic %s has %s=%q, expected %q",
+				topic, name, value, *expected[name]))
+		}
+	}
+	return result
+}
+
+func (a *Admin) topicDetail() *sarama.TopicDetail {
+	entries := make(map[string]*string, len(a.configEntries)+2)
+	if a.retention > 0 {
+		retention := strconv.FormatInt(a.retention.Milliseconds(), 10)
+		entries["retention.ms"] = &retention
+	}
+	if a.compact {
+		policy := "compact"
+		entries["cleanup.policy"] = &policy
+	}
+	for name, value := range a.configEntries {
+		entries[name] = value
+	}
+	return &sarama.TopicDetail{
+		NumPartitions:     a.partitions,
+		ReplicationFactor: a.replicationFactor,
+		ConfigEntries:     entries,
+	}
+}
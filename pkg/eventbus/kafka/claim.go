@@ -0,0 +1,88 @@
+package eventbuskafka
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Shopify/sarama"
+	"github.com/go-gulfstream/gulfstream/pkg/util"
+)
+
+func (s *Subscriber) consumeClaimParallel(
+	ctx context.Context,
+	session sarama.ConsumerGroupSession,
+	claim sarama.ConsumerGroupClaim,
+) error {
+	var wg sync.WaitGroup
+	tracker := newOffsetTracker(session)
+	workers := make([]chan *trackedMessage, s.claimWorkers)
+	for i := range workers {
+		workers[i] = make(chan *trackedMessage, 1)
+		wg.Add(1)
+		go func(queue chan *trackedMessage) {
+			defer wg.Done()
+			for tm := range queue {
+				tracker.done(tm, s.handleMessage(ctx, tm.message))
+			}
+		}(workers[i])
+	}
+	seed := util.SeedUint32()
+	for message := range claim.Messages() {
+		tm := tracker.add(message)
+		idx := util.DJB2(seed, string(message.Key)) % uint32(len(workers))
+		workers[idx] <- tm
+	}
+	for _, queue := range workers {
+		close(queue)
+	}
+	wg.Wait()
+	return nil
+}
+
+type trackedMessage struct {
+	message *sarama.ConsumerMessage
+	done    bool
+	mark    bool
+}
+
+// offsetTracker marks the offset of a claim only up to the lowest message
+// that is still in processing, so an offset is never committed
+// before all the previous messages of the partition are handled.
+type offsetTracker struct {
+	mu      sync.Mutex
+	session sarama.ConsumerGroupSession
+	pending []*trackedMessage
+}
+
+func newOffsetTracker(session sarama.ConsumerGroupSession) *offsetTracker {
+	return &offsetTracker{
+		session: session,
+	}
+}
+
+func (t *offsetTracker) add(message *sarama.ConsumerMessage) *trackedMessage {
+	tm := &trackedMessage{message: message}
+	t.mu.Lock()
+	t.pending = append(t.pending, tm)
+	t.mu.Unlock()
+	return tm
+}
+
+func (t *offsetTracker) done(tm *trackedMessage, mark bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tm.done = true
+	tm.mark = mark
+	var last *sarama.ConsumerMessage
+	i := 0
+	for ; i < len(t.pending) && t.pending[i].done; i++ {
+		if t.pending[i].mark {
+			last = t.pending[i].message
+		}
+		t.pending[i] = nil
+	}
+	t.pending = t.pending[i:]
+	if last != nil {
+		t.session.MarkMessage(last, "")
+	}
+}
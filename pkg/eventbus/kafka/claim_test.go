@@ -0,0 +1,148 @@
+package eventbuskafka
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+	"github.com/go-gulfstream/gulfstream/pkg/eventbus"
+)
+
+func TestSubscriber_ConsumeClaimParallel(t *testing.T) {
+	var mu sync.Mutex
+	versions := make(map[uuid.UUID][]int)
+	sub := NewSubscriber(nil, nil, WithSubscriberClaimWorkers(4))
+	sub.Subscribe("users", eventbus.HandlerFunc("created",
+		func(ctx context.Context, e *event.Event) error {
+			time.Sleep(time.Duration(e.Version()%3) * time.Millisecond)
+			mu.Lock()
+			versions[e.StreamID()] = append(versions[e.StreamID()], e.Version())
+			mu.Unlock()
+			return nil
+		}, nil))
+
+	streams := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+	claim := newTestClaim("users")
+	var offset int64
+	for version := 1; version <= 10; version++ {
+		for _, streamID := range streams {
+			claim.push(t, offset, event.New("created", "users", streamID, version, nil))
+			offset++
+		}
+	}
+	close(claim.messages)
+
+	session := newTestSession()
+	assert.NoError(t, sub.ConsumeClaim(session, claim))
+	assert.Equal(t, offset-1, session.marked)
+	for _, streamID := range streams {
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, versions[streamID])
+	}
+}
+
+func TestOffsetTracker_MarksLowestProcessed(t *testing.T) {
+	session := newTestSession()
+	tracker := newOffsetTracker(session)
+	m0 := tracker.add(&sarama.ConsumerMessage{Offset: 0})
+	m1 := tracker.add(&sarama.ConsumerMessage{Offset: 1})
+	m2 := tracker.add(&sarama.ConsumerMessage{Offset: 2})
+	tracker.done(m2, true)
+	assert.Equal(t, int64(-1), session.marked)
+	tracker.done(m0, true)
+	assert.Equal(t, int64(0), session.marked)
+	tracker.done(m1, false)
+	assert.Equal(t, int64(2), session.marked)
+}
+
+type testClaim struct {
+	topic    string
+	messages chan *sarama.ConsumerMessage
+}
+
+func newTestClaim(topic string) *testClaim {
+	return &testClaim{
+		topic:    topic,
+		messages: make(chan *sarama.ConsumerMessage, 1024),
+	}
+}
+
+func (c *testClaim) push(t *testing.T, offset int64, e *event.Event) {
+	data, err := event.Encode(e)
+	assert.NoError(t, err)
+	c.messages <- &sarama.ConsumerMessage{
+		Topic:  c.topic,
+		Offset: offset,
+		Key:    []byte(e.StreamID().String()),
+		Value:  data,
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte("_stream"), Value: []byte(e.StreamName())},
+			{Key: []byte("_event"), Value: []byte(e.Name())},
+		},
+	}
+}
+
+func (c *testClaim) Topic() string                            { return c.topic }
+func (c *testClaim) Partition() int32                         { return 0 }
+func (c *testClaim) InitialOffset() int64                     { return 0 }
+func (c *testClaim) HighWaterMarkOffset() int64               { return 0 }
+func (c *testClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+// testSession keeps the offsets of the partitions the way sarama does:
+// ResetOffset only moves the offset back and MarkOffset only forward.
+type testSession struct {
+	mu      sync.Mutex
+	marked  int64
+	claims  map[string][]int32
+	offsets map[string]map[int32]int64
+}
+
+func newTestSession() *testSession {
+	return &testSession{marked: -1}
+}
+
+func (s *testSession) Claims() map[string][]int32 { return s.claims }
+func (s *testSession) MemberID() string           { return "" }
+func (s *testSession) GenerationID() int32        { return 0 }
+func (s *testSession) Commit()                    {}
+func (s *testSession) Context() context.Context   { return context.Background() }
+func (s *testSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if msg.Offset < s.marked {
+		panic("offset moved backwards")
+	}
+	s.marked = msg.Offset
+}
+
+func (s *testSession) ResetOffset(topic string, partition int32, offset int64, _ string) {
+	if offset <= s.offset(topic, partition) {
+		s.offsets[topic][partition] = offset
+	}
+}
+
+func (s *testSession) MarkOffset(topic string, partition int32, offset int64, _ string) {
+	if offset > s.offset(topic, partition) {
+		s.offsets[topic][partition] = offset
+	}
+}
+
+// offset returns the offset of the partition, -1 if the group has no offset.
+func (s *testSession) offset(topic string, partition int32) int64 {
+	if s.offsets == nil {
+		s.offsets = make(map[string]map[int32]int64)
+	}
+	if s.offsets[topic] == nil {
+		s.offsets[topic] = make(map[int32]int64)
+	}
+	offset, found := s.offsets[topic][partition]
+	if !found {
+		return -1
+	}
+	return offset
+}
@@ -0,0 +1,67 @@
+package eventbuskafka
+
+import (
+	"github.com/Shopify/sarama"
+
+	"github.com/go-gulfstream/gulfstream/pkg/cloudevents"
+)
+
+// WithPublisherCloudEvents makes the publisher encode the events to CloudEvents
+// in the structured or the binary content mode instead of the event codec.
+func WithPublisherCloudEvents(c *cloudevents.Codec, mode cloudevents.Mode) PublisherOption {
+	return func(p *Publisher) {
+		p.cloudEvents = c
+		p.cloudEventsMode = mode
+	}
+}
+
+// WithSubscriberCloudEvents makes the subscriber decode the CloudEvents messages.
+// The content mode is detected by the message headers, the other messages
+// are decoded by the event codec.
+func WithSubscriberCloudEvents(c *cloudevents.Codec) SubscriberOption {
+	return func(s *Subscriber) {
+		s.cloudEvents = c
+	}
+}
+
+type producerHeaders struct {
+	headers []sarama.RecordHeader
+}
+
+func (h *producerHeaders) Get(attr string) string {
+	key := cloudevents.KafkaHeader(attr)
+	for _, header := range h.headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (h *producerHeaders) Set(attr, value string) {
+	key := cloudevents.KafkaHeader(attr)
+	for i, header := range h.headers {
+		if string(header.Key) == key {
+			h.headers[i].Value = []byte(value)
+			return
+		}
+	}
+	h.headers = append(h.headers, sarama.RecordHeader{
+		Key:   []byte(key),
+		Value: []byte(value),
+	})
+}
+
+type consumerHeaders []*sarama.RecordHeader
+
+func (h consumerHeaders) Get(attr string) string {
+	key := cloudevents.KafkaHeader(attr)
+	for _, header := range h {
+		if header != nil && string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (h consumerHeaders) Set(string, string) {}
@@ -0,0 +1,57 @@
+package eventbuskafka
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/cloudevents"
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+)
+
+type userCreated struct {
+	Name string `json:"name"`
+}
+
+func TestCloudEvents_PublishSubscribe(t *testing.T) {
+	events := event.NewCodec()
+	events.Register("created", codec.JSON(&userCreated{}))
+	ce := cloudevents.New(cloudevents.WithEventCodec(events))
+	sub := NewSubscriber(nil, nil, WithSubscriberCloudEvents(ce))
+
+	for _, mode := range []cloudevents.Mode{cloudevents.Structured, cloudevents.Binary} {
+		pub := NewPublisher(nil, nil, WithPublisherCloudEvents(ce, mode))
+		e := event.New("created", "users", uuid.New(), 1, codec.JSON(&userCreated{Name: "John"}))
+		messages, err := pub.newMessages([]*event.Event{e})
+		assert.NoError(t, err)
+		message := consumerMessage(t, messages[0])
+
+		headers := consumerHeaders(message.Headers)
+		if mode == cloudevents.Binary {
+			assert.Equal(t, e.ID().String(), headers.Get("id"))
+			assert.Equal(t, "application/json", headers.Get("datacontenttype"))
+		} else {
+			assert.Equal(t, cloudevents.ContentType, headers.Get("datacontenttype"))
+		}
+		e2, err := sub.decodeEvent(message)
+		assert.NoError(t, err)
+		assert.Equal(t, e.ID(), e2.ID())
+		assert.Equal(t, e.Payload(), e2.Payload())
+	}
+}
+
+func consumerMessage(t *testing.T, m *sarama.ProducerMessage) *sarama.ConsumerMessage {
+	value, err := m.Value.Encode()
+	assert.NoError(t, err)
+	message := &sarama.ConsumerMessage{
+		Topic: m.Topic,
+		Value: value,
+	}
+	for i := range m.Headers {
+		message.Headers = append(message.Headers, &m.Headers[i])
+	}
+	return message
+}
@@ -14,6 +14,9 @@ func DefaultConfig() *sarama.Config {
 	conf.Producer.MaxMessageBytes = 1e6
 	conf.Producer.Retry.Max = 30
 	conf.Producer.RequiredAcks = sarama.WaitForAll
+	conf.Producer.Idempotent = true
+	conf.Net.MaxOpenRequests = 1
+	conf.Consumer.IsolationLevel = sarama.ReadCommitted
 	conf.Consumer.Offsets.Initial = sarama.OffsetNewest
 	conf.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
 	return conf
@@ -0,0 +1,154 @@
+package eventbuskafka
+
+import (
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/hashicorp/go-multierror"
+)
+
+// StartPosition resolves the offset of the partition the subscriber
+// starts consuming from. If ok is false the committed offset of the group is used.
+type StartPosition func(client sarama.Client, topic string, partition int32) (offset int64, ok bool, err error)
+
+// FromOldest starts from the earliest available offset of each partition.
+func FromOldest() StartPosition {
+	return func(client sarama.Client, topic string, partition int32) (int64, bool, error) {
+		offset, err := client.GetOffset(topic, partition, sarama.OffsetOldest)
+		return offset, err == nil, err
+	}
+}
+
+// FromNewest skips all the messages produced before the subscriber is started.
+func FromNewest() StartPosition {
+	return func(client sarama.Client, topic string, partition int32) (int64, bool, error) {
+		offset, err := client.GetOffset(topic, partition, sarama.OffsetNewest)
+		return offset, err == nil, err
+	}
+}
+
+// FromTime starts from the first message of each partition with a timestamp
+// equal or later than t.
+func FromTime(t time.Time) StartPosition {
+	return func(client sarama.Client, topic string, partition int32) (int64, bool, error) {
+		offset, err := client.GetOffset(topic, partition, t.UnixNano()/int64(time.Millisecond))
+		if err != nil {
+			return 0, false, err
+		}
+		if offset == sarama.OffsetNewest {
+			offset, err = client.GetOffset(topic, partition, sarama.OffsetNewest)
+		}
+		return offset, err == nil, err
+	}
+}
+
+// FromOffsets starts from the given offsets by topic and partition.
+// The partitions missing in the offsets keep the committed offset of the group.
+func FromOffsets(offsets map[string]map[int32]int64) StartPosition {
+	return func(_ sarama.Client, topic string, partition int32) (int64, bool, error) {
+		offset, ok := offsets[topic][partition]
+		return offset, ok, nil
+	}
+}
+
+// ResetGroupOffsets commits the offsets of the consumer group for all partitions
+// of the topics according to the start position.
+// The group must have no active members, otherwise the offsets are overwritten by them.
+func ResetGroupOffsets(
+	addr []string,
+	conf *sarama.Config,
+	group string,
+	topics []string,
+	position StartPosition,
+) error {
+	if conf == nil {
+		conf = DefaultConfig()
+	}
+	client, err := sarama.NewClient(addr, conf)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return resetGroupOffsets(client, group, topics, position)
+}
+
+func resetGroupOffsets(
+	client sarama.Client,
+	group string,
+	topics []string,
+	position StartPosition,
+) (err error) {
+	manager, err := sarama.NewOffsetManagerFromClient(group, client)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if er := manager.Close(); er != nil {
+			err = multierror.Append(err, er)
+		}
+	}()
+	for _, topic := range topics {
+		partitions, err := client.Partitions(topic)
+		if err != nil {
+			return err
+		}
+		for _, partition := range partitions {
+			offset, ok, err := position(client, topic, partition)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			pom, err := manager.ManagePartition(topic, partition)
+			if err != nil {
+				return err
+			}
+			// ResetOffset only moves the offset back and MarkOffset only forward,
+			// together they set the offset of the new and the existing groups.
+			pom.ResetOffset(offset, "")
+			pom.MarkOffset(offset, "")
+			if err := pom.Close(); err != nil {
+				return err
+			}
+		}
+	}
+	manager.Commit()
+	return nil
+}
+
+// startPositions applies the start position to each claimed partition once,
+// so the partitions reassigned after a rebalance keep their progress.
+type startPositions struct {
+	position StartPosition
+	applied  map[string]map[int32]struct{}
+}
+
+func (sp *startPositions) apply(client sarama.Client, sess sarama.ConsumerGroupSession) error {
+	if sp.position == nil {
+		return nil
+	}
+	if sp.applied == nil {
+		sp.applied = make(map[string]map[int32]struct{})
+	}
+	for topic, partitions := range sess.Claims() {
+		if _, found := sp.applied[topic]; !found {
+			sp.applied[topic] = make(map[int32]struct{})
+		}
+		for _, partition := range partitions {
+			if _, found := sp.applied[topic][partition]; found {
+				continue
+			}
+			offset, ok, err := sp.position(client, topic, partition)
+			if err != nil {
+				return err
+			}
+			if ok {
+				sess.ResetOffset(topic, partition, offset, "")
+				sess.MarkOffset(topic, partition, offset, "")
+			}
+			sp.applied[topic][partition] = struct{}{}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package eventbuskafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartPositions_ApplyOnce(t *testing.T) {
+	client := &testClient{oldest: 10, newest: 100}
+	positions := startPositions{position: FromOldest()}
+	session := newTestSession()
+	session.claims = map[string][]int32{"users": {0, 1}}
+	assert.NoError(t, positions.apply(client, session))
+	assert.Equal(t, map[int32]int64{0: 10, 1: 10}, session.offsets["users"],
+		"the new group starts from the position")
+
+	session = newTestSession()
+	session.claims = map[string][]int32{"users": {1, 2}}
+	session.offsets = map[string]map[int32]int64{"users": {1: 50, 2: 50}}
+	assert.NoError(t, positions.apply(client, session))
+	assert.Equal(t, map[int32]int64{1: 50, 2: 10}, session.offsets["users"],
+		"the position is applied once")
+}
+
+func TestStartPositions_MoveForward(t *testing.T) {
+	client := &testClient{oldest: 10, newest: 100}
+	positions := startPositions{position: FromNewest()}
+	session := newTestSession()
+	session.claims = map[string][]int32{"users": {0}}
+	session.offsets = map[string]map[int32]int64{"users": {0: 50}}
+	assert.NoError(t, positions.apply(client, session))
+	assert.Equal(t, int64(100), session.offsets["users"][0])
+}
+
+func TestResetGroupOffsets(t *testing.T) {
+	cases := []struct {
+		name      string
+		committed int64
+		position  StartPosition
+		expected  int64
+	}{
+		{"NewGroupFromOldest", -1, FromOldest(), 10},
+		{"NewGroupFromTime", -1, FromTime(time.UnixMilli(1000)), 42},
+		{"ForwardFromNewest", 50, FromNewest(), 100},
+		{"BackFromOffsets", 50, FromOffsets(map[string]map[int32]int64{"users": {0: 20}}), 20},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			broker := sarama.NewMockBroker(t, 1)
+			defer broker.Close()
+			broker.SetHandlerByMap(map[string]sarama.MockResponse{
+				"MetadataRequest": sarama.NewMockMetadataResponse(t).
+					SetBroker(broker.Addr(), broker.BrokerID()).
+					SetLeader("users", 0, broker.BrokerID()),
+				"OffsetRequest": sarama.NewMockOffsetResponse(t).
+					SetOffset("users", 0, sarama.OffsetOldest, 10).
+					SetOffset("users", 0, sarama.OffsetNewest, 100).
+					SetOffset("users", 0, 1000, 42),
+				"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+					SetCoordinator(sarama.CoordinatorGroup, "group", broker),
+				"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+					SetOffset("group", "users", 0, c.committed, "", sarama.ErrNoError),
+				"OffsetCommitRequest": sarama.NewMockOffsetCommitResponse(t),
+			})
+			conf := DefaultConfig()
+			conf.Version = sarama.V2_0_0_0
+			assert.NoError(t, ResetGroupOffsets([]string{broker.Addr()}, conf, "group", []string{"users"}, c.position))
+
+			var committed []int64
+			for _, rr := range broker.History() {
+				if req, ok := rr.Request.(*sarama.OffsetCommitRequest); ok {
+					offset, _, err := req.Offset("users", 0)
+					assert.NoError(t, err)
+					committed = append(committed, offset)
+				}
+			}
+			assert.Equal(t, []int64{c.expected}, committed)
+		})
+	}
+}
+
+func TestStartPosition_FromTime(t *testing.T) {
+	client := &testClient{oldest: 10, newest: 100, byTime: 42}
+	offset, ok, err := FromTime(time.Now())(client, "users", 0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), offset)
+
+	client.byTime = sarama.OffsetNewest
+	offset, ok, err = FromTime(time.Now())(client, "users", 0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(100), offset)
+}
+
+func TestStartPosition_FromOffsets(t *testing.T) {
+	position := FromOffsets(map[string]map[int32]int64{
+		"users": {1: 5},
+	})
+	offset, ok, err := position(nil, "users", 1)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(5), offset)
+	_, ok, err = position(nil, "users", 0)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+type testClient struct {
+	sarama.Client
+	oldest int64
+	newest int64
+	byTime int64
+}
+
+func (c *testClient) GetOffset(_ string, _ int32, t int64) (int64, error) {
+	switch t {
+	case sarama.OffsetOldest:
+		return c.oldest, nil
+	case sarama.OffsetNewest:
+		return c.newest, nil
+	default:
+		return c.byTime, nil
+	}
+}
@@ -1,18 +1,40 @@
 package eventbuskafka
 
 import (
+	"errors"
+	"sync"
+
 	"github.com/Shopify/sarama"
+	"github.com/go-gulfstream/gulfstream/pkg/cloudevents"
 	"github.com/go-gulfstream/gulfstream/pkg/event"
 	"github.com/go-gulfstream/gulfstream/pkg/stream"
+	"github.com/hashicorp/go-multierror"
 )
 
 var _ stream.Publisher = (*Publisher)(nil)
 
+var ErrAsyncTransactional = errors.New("eventbuskafka: async publisher can not be transactional")
+
+// FlushFunc is called by the async publisher when all the events
+// of a Publish call are acknowledged by the brokers or failed.
+// The calls are serialized.
+type FlushFunc func(events []*event.Event, err error)
+
 type Publisher struct {
-	brokers    []string
-	conf       *sarama.Config
-	eventCodec event.Encoding
-	producer   sarama.SyncProducer
+	mu              sync.Mutex
+	brokers         []string
+	conf            *sarama.Config
+	eventCodec      event.Encoding
+	cloudEvents     *cloudevents.Codec
+	cloudEventsMode cloudevents.Mode
+	naming          TopicNaming
+	producer        sarama.SyncProducer
+	asyncProducer   sarama.AsyncProducer
+	async           bool
+	flushFunc       FlushFunc
+	flushMu         sync.Mutex
+	transactionalID string
+	wg              sync.WaitGroup
 }
 
 type PublisherOption func(*Publisher)
@@ -27,6 +49,8 @@ func NewPublisher(
 	}
 	publisher := &Publisher{
 		brokers: addr,
+		conf:    conf,
+		naming:  StreamTopic(),
 	}
 	for _, opt := range opts {
 		opt(publisher)
@@ -40,21 +64,166 @@ func WithPublisherCodec(codec event.Encoding) PublisherOption {
 	}
 }
 
+func WithPublisherTopicNaming(n TopicNaming) PublisherOption {
+	return func(p *Publisher) {
+		p.naming = n
+	}
+}
+
+// WithPublisherTransactionalID makes the publisher send the events
+// of each Publish call in one Kafka transaction.
+// The id must be unique and stable for each instance of the publisher.
+func WithPublisherTransactionalID(id string) PublisherOption {
+	return func(p *Publisher) {
+		p.transactionalID = id
+	}
+}
+
+// WithPublisherAsync makes Publish return without waiting for the brokers.
+// The messages are batched by the producer according to Producer.Flush config,
+// the result of each Publish call is passed to the flush func.
+func WithPublisherAsync(fn FlushFunc) PublisherOption {
+	return func(p *Publisher) {
+		p.async = true
+		p.flushFunc = fn
+	}
+}
+
+// Connect creates the idempotent producer. The settings it requires
+// override the config passed to NewPublisher.
 func (p *Publisher) Connect() (err error) {
+	if len(p.transactionalID) > 0 && p.async {
+		return ErrAsyncTransactional
+	}
+	p.conf = p.producerConfig()
+	if p.async {
+		producer, err := sarama.NewAsyncProducer(p.brokers, p.conf)
+		if err != nil {
+			return err
+		}
+		p.startAsync(producer)
+		return nil
+	}
 	p.producer, err = sarama.NewSyncProducer(p.brokers, p.conf)
 	return
 }
 
+func (p *Publisher) producerConfig() *sarama.Config {
+	// the config of the caller may be shared with the other clients
+	conf := *p.conf
+	conf.Producer.Idempotent = true
+	conf.Producer.RequiredAcks = sarama.WaitForAll
+	conf.Net.MaxOpenRequests = 1
+	// the sync producer and the flush of the async batches need both
+	conf.Producer.Return.Successes = true
+	conf.Producer.Return.Errors = true
+	if len(p.transactionalID) > 0 {
+		conf.Producer.Transaction.ID = p.transactionalID
+	}
+	return &conf
+}
+
+func (p *Publisher) startAsync(producer sarama.AsyncProducer) {
+	p.asyncProducer = producer
+	p.wg.Add(2)
+	go p.dispatchSuccesses()
+	go p.dispatchErrors()
+}
+
 func (p *Publisher) Publish(events []*event.Event) error {
+	if p.producer == nil && p.asyncProducer == nil {
+		return nil
+	}
+	messages, err := p.newMessages(events)
+	if err != nil {
+		return err
+	}
+	if p.asyncProducer != nil {
+		p.publishAsync(events, messages)
+		return nil
+	}
+	if !p.producer.IsTransactional() {
+		return p.producer.SendMessages(messages)
+	}
+	return p.publishTxn(messages)
+}
+
+func (p *Publisher) publishTxn(messages []*sarama.ProducerMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.producer.BeginTxn(); err != nil {
+		return err
+	}
+	if err := p.producer.SendMessages(messages); err != nil {
+		if er := p.producer.AbortTxn(); er != nil {
+			err = multierror.Append(err, er)
+		}
+		return err
+	}
+	return p.producer.CommitTxn()
+}
+
+func (p *Publisher) publishAsync(events []*event.Event, messages []*sarama.ProducerMessage) {
+	b := &batch{events: events, pending: len(messages)}
+	if len(messages) == 0 {
+		p.flushBatch(b)
+		return
+	}
+	for _, message := range messages {
+		message.Metadata = b
+		p.asyncProducer.Input() <- message
+	}
+}
+
+func (p *Publisher) dispatchSuccesses() {
+	defer p.wg.Done()
+	for message := range p.asyncProducer.Successes() {
+		p.flush(message, nil)
+	}
+}
+
+func (p *Publisher) dispatchErrors() {
+	defer p.wg.Done()
+	for perr := range p.asyncProducer.Errors() {
+		p.flush(perr.Msg, perr.Err)
+	}
+}
+
+func (p *Publisher) flush(message *sarama.ProducerMessage, err error) {
+	b, ok := message.Metadata.(*batch)
+	if !ok {
+		return
+	}
+	if !b.done(err) {
+		return
+	}
+	p.flushBatch(b)
+}
+
+func (p *Publisher) flushBatch(b *batch) {
+	if p.flushFunc == nil {
+		return
+	}
+	p.flushMu.Lock()
+	defer p.flushMu.Unlock()
+	p.flushFunc(b.events, b.err)
+}
+
+func (p *Publisher) Close() error {
+	if p.asyncProducer != nil {
+		err := p.asyncProducer.Close()
+		p.wg.Wait()
+		return err
+	}
 	if p.producer == nil {
 		return nil
 	}
+	return p.producer.Close()
+}
+
+func (p *Publisher) newMessages(events []*event.Event) ([]*sarama.ProducerMessage, error) {
 	messages := make([]*sarama.ProducerMessage, len(events))
 	for i, e := range events {
-		data, err := p.encodeEvent(e)
-		if err != nil {
-			return err
-		}
 		route := e.StreamID().String()
 		headers := []sarama.RecordHeader{
 			{
@@ -66,28 +235,55 @@ func (p *Publisher) Publish(events []*event.Event) error {
 				Value: []byte(e.Name()),
 			},
 		}
+		data, headers, err := p.encodeEvent(e, headers)
+		if err != nil {
+			return nil, err
+		}
 		message := &sarama.ProducerMessage{
-			Topic:   e.StreamName(),
+			Topic:   p.naming.Topic(e.StreamName(), e.Name()),
 			Key:     sarama.StringEncoder(route),
 			Value:   sarama.ByteEncoder(data),
 			Headers: headers,
 		}
 		messages[i] = message
 	}
-	return p.producer.SendMessages(messages)
+	return messages, nil
 }
 
-func (p *Publisher) Close() error {
-	if p.producer == nil {
-		return nil
+func (p *Publisher) encodeEvent(e *event.Event, headers []sarama.RecordHeader) ([]byte, []sarama.RecordHeader, error) {
+	if p.cloudEvents != nil {
+		h := &producerHeaders{headers: headers}
+		if p.cloudEventsMode == cloudevents.Binary {
+			data, err := p.cloudEvents.EncodeBinary(e, h)
+			return data, h.headers, err
+		}
+		h.Set("datacontenttype", cloudevents.ContentType)
+		data, err := p.cloudEvents.Encode(e)
+		return data, h.headers, err
 	}
-	return p.producer.Close()
-}
-
-func (p *Publisher) encodeEvent(e *event.Event) ([]byte, error) {
+	var data []byte
+	var err error
 	if p.eventCodec != nil {
-		return p.eventCodec.Encode(e)
+		data, err = p.eventCodec.Encode(e)
 	} else {
-		return event.Encode(e)
+		data, err = event.Encode(e)
+	}
+	return data, headers, err
+}
+
+type batch struct {
+	mu      sync.Mutex
+	events  []*event.Event
+	pending int
+	err     error
+}
+
+func (b *batch) done(err error) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		b.err = multierror.Append(b.err, err)
 	}
+	b.pending--
+	return b.pending == 0
 }
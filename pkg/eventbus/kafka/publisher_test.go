@@ -0,0 +1,125 @@
+package eventbuskafka
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+)
+
+func TestPublisher_PublishTxn(t *testing.T) {
+	conf := DefaultConfig()
+	conf.Producer.Transaction.ID = "test"
+	producer := mocks.NewSyncProducer(t, conf)
+	producer.ExpectSendMessageAndSucceed()
+	producer.ExpectSendMessageAndSucceed()
+	publisher := NewPublisher(nil, conf)
+	publisher.producer = producer
+	streamID := uuid.New()
+	assert.NoError(t, publisher.Publish([]*event.Event{
+		event.New("event1", "users", streamID, 1, nil),
+		event.New("event2", "users", streamID, 2, nil),
+	}))
+	assert.Equal(t, sarama.ProducerTxnFlagReady, producer.TxnStatus())
+	assert.NoError(t, publisher.Close())
+}
+
+func TestPublisher_PublishAsync(t *testing.T) {
+	var wg sync.WaitGroup
+	flushed := make(map[int]error)
+	wg.Add(2)
+	conf := DefaultConfig()
+	producer := mocks.NewAsyncProducer(t, conf)
+	producer.ExpectInputAndSucceed()
+	producer.ExpectInputAndSucceed()
+	producer.ExpectInputAndFail(errors.New("broker down"))
+	publisher := NewPublisher(nil, conf, WithPublisherAsync(
+		func(events []*event.Event, err error) {
+			flushed[len(events)] = err
+			wg.Done()
+		}))
+	publisher.startAsync(producer)
+	streamID := uuid.New()
+	assert.NoError(t, publisher.Publish([]*event.Event{
+		event.New("event1", "users", streamID, 1, nil),
+		event.New("event2", "users", streamID, 2, nil),
+	}))
+	assert.NoError(t, publisher.Publish([]*event.Event{
+		event.New("event3", "users", streamID, 3, nil),
+	}))
+	wg.Wait()
+	assert.NoError(t, publisher.Close())
+	assert.Len(t, flushed, 2)
+	assert.NoError(t, flushed[2])
+	assert.Error(t, flushed[1])
+	assert.Contains(t, flushed[1].Error(), "broker down")
+}
+
+func TestPublisher_ConnectAsyncTransactional(t *testing.T) {
+	publisher := NewPublisher(nil, nil,
+		WithPublisherAsync(nil),
+		WithPublisherTransactionalID("test"))
+	assert.ErrorIs(t, publisher.Connect(), ErrAsyncTransactional)
+}
+
+func TestPublisher_PublishAsyncEmpty(t *testing.T) {
+	var flushed int
+	conf := DefaultConfig()
+	producer := mocks.NewAsyncProducer(t, conf)
+	publisher := NewPublisher(nil, conf, WithPublisherAsync(
+		func(events []*event.Event, err error) {
+			flushed++
+			assert.Empty(t, events)
+			assert.NoError(t, err)
+		}))
+	publisher.startAsync(producer)
+	assert.NoError(t, publisher.Publish(nil))
+	assert.Equal(t, 1, flushed)
+	assert.NoError(t, publisher.Close())
+}
+
+func TestPublisher_ConnectKeepsConfig(t *testing.T) {
+	conf := DefaultConfig()
+	conf.Producer.Idempotent = false
+	conf.Net.MaxOpenRequests = 5
+	publisher := NewPublisher(nil, conf, WithPublisherTransactionalID("test"))
+	assert.Error(t, publisher.Connect())
+	assert.False(t, conf.Producer.Idempotent)
+	assert.Empty(t, conf.Producer.Transaction.ID)
+	assert.Equal(t, 5, conf.Net.MaxOpenRequests)
+	assert.Equal(t, "test", publisher.conf.Producer.Transaction.ID)
+}
+
+func TestPublisher_ProducerConfig(t *testing.T) {
+	conf := sarama.NewConfig()
+	conf.Version = sarama.V2_7_0_0
+	conf.Producer.RequiredAcks = sarama.WaitForLocal
+	conf.Producer.Return.Successes = false
+	conf.Net.MaxOpenRequests = 5
+
+	publisher := NewPublisher(nil, conf, WithPublisherAsync(nil))
+	producerConf := publisher.producerConfig()
+	assert.True(t, producerConf.Producer.Idempotent)
+	assert.Equal(t, sarama.WaitForAll, producerConf.Producer.RequiredAcks)
+	assert.Equal(t, 1, producerConf.Net.MaxOpenRequests)
+	assert.True(t, producerConf.Producer.Return.Successes, "the async batches are flushed on success")
+	assert.True(t, producerConf.Producer.Return.Errors)
+	assert.Empty(t, producerConf.Producer.Transaction.ID)
+	assert.NoError(t, producerConf.Validate())
+
+	publisher = NewPublisher(nil, conf, WithPublisherTransactionalID("test"))
+	producerConf = publisher.producerConfig()
+	assert.True(t, producerConf.Producer.Idempotent)
+	assert.Equal(t, "test", producerConf.Producer.Transaction.ID)
+	assert.NoError(t, producerConf.Validate())
+
+	assert.False(t, conf.Producer.Idempotent, "the config of the caller is not changed")
+	assert.False(t, conf.Producer.Return.Successes)
+	assert.Equal(t, 5, conf.Net.MaxOpenRequests)
+}
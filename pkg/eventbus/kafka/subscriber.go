@@ -3,6 +3,8 @@ package eventbuskafka
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"unsafe"
 
 	"github.com/go-gulfstream/gulfstream/pkg/eventbus"
@@ -12,6 +14,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/Shopify/sarama"
+	"github.com/go-gulfstream/gulfstream/pkg/cloudevents"
 	"github.com/go-gulfstream/gulfstream/pkg/event"
 	"github.com/go-gulfstream/gulfstream/pkg/stream"
 )
@@ -19,10 +22,17 @@ import (
 var _ stream.Subscriber = (*Subscriber)(nil)
 
 type Subscriber struct {
+	mu            sync.Mutex
 	brokers       []string
-	handlers      map[string][]stream.EventHandler
+	subscriptions *eventbus.Subscriptions
+	rejoin        context.CancelFunc
 	eventCodec    event.Encoding
+	cloudEvents   *cloudevents.Codec
+	lazy          bool
+	naming        TopicNaming
+	client        sarama.Client
 	consumerGroup sarama.ConsumerGroup
+	startPosition startPositions
 	conf          *sarama.Config
 	contextFunc   []func(context.Context) context.Context
 	exitFunc      []func()
@@ -32,6 +42,7 @@ type Subscriber struct {
 	beforeFunc    []func(*sarama.ConsumerMessage) (bool, error)
 	deduplicator  eventbus.Deduplicator
 	group         string
+	claimWorkers  int
 	ready         chan error
 }
 
@@ -44,14 +55,16 @@ func NewSubscriber(
 		conf = DefaultConfig()
 	}
 	s := &Subscriber{
-		brokers:  addr,
-		conf:     conf,
-		handlers: make(map[string][]stream.EventHandler),
-		ready:    make(chan error),
+		brokers:       addr,
+		conf:          conf,
+		subscriptions: eventbus.NewSubscriptions(),
+		naming:        StreamTopic(),
+		ready:         make(chan error, 1),
 	}
 	for _, f := range opts {
 		f(s)
 	}
+	s.subscriptions.Notify(s.rejoinGroup)
 	return s
 }
 
@@ -99,33 +112,79 @@ func WithSubscriberErrorHandler(fn func(*event.Event, error)) SubscriberOption {
 	}
 }
 
+// WithSubscriberBeforeFunc adds the func called before the message is handled.
+// The error does not stop the handling of the message, if the func also
+// returns true the message offset is marked even if the handling fails.
 func WithSubscriberBeforeFunc(fn func(*sarama.ConsumerMessage) (bool, error)) SubscriberOption {
 	return func(s *Subscriber) {
 		s.beforeFunc = append(s.beforeFunc, fn)
 	}
 }
 
+// WithSubscriberClaimWorkers enables processing of a partition claim by n workers.
+// Messages with the same key (stream ID) are handled by the same worker in order,
+// messages of different streams are handled concurrently.
+func WithSubscriberClaimWorkers(n int) SubscriberOption {
+	return func(s *Subscriber) {
+		s.claimWorkers = n
+	}
+}
+
+func WithSubscriberTopicNaming(n TopicNaming) SubscriberOption {
+	return func(s *Subscriber) {
+		s.naming = n
+	}
+}
+
+// WithSubscriberStartPosition sets the offsets the subscriber starts from
+// instead of the committed offsets of the group. The position is applied
+// once for each partition claimed by the subscriber.
+func WithSubscriberStartPosition(p StartPosition) SubscriberOption {
+	return func(s *Subscriber) {
+		s.startPosition.position = p
+	}
+}
+
 func WithSubscriberCodec(codec event.Encoding) SubscriberOption {
 	return func(s *Subscriber) {
 		s.eventCodec = codec
 	}
 }
 
-func (s *Subscriber) Subscribe(streamName string, h ...stream.EventHandler) {
-	s.handlers[streamName] = append(s.handlers[streamName], h...)
+// WithSubscriberLazyPayloads makes the subscriber defer the decoding
+// of the payloads until the handlers ask for them. It applies to the codecs
+// with the DecodeLazy method, e.g. event.Codec. The handler returns the error
+// of Event.DecodePayload to leave the message unmarked.
+func WithSubscriberLazyPayloads() SubscriberOption {
+	return func(s *Subscriber) {
+		s.lazy = true
+	}
+}
+
+// Subscribe adds the handlers of the stream. If the subscriber is listening
+// and the set of the streams is changed, the consumer group session
+// is restarted to rejoin the group with the new topics.
+func (s *Subscriber) Subscribe(streamName string, h ...stream.EventHandler) stream.Subscription {
+	return s.subscriptions.Add(streamName, h...)
+}
+
+// Describe returns the events consumed by the subscriptions of the subscriber.
+func (s *Subscriber) Describe() []eventbus.SubscriptionInfo {
+	return s.subscriptions.Describe()
 }
 
 func (s *Subscriber) Listen(ctx context.Context) (err error) {
 	if len(s.group) == 0 {
 		s.group = "gulfstream." + uuid.New().String()
 	}
-	s.consumerGroup, err = sarama.NewConsumerGroup(s.brokers, s.group, s.conf)
+	s.client, err = sarama.NewClient(s.brokers, s.conf)
 	if err != nil {
 		return err
 	}
-	topics := make([]string, 0, len(s.handlers))
-	for streamName := range s.handlers {
-		topics = append(topics, streamName)
+	s.consumerGroup, err = sarama.NewConsumerGroupFromClient(s.group, s.client)
+	if err != nil {
+		_ = s.client.Close()
+		return err
 	}
 	go func() {
 		defer func() {
@@ -134,31 +193,70 @@ func (s *Subscriber) Listen(ctx context.Context) (err error) {
 			}
 		}()
 		for {
-			if err := s.consumerGroup.Consume(ctx, topics, s); err != nil {
-				select {
-				case s.ready <- err:
-				default:
-				}
+			sessionCtx, cancel := context.WithCancel(ctx)
+			s.setRejoin(cancel)
+			topics, err := resolveTopics(s.naming, s.subscriptions.Streams())
+			if err != nil {
+				cancel()
+				s.errorHandle(nil, err)
+				s.signalReady(err)
+				return
+			}
+			if len(topics) == 0 {
+				s.signalReady(nil)
+				<-sessionCtx.Done()
+			} else if err := s.consumerGroup.Consume(sessionCtx, topics, s); err != nil {
+				cancel()
+				s.signalReady(err)
 				return
 			}
+			cancel()
 			if ctx.Err() != nil {
 				return
 			}
-			s.ready = make(chan error)
 		}
 	}()
 	return <-s.ready
 }
 
+func (s *Subscriber) setRejoin(cancel context.CancelFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejoin = cancel
+}
+
+func (s *Subscriber) rejoinGroup() {
+	s.mu.Lock()
+	cancel := s.rejoin
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (s *Subscriber) signalReady(err error) {
+	select {
+	case s.ready <- err:
+	default:
+	}
+}
+
 func (s *Subscriber) Close() error {
 	if s.consumerGroup == nil {
 		return nil
 	}
-	return s.consumerGroup.Close()
+	err := s.consumerGroup.Close()
+	if er := s.client.Close(); er != nil {
+		err = multierror.Append(err, er)
+	}
+	return err
 }
 
 func (s *Subscriber) Setup(sess sarama.ConsumerGroupSession) error {
-	close(s.ready)
+	if err := s.startPosition.apply(s.client, sess); err != nil {
+		return err
+	}
+	s.signalReady(nil)
 	for _, setupFunc := range s.setupFunc {
 		if err := setupFunc(sess); err != nil {
 			return err
@@ -181,84 +279,94 @@ func (s *Subscriber) ConsumeClaim(session sarama.ConsumerGroupSession, claim sar
 	for _, ctcFunc := range s.contextFunc {
 		ctx = ctcFunc(ctx)
 	}
+	if s.claimWorkers > 1 {
+		return s.consumeClaimParallel(ctx, session, claim)
+	}
 	for message := range claim.Messages() {
-		for _, beforeFunc := range s.beforeFunc {
-			if mark, err := beforeFunc(message); err != nil {
-				if mark {
-					session.MarkMessage(message, "")
-				}
-				continue
-			}
-		}
-		streamName, eventName := findMetaInfoFromHeaders(message.Headers)
-		if len(streamName) == 0 || len(eventName) == 0 {
-			session.MarkMessage(message, "")
-			continue
-		}
-		handlers, found := s.handlers[message.Topic]
-		if !found {
+		if s.handleMessage(ctx, message) {
 			session.MarkMessage(message, "")
-			continue
-		}
-		var matched bool
-		for _, handler := range handlers {
-			if handler.Match(eventName) {
-				matched = true
-				break
-			}
 		}
-		if !matched {
-			session.MarkMessage(message, "")
-			continue
+	}
+	return nil
+}
+
+// handleMessage passes the message to the handlers of the stream
+// and reports whether the message offset can be marked as consumed.
+func (s *Subscriber) handleMessage(ctx context.Context, message *sarama.ConsumerMessage) bool {
+	var mark bool
+	for _, beforeFunc := range s.beforeFunc {
+		if ok, err := beforeFunc(message); err != nil && ok {
+			mark = true
 		}
-		e, err := s.decodeEvent(message.Value)
-		if err != nil {
-			s.errorHandle(nil, err)
-			continue
+	}
+	return s.dispatchMessage(ctx, message) || mark
+}
+
+func (s *Subscriber) dispatchMessage(ctx context.Context, message *sarama.ConsumerMessage) bool {
+	streamName, eventName := findMetaInfoFromHeaders(message.Headers)
+	if len(streamName) == 0 || len(eventName) == 0 {
+		return true
+	}
+	handlers := s.subscriptions.Handlers(streamName)
+	if len(handlers) == 0 {
+		return true
+	}
+	var matched bool
+	for _, handler := range handlers {
+		if handler.Match(eventName) {
+			matched = true
+			break
 		}
-		hasVisit, err := s.hasVisit(ctx, e)
-		if err != nil {
-			s.errorHandle(nil, err)
+	}
+	if !matched {
+		return true
+	}
+	e, err := s.decodeEvent(message)
+	if err != nil {
+		s.errorHandle(nil, err)
+		return false
+	}
+	hasVisit, err := s.hasVisit(ctx, e)
+	if err != nil {
+		s.errorHandle(nil, err)
+		return false
+	}
+	if hasVisit {
+		return true
+	}
+	rollback := -1
+	for i, recv := range handlers {
+		if !recv.Match(e.Name()) {
 			continue
 		}
-		if hasVisit {
-			session.MarkMessage(message, "")
-			continue
+		if er := recv.Handle(ctx, e); er != nil {
+			rollback = i
+			err = multierror.Append(err, er)
+			s.errorHandle(e, err)
+			break
 		}
-		rollback := -1
-		for i, recv := range handlers {
+	}
+	if rollback >= 0 {
+		for i := rollback; i >= 0; i-- {
+			recv := handlers[i]
 			if !recv.Match(e.Name()) {
 				continue
 			}
-			if er := recv.Handle(ctx, e); er != nil {
-				rollback = i
-				err = multierror.Append(err, er)
+			if er := recv.Rollback(ctx, e); er != nil {
+				err = fmt.Errorf("receiver rollback: %w", er)
 				s.errorHandle(e, err)
-				break
-			}
-		}
-		if rollback >= 0 {
-			for i := rollback; i >= 0; i-- {
-				recv := handlers[i]
-				if !recv.Match(e.Name()) {
-					continue
-				}
-				if er := recv.Rollback(ctx, e); er != nil {
-					err = fmt.Errorf("receiver rollback: %w", er)
-					s.errorHandle(e, err)
-					err = multierror.Append(err, er)
-				}
-			}
-		}
-		if err == nil {
-			if err := s.setVisit(ctx, e); err != nil {
-				s.errorHandle(e, err)
-				continue
+				err = multierror.Append(err, er)
 			}
-			session.MarkMessage(message, "")
 		}
 	}
-	return nil
+	if err != nil {
+		return false
+	}
+	if err := s.setVisit(ctx, e); err != nil {
+		s.errorHandle(e, err)
+		return false
+	}
+	return true
 }
 
 func (s *Subscriber) setVisit(ctx context.Context, e *event.Event) error {
@@ -275,12 +383,30 @@ func (s *Subscriber) hasVisit(ctx context.Context, e *event.Event) (bool, error)
 	return s.deduplicator.HasVisit(ctx, e)
 }
 
-func (s *Subscriber) decodeEvent(data []byte) (*event.Event, error) {
-	if s.eventCodec != nil {
-		return s.eventCodec.Decode(data)
-	} else {
-		return event.Decode(data)
+func (s *Subscriber) decodeEvent(message *sarama.ConsumerMessage) (*event.Event, error) {
+	if s.cloudEvents != nil {
+		headers := consumerHeaders(message.Headers)
+		if strings.HasPrefix(headers.Get("datacontenttype"), cloudevents.ContentType) {
+			return s.cloudEvents.Decode(message.Value)
+		}
+		if len(headers.Get("specversion")) > 0 {
+			return s.cloudEvents.DecodeBinary(message.Value, headers)
+		}
 	}
+	if s.eventCodec == nil {
+		if s.lazy {
+			return event.DecodeLazy(message.Value)
+		}
+		return event.Decode(message.Value)
+	}
+	if codec, ok := s.eventCodec.(lazyDecoder); ok && s.lazy {
+		return codec.DecodeLazy(message.Value)
+	}
+	return s.eventCodec.Decode(message.Value)
+}
+
+type lazyDecoder interface {
+	DecodeLazy([]byte) (*event.Event, error)
 }
 
 func (s *Subscriber) errorHandle(msg *event.Event, err error) {
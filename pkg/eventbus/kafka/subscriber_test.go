@@ -0,0 +1,132 @@
+package eventbuskafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+	"github.com/go-gulfstream/gulfstream/pkg/eventbus"
+)
+
+func TestSubscriber_LazyPayloads(t *testing.T) {
+	events := event.NewCodec()
+	events.Register("created", codec.JSON(&userCreated{}))
+	e := event.New("created", "users", uuid.New(), 1, codec.JSON(&userCreated{Name: "John"}))
+	data, err := events.Encode(e)
+	assert.NoError(t, err)
+	message := &sarama.ConsumerMessage{Value: data}
+
+	sub := NewSubscriber(nil, nil, WithSubscriberCodec(event.NewCodec()))
+	_, err = sub.decodeEvent(message)
+	assert.Error(t, err)
+
+	sub = NewSubscriber(nil, nil, WithSubscriberCodec(event.NewCodec()), WithSubscriberLazyPayloads())
+	e2, err := sub.decodeEvent(message)
+	assert.NoError(t, err)
+	assert.Equal(t, e.ID(), e2.ID())
+	_, err = e2.DecodePayload()
+	assert.Error(t, err)
+}
+
+func TestSubscriber_LazyPayloadHandlers(t *testing.T) {
+	events := event.NewCodec()
+	events.Register("created", codec.JSON(&userCreated{}))
+	data, err := events.Encode(event.New("created", "users", uuid.New(), 1, codec.JSON(&userCreated{Name: "John"})))
+	assert.NoError(t, err)
+	message := &sarama.ConsumerMessage{
+		Value: data,
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte("_stream"), Value: []byte("users")},
+			{Key: []byte("_event"), Value: []byte("created")},
+		},
+	}
+	filter := &countingFilter{}
+	lazyEvents := event.NewCodec()
+	lazyEvents.Use(filter)
+
+	var handled int
+	var errs []error
+	sub := NewSubscriber(nil, nil,
+		WithSubscriberCodec(lazyEvents),
+		WithSubscriberLazyPayloads(),
+		WithSubscriberErrorHandler(func(e *event.Event, err error) {
+			errs = append(errs, err)
+		}))
+	sub.Subscribe("users", eventbus.HandlerFunc("created",
+		func(ctx context.Context, e *event.Event) error {
+			handled++
+			return nil
+		}, nil))
+	assert.True(t, sub.handleMessage(context.Background(), message))
+	assert.Equal(t, 1, handled)
+	assert.Zero(t, filter.decoded, "the header-only handler never decodes the payload")
+	assert.Empty(t, errs)
+
+	sub = NewSubscriber(nil, nil,
+		WithSubscriberCodec(lazyEvents),
+		WithSubscriberLazyPayloads(),
+		WithSubscriberErrorHandler(func(e *event.Event, err error) {
+			errs = append(errs, err)
+		}))
+	sub.Subscribe("users", eventbus.HandlerFunc("created",
+		func(ctx context.Context, e *event.Event) error {
+			_, err := e.DecodePayload()
+			return err
+		}, nil))
+	assert.False(t, sub.handleMessage(context.Background(), message),
+		"the message is not marked when the handler fails to decode the payload")
+	assert.Equal(t, 1, filter.decoded)
+	assert.Len(t, errs, 1)
+}
+
+type countingFilter struct {
+	decoded int
+}
+
+func (f *countingFilter) EncodePayload(_ *event.Event, data []byte) ([]byte, error) {
+	return data, nil
+}
+
+func (f *countingFilter) DecodePayload(_ *event.Event, data []byte) ([]byte, error) {
+	f.decoded++
+	return data, nil
+}
+
+func TestSubscriber_BeforeFunc(t *testing.T) {
+	var handled int
+	errFailed := errors.New("failed")
+	sub := NewSubscriber(nil, nil,
+		WithSubscriberBeforeFunc(func(*sarama.ConsumerMessage) (bool, error) {
+			return true, errFailed
+		}))
+	sub.Subscribe("users", eventbus.HandlerFunc("created",
+		func(ctx context.Context, e *event.Event) error {
+			handled++
+			return errFailed
+		}, nil))
+	claim := newTestClaim("users")
+	claim.push(t, 0, event.New("created", "users", uuid.New(), 1, nil))
+	message := <-claim.messages
+
+	assert.True(t, sub.handleMessage(context.Background(), message),
+		"the message is marked when the before func asks for it")
+	assert.Equal(t, 1, handled, "the error of the before func does not stop the handling")
+
+	sub = NewSubscriber(nil, nil,
+		WithSubscriberBeforeFunc(func(*sarama.ConsumerMessage) (bool, error) {
+			return false, errFailed
+		}))
+	sub.Subscribe("users", eventbus.HandlerFunc("created",
+		func(ctx context.Context, e *event.Event) error {
+			handled++
+			return nil
+		}, nil))
+	assert.True(t, sub.handleMessage(context.Background(), message))
+	assert.Equal(t, 2, handled)
+}
@@ -0,0 +1,93 @@
+package eventbuskafka
+
+import (
+	"fmt"
+	"sort"
+)
+
+// TopicNaming resolves the Kafka topics of the streams.
+// The publisher and the subscriber must use the same naming.
+type TopicNaming interface {
+	// Topic returns the topic of the event of the stream.
+	Topic(streamName, eventName string) string
+	// Topics returns all topics of the stream.
+	Topics(streamName string) []string
+}
+
+// StreamTopic names the topic exactly after the stream name.
+func StreamTopic() TopicNaming {
+	return PrefixedTopic("")
+}
+
+// PrefixedTopic names the topic after the stream name with a prefix,
+// for example an environment or a tenant: "prod.orders".
+func PrefixedTopic(prefix string) TopicNaming {
+	return prefixedTopic{prefix: prefix}
+}
+
+// EventTopic names a separate topic for each event type of the stream:
+// "prefix.orders.orderCreated". The events maps the stream names
+// to the event names that the subscriber consumes, the streams missing
+// from the map have no topics and fail the subscriber and the admin.
+func EventTopic(prefix string, events map[string][]string) TopicNaming {
+	return eventTopic{
+		prefix: prefixedTopic{prefix: prefix},
+		events: events,
+	}
+}
+
+type prefixedTopic struct {
+	prefix string
+}
+
+func (n prefixedTopic) Topic(streamName, _ string) string {
+	return n.name(streamName)
+}
+
+func (n prefixedTopic) Topics(streamName string) []string {
+	return []string{n.name(streamName)}
+}
+
+func (n prefixedTopic) name(streamName string) string {
+	if len(n.prefix) == 0 {
+		return streamName
+	}
+	return n.prefix + "." + streamName
+}
+
+type eventTopic struct {
+	prefix prefixedTopic
+	events map[string][]string
+}
+
+func (n eventTopic) Topic(streamName, eventName string) string {
+	return n.prefix.name(streamName) + "." + eventName
+}
+
+func (n eventTopic) Topics(streamName string) []string {
+	events := n.events[streamName]
+	topics := make([]string, 0, len(events))
+	for _, eventName := range events {
+		topics = append(topics, n.Topic(streamName, eventName))
+	}
+	return topics
+}
+
+func resolveTopics(naming TopicNaming, streams []string) ([]string, error) {
+	uniq := make(map[string]struct{})
+	for _, streamName := range streams {
+		topics := naming.Topics(streamName)
+		if len(topics) == 0 {
+			return nil, fmt.Errorf("eventbuskafka: stream %s has no topics", streamName)
+		}
+		for _, topic := range topics {
+			uniq[topic] = struct{}{}
+		}
+	}
+	topics := make([]string, 0, len(uniq))
+	for topic := range uniq {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics, nil
+}
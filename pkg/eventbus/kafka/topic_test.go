@@ -0,0 +1,94 @@
+package eventbuskafka
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopicNaming(t *testing.T) {
+	assert.Equal(t, "orders", StreamTopic().Topic("orders", "created"))
+	assert.Equal(t, "prod.orders", PrefixedTopic("prod").Topic("orders", "created"))
+	assert.Equal(t, []string{"prod.orders"}, PrefixedTopic("prod").Topics("orders"))
+
+	naming := EventTopic("prod", map[string][]string{
+		"orders": {"created", "paid"},
+	})
+	assert.Equal(t, "prod.orders.created", naming.Topic("orders", "created"))
+	assert.Equal(t, []string{"prod.orders.created", "prod.orders.paid"}, naming.Topics("orders"))
+	assert.Empty(t, naming.Topics("users"))
+
+	topics, err := resolveTopics(StreamTopic(), []string{"b", "a", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, topics)
+
+	_, err = resolveTopics(naming, []string{"orders", "users"})
+	assert.EqualError(t, err, "eventbuskafka: stream users has no topics")
+	assert.Error(t, NewAdminFromClusterAdmin(&testClusterAdmin{},
+		WithAdminTopicNaming(naming)).EnsureTopics("users"))
+}
+
+func TestAdmin_EnsureTopics(t *testing.T) {
+	admin := &testClusterAdmin{
+		topics: map[string]sarama.TopicDetail{
+			"prod.users":    {NumPartitions: 8, ReplicationFactor: 3},
+			"prod.payments": {NumPartitions: 4, ReplicationFactor: 3},
+			"prod.invoices": {NumPartitions: 8, ReplicationFactor: 3},
+		},
+		configs: map[string][]sarama.ConfigEntry{
+			"prod.users": {
+				{Name: "cleanup.policy", Value: "compact"},
+				{Name: "retention.ms", Value: "3600000"},
+			},
+			"prod.invoices": {
+				{Name: "cleanup.policy", Value: "delete"},
+				{Name: "retention.ms", Value: "3600000"},
+			},
+		},
+	}
+	a := NewAdminFromClusterAdmin(admin,
+		WithAdminTopicNaming(PrefixedTopic("prod")),
+		WithAdminPartitions(8),
+		WithAdminReplicationFactor(3),
+		WithAdminRetention(time.Hour),
+		WithAdminCompaction(),
+	)
+	err := a.EnsureTopics("users", "orders", "payments", "invoices")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "prod.payments has 4 partitions, expected 8")
+	assert.Contains(t, err.Error(), `prod.invoices has cleanup.policy="delete", expected "compact"`)
+	assert.NotContains(t, err.Error(), "prod.users")
+	created, found := admin.topics["prod.orders"]
+	assert.True(t, found)
+	assert.Equal(t, int32(8), created.NumPartitions)
+	assert.Equal(t, "3600000", *created.ConfigEntries["retention.ms"])
+	assert.Equal(t, "compact", *created.ConfigEntries["cleanup.policy"])
+}
+
+func TestAdmin_EnsureTopicsListError(t *testing.T) {
+	admin := &testClusterAdmin{err: errors.New("no brokers")}
+	assert.Error(t, NewAdminFromClusterAdmin(admin).EnsureTopics("users"))
+}
+
+type testClusterAdmin struct {
+	sarama.ClusterAdmin
+	topics  map[string]sarama.TopicDetail
+	configs map[string][]sarama.ConfigEntry
+	err     error
+}
+
+func (a *testClusterAdmin) DescribeConfig(resource sarama.ConfigResource) ([]sarama.ConfigEntry, error) {
+	return a.configs[resource.Name], a.err
+}
+
+func (a *testClusterAdmin) ListTopics() (map[string]sarama.TopicDetail, error) {
+	return a.topics, a.err
+}
+
+func (a *testClusterAdmin) CreateTopic(topic string, detail *sarama.TopicDetail, _ bool) error {
+	a.topics[topic] = *detail
+	return nil
+}
@@ -23,4 +23,14 @@ func (mutatorHandler) Match(_ string) bool { return true }
 func (eb mutatorHandler) Handle(ctx context.Context, e *event.Event) error {
 	return eb.mutator.EventSink(ctx, e)
 }
+
+// EventNames returns the events of the mutator or nil
+// if the mutator does not list them.
+func (eb mutatorHandler) EventNames() []string {
+	if namer, ok := eb.mutator.(stream.EventNamer); ok {
+		return namer.EventNames()
+	}
+	return nil
+}
+
 func (mutatorHandler) Rollback(context.Context, *event.Event) error { return nil }
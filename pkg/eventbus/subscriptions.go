@@ -0,0 +1,178 @@
+package eventbus
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+// Subscriptions is a thread-safe registry of event handlers grouped by stream name.
+// It is shared by the event bus implementations to allow subscribing
+// and unsubscribing while the bus is listening.
+type Subscriptions struct {
+	mu       sync.RWMutex
+	seq      uint64
+	streams  map[string][]*subscription
+	handlers map[string][]stream.EventHandler
+	notify   []func()
+}
+
+func NewSubscriptions() *Subscriptions {
+	return &Subscriptions{
+		streams:  make(map[string][]*subscription),
+		handlers: make(map[string][]stream.EventHandler),
+	}
+}
+
+// Notify registers a callback that is called each time
+// the set of subscribed streams is changed.
+func (s *Subscriptions) Notify(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notify = append(s.notify, fn)
+}
+
+func (s *Subscriptions) Add(streamName string, h ...stream.EventHandler) stream.Subscription {
+	s.mu.Lock()
+	s.seq++
+	sub := &subscription{
+		id:         s.seq,
+		streamName: streamName,
+		handlers:   h,
+		owner:      s,
+	}
+	_, exists := s.streams[streamName]
+	s.streams[streamName] = append(s.streams[streamName], sub)
+	s.rebuild(streamName)
+	notify := s.notify
+	s.mu.Unlock()
+	if !exists {
+		for _, fn := range notify {
+			fn()
+		}
+	}
+	return sub
+}
+
+// Handlers returns the handlers of all live subscriptions of the stream
+// in the order of subscribing. The returned slice must not be modified.
+func (s *Subscriptions) Handlers(streamName string) []stream.EventHandler {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.handlers[streamName]
+}
+
+// Has reports whether the stream has at least one live subscription.
+func (s *Subscriptions) Has(streamName string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, found := s.streams[streamName]
+	return found
+}
+
+// Streams returns the sorted names of the streams with at least one live subscription.
+func (s *Subscriptions) Streams() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	streams := make([]string, 0, len(s.streams))
+	for streamName := range s.streams {
+		streams = append(streams, streamName)
+	}
+	sort.Strings(streams)
+	return streams
+}
+
+// SubscriptionInfo describes the events consumed from the stream.
+// AnyEvent is listed if one of the handlers does not name its events.
+type SubscriptionInfo struct {
+	StreamName string
+	Events     []string
+}
+
+const AnyEvent = "*"
+
+// Describe returns the events consumed by the live subscriptions
+// sorted by the stream name.
+func (s *Subscriptions) Describe() []SubscriptionInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	info := make([]SubscriptionInfo, 0, len(s.streams))
+	for streamName := range s.streams {
+		seen := make(map[string]struct{})
+		for _, h := range s.handlers[streamName] {
+			names := []string{AnyEvent}
+			if namer, ok := h.(stream.EventNamer); ok && namer.EventNames() != nil {
+				names = namer.EventNames()
+			}
+			for _, name := range names {
+				seen[name] = struct{}{}
+			}
+		}
+		events := make([]string, 0, len(seen))
+		for name := range seen {
+			events = append(events, name)
+		}
+		sort.Strings(events)
+		info = append(info, SubscriptionInfo{StreamName: streamName, Events: events})
+	}
+	sort.Slice(info, func(i, j int) bool {
+		return info[i].StreamName < info[j].StreamName
+	})
+	return info
+}
+
+func (s *Subscriptions) remove(sub *subscription) {
+	s.mu.Lock()
+	subs := s.streams[sub.streamName]
+	for i, other := range subs {
+		if other.id == sub.id {
+			subs = append(subs[:i:i], subs[i+1:]...)
+			break
+		}
+	}
+	var removed bool
+	if len(subs) == 0 {
+		delete(s.streams, sub.streamName)
+		removed = true
+	} else {
+		s.streams[sub.streamName] = subs
+	}
+	s.rebuild(sub.streamName)
+	notify := s.notify
+	s.mu.Unlock()
+	if removed {
+		for _, fn := range notify {
+			fn()
+		}
+	}
+}
+
+// rebuild makes a new handlers slice of the stream, so the slices
+// returned earlier by Handlers stay unchanged.
+func (s *Subscriptions) rebuild(streamName string) {
+	subs, found := s.streams[streamName]
+	if !found {
+		delete(s.handlers, streamName)
+		return
+	}
+	handlers := make([]stream.EventHandler, 0, len(subs))
+	for _, sub := range subs {
+		handlers = append(handlers, sub.handlers...)
+	}
+	s.handlers[streamName] = handlers
+}
+
+type subscription struct {
+	id         uint64
+	streamName string
+	handlers   []stream.EventHandler
+	owner      *Subscriptions
+	once       sync.Once
+}
+
+func (s *subscription) Unsubscribe() {
+	s.once.Do(func() {
+		s.owner.remove(s)
+	})
+}
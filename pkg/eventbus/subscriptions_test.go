@@ -0,0 +1,51 @@
+package eventbus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+func TestSubscriptions_AddUnsubscribe(t *testing.T) {
+	var changes int
+	subs := NewSubscriptions()
+	subs.Notify(func() { changes++ })
+
+	h1 := HandlerFunc("a", nil, nil)
+	h2 := HandlerFunc("b", nil, nil)
+	s1 := subs.Add("users", h1)
+	s2 := subs.Add("users", h2)
+	s3 := subs.Add("orders")
+	assert.Equal(t, 2, changes)
+	assert.Equal(t, []string{"orders", "users"}, subs.Streams())
+	assert.Len(t, subs.Handlers("users"), 2)
+
+	handlers := subs.Handlers("users")
+	s1.Unsubscribe()
+	s1.Unsubscribe()
+	assert.Len(t, handlers, 2)
+	assert.Len(t, subs.Handlers("users"), 1)
+	assert.True(t, subs.Handlers("users")[0].Match("b"))
+	assert.Equal(t, 2, changes)
+
+	s2.Unsubscribe()
+	s3.Unsubscribe()
+	assert.Equal(t, 4, changes)
+	assert.Empty(t, subs.Streams())
+	assert.False(t, subs.Has("users"))
+}
+
+func TestSubscriptions_Describe(t *testing.T) {
+	subs := NewSubscriptions()
+	projection := stream.NewProjection()
+	projection.AddEventController("created", nil)
+	projection.AddEventController("deleted", nil)
+	subs.Add("users", projection, HandlerFunc("created", nil, nil))
+	subs.Add("orders", HandlerFunc("paid", nil, nil), mutatorHandler{})
+	assert.Equal(t, []SubscriptionInfo{
+		{StreamName: "orders", Events: []string{AnyEvent, "paid"}},
+		{StreamName: "users", Events: []string{"created", "deleted"}},
+	}, subs.Describe())
+}
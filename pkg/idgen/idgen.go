@@ -0,0 +1,55 @@
+package idgen
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Generator is the source of the IDs of the events and the commands.
+type Generator interface {
+	NewID() uuid.UUID
+}
+
+type randomGenerator struct{}
+
+func (randomGenerator) NewID() uuid.UUID { return uuid.New() }
+
+// Random returns the generator of the random (version 4) UUIDs.
+func Random() Generator {
+	return randomGenerator{}
+}
+
+// OrRandom returns the generator or the random generator if it is nil.
+func OrRandom(g Generator) Generator {
+	if g == nil {
+		return Random()
+	}
+	return g
+}
+
+// Sequence is the deterministic generator for tests and replays.
+// It returns the UUIDs with the prefix and the incrementing counter
+// in the last 8 bytes.
+type Sequence struct {
+	mu     sync.Mutex
+	prefix [8]byte
+	n      uint64
+}
+
+func NewSequence(prefix uint64) *Sequence {
+	s := &Sequence{}
+	binary.BigEndian.PutUint64(s.prefix[:], prefix)
+	return s
+}
+
+func (s *Sequence) NewID() uuid.UUID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.n++
+	var id uuid.UUID
+	copy(id[:8], s.prefix[:])
+	binary.BigEndian.PutUint64(id[8:], s.n)
+	return id
+}
@@ -0,0 +1,14 @@
+package idgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSequence(t *testing.T) {
+	seq := NewSequence(1)
+	assert.Equal(t, "00000000-0000-0001-0000-000000000001", seq.NewID().String())
+	assert.Equal(t, "00000000-0000-0001-0000-000000000002", seq.NewID().String())
+	assert.Equal(t, "00000000-0000-0001-0000-000000000001", NewSequence(1).NewID().String())
+}
@@ -0,0 +1,175 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-gulfstream/gulfstream/pkg/clock"
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+const (
+	DefaultBreakerFailures = 5
+	DefaultBreakerCooldown = 30 * time.Second
+)
+
+var ErrCircuitOpen = errors.New("resilience: circuit is open")
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker stops the calls to the target after the consecutive failures.
+// After the cooldown the single probe call is let through, it closes
+// the circuit on success and opens it again on failure.
+// Create one breaker per target, e.g. per commandbus client.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	target    string
+	failures  int
+	cooldown  time.Duration
+	isFailure func(error) bool
+	clock     clock.Clock
+	state     BreakerState
+	failed    int
+	openedAt  time.Time
+}
+
+type BreakerOption func(*CircuitBreaker)
+
+// WithBreakerFailures sets the number of the consecutive failures opening the circuit.
+func WithBreakerFailures(n int) BreakerOption {
+	return func(b *CircuitBreaker) {
+		if n >= 1 {
+			b.failures = n
+		}
+	}
+}
+
+// WithBreakerCooldown sets the time the circuit stays open before the probe.
+func WithBreakerCooldown(d time.Duration) BreakerOption {
+	return func(b *CircuitBreaker) {
+		b.cooldown = d
+	}
+}
+
+// WithBreakerFailure sets the errors counted as the failures, IsTransient by default.
+func WithBreakerFailure(fn func(error) bool) BreakerOption {
+	return func(b *CircuitBreaker) {
+		b.isFailure = fn
+	}
+}
+
+func WithBreakerClock(c clock.Clock) BreakerOption {
+	return func(b *CircuitBreaker) {
+		b.clock = c
+	}
+}
+
+func NewCircuitBreaker(target string, opts ...BreakerOption) *CircuitBreaker {
+	b := &CircuitBreaker{
+		target:    target,
+		failures:  DefaultBreakerFailures,
+		cooldown:  DefaultBreakerCooldown,
+		isFailure: IsTransient,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	b.clock = clock.OrSystem(b.clock)
+	return b
+}
+
+func (b *CircuitBreaker) Target() string {
+	return b.target
+}
+
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && b.cooledDown() {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// Allow reports whether the call may be made. The done func must be called
+// with the result of the allowed call, the *PanicError is always the failure.
+func (b *CircuitBreaker) Allow() (done func(err error), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerHalfOpen:
+		return nil, b.errOpen()
+	case BreakerOpen:
+		if !b.cooledDown() {
+			return nil, b.errOpen()
+		}
+		b.state = BreakerHalfOpen
+	}
+	return b.done, nil
+}
+
+func (b *CircuitBreaker) done(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var perr *PanicError
+	if err != nil && (errors.As(err, &perr) || b.isFailure(err)) {
+		b.failed++
+		if b.state == BreakerHalfOpen || b.failed >= b.failures {
+			b.state = BreakerOpen
+			b.openedAt = b.clock.Now()
+		}
+		return
+	}
+	b.failed = 0
+	b.state = BreakerClosed
+}
+
+func (b *CircuitBreaker) cooledDown() bool {
+	return !b.clock.Now().Before(b.openedAt.Add(b.cooldown))
+}
+
+func (b *CircuitBreaker) errOpen() error {
+	return fmt.Errorf("%w: %s", ErrCircuitOpen, b.target)
+}
+
+// NewCommandSinkerCircuitBreaker rejects the commands with ErrCircuitOpen
+// while the circuit of the breaker is open.
+func NewCommandSinkerCircuitBreaker(b *CircuitBreaker) stream.CommandSinkerInterceptor {
+	return func(next stream.CommandSinker) stream.CommandSinker {
+		return commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			done, err := b.Allow()
+			if err != nil {
+				return nil, err
+			}
+			defer onPanic(func(err *PanicError) {
+				done(err)
+			})
+			reply, err := next.CommandSink(ctx, cmd)
+			done(err)
+			return reply, err
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+var ErrBulkheadFull = errors.New("resilience: bulkhead is full")
+
+// Bulkhead limits the concurrent calls per command or event name.
+type Bulkhead struct {
+	mu      sync.Mutex
+	limit   int
+	limits  map[string]int
+	slots   map[string]chan struct{}
+	maxWait time.Duration
+}
+
+type BulkheadOption func(*Bulkhead)
+
+// NewBulkhead creates the bulkhead with the limit of the concurrent calls
+// of each name. By default the calls over the limit wait for the free slot
+// until the context is done.
+func NewBulkhead(limit int, opts ...BulkheadOption) *Bulkhead {
+	if limit < 1 {
+		limit = 1
+	}
+	b := &Bulkhead{
+		limit:   limit,
+		limits:  make(map[string]int),
+		slots:   make(map[string]chan struct{}),
+		maxWait: -1,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// WithBulkheadLimit overrides the limit of the name.
+func WithBulkheadLimit(name string, limit int) BulkheadOption {
+	return func(b *Bulkhead) {
+		if limit >= 1 {
+			b.limits[name] = limit
+		}
+	}
+}
+
+// WithBulkheadMaxWait sets the time to wait for the free slot,
+// zero rejects the calls over the limit at once.
+func WithBulkheadMaxWait(d time.Duration) BulkheadOption {
+	return func(b *Bulkhead) {
+		b.maxWait = d
+	}
+}
+
+// Acquire takes the slot of the name. The release func must be called
+// when the call is done.
+func (b *Bulkhead) Acquire(ctx context.Context, name string) (release func(), err error) {
+	slots := b.slotsOf(name)
+	release = func() { <-slots }
+	select {
+	case slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+	if b.maxWait == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrBulkheadFull, name)
+	}
+	var timeout <-chan time.Time
+	if b.maxWait > 0 {
+		timer := time.NewTimer(b.maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case slots <- struct{}{}:
+		return release, nil
+	case <-timeout:
+		return nil, fmt.Errorf("%w: %s", ErrBulkheadFull, name)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w: %s: %v", ErrBulkheadFull, name, ctx.Err())
+	}
+}
+
+func (b *Bulkhead) slotsOf(name string) chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	slots, found := b.slots[name]
+	if !found {
+		limit, found := b.limits[name]
+		if !found {
+			limit = b.limit
+		}
+		slots = make(chan struct{}, limit)
+		b.slots[name] = slots
+	}
+	return slots
+}
+
+// NewCommandSinkerBulkhead limits the concurrent calls per command name.
+func NewCommandSinkerBulkhead(b *Bulkhead) stream.CommandSinkerInterceptor {
+	return func(next stream.CommandSinker) stream.CommandSinker {
+		return commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			release, err := b.Acquire(ctx, commandName(cmd))
+			if err != nil {
+				return nil, err
+			}
+			defer release()
+			return next.CommandSink(ctx, cmd)
+		})
+	}
+}
+
+// NewEventSinkerBulkhead limits the concurrent calls per event name.
+func NewEventSinkerBulkhead(b *Bulkhead) stream.EventSinkerInterceptor {
+	return func(next stream.EventSinker) stream.EventSinker {
+		return eventSinkerFunc(func(ctx context.Context, e *event.Event) error {
+			release, err := b.Acquire(ctx, eventName(e))
+			if err != nil {
+				return err
+			}
+			defer release()
+			return next.EventSink(ctx, e)
+		})
+	}
+}
+
+// NewEventHandlerBulkhead limits the concurrent calls of the handler per event name.
+func NewEventHandlerBulkhead(b *Bulkhead) stream.EventHandlerInterceptor {
+	return func(next stream.EventHandler) stream.EventHandler {
+		return eventHandler{
+			next: next,
+			wrap: func(ctx context.Context, e *event.Event, call func(context.Context, *event.Event) error) error {
+				release, err := b.Acquire(ctx, eventName(e))
+				if err != nil {
+					return err
+				}
+				defer release()
+				return call(ctx, e)
+			},
+		}
+	}
+}
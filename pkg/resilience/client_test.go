@@ -0,0 +1,291 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/clock"
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+var errUnavailable = Transient(errors.New("unavailable"))
+
+func TestIsTransient(t *testing.T) {
+	assert.False(t, IsTransient(nil))
+	assert.False(t, IsTransient(errors.New("invalid")))
+	assert.False(t, IsTransient(context.Canceled))
+	assert.True(t, IsTransient(context.DeadlineExceeded))
+	assert.True(t, IsTransient(&net.DNSError{IsTimeout: true}))
+	err := fmt.Errorf("client: %w", errUnavailable)
+	assert.True(t, IsTransient(err))
+	assert.Equal(t, "client: unavailable", err.Error())
+	assert.Nil(t, Transient(nil))
+}
+
+func TestCommandSinkerRetry(t *testing.T) {
+	var calls int
+	sinker := stream.WithCommandSinkerInterceptor(
+		commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			calls++
+			if calls < 3 {
+				return nil, errUnavailable
+			}
+			return cmd.ReplyOk(0), nil
+		}),
+		NewCommandSinkerRetry(WithRetryBackoff(time.Millisecond, time.Millisecond)),
+	)
+	reply, err := sinker.CommandSink(context.Background(), newCommand("create"))
+	assert.NoError(t, err)
+	assert.NotNil(t, reply)
+	assert.Equal(t, 3, calls)
+}
+
+func TestCommandSinkerRetry_NotTransient(t *testing.T) {
+	var calls int
+	errInvalid := errors.New("invalid")
+	sinker := stream.WithCommandSinkerInterceptor(
+		commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			calls++
+			return nil, errInvalid
+		}),
+		NewCommandSinkerRetry(WithRetryAttempts(5)),
+	)
+	_, err := sinker.CommandSink(context.Background(), newCommand("create"))
+	assert.Equal(t, errInvalid, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestCommandSinkerRetry_Exhausted(t *testing.T) {
+	var calls int
+	sinker := stream.WithCommandSinkerInterceptor(
+		commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			calls++
+			return nil, errUnavailable
+		}),
+		NewCommandSinkerRetry(WithRetryAttempts(4), WithRetryBackoff(0, 0)),
+	)
+	_, err := sinker.CommandSink(context.Background(), newCommand("create"))
+	assert.Equal(t, errUnavailable, err)
+	assert.Equal(t, 4, calls)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	b := NewCircuitBreaker("orders",
+		WithBreakerFailures(2),
+		WithBreakerCooldown(time.Second),
+		WithBreakerClock(fake),
+	)
+	var fail bool
+	var calls int
+	sinker := stream.WithCommandSinkerInterceptor(
+		commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			calls++
+			if fail {
+				return nil, errUnavailable
+			}
+			return cmd.ReplyOk(0), nil
+		}),
+		NewCommandSinkerCircuitBreaker(b),
+	)
+	ctx := context.Background()
+	fail = true
+	for i := 0; i < 2; i++ {
+		_, err := sinker.CommandSink(ctx, newCommand("create"))
+		assert.Equal(t, errUnavailable, err)
+	}
+	assert.Equal(t, BreakerOpen, b.State())
+	_, err := sinker.CommandSink(ctx, newCommand("create"))
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.EqualError(t, err, "resilience: circuit is open: orders")
+	assert.Equal(t, 2, calls)
+
+	fake.Advance(time.Second)
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	_, err = sinker.CommandSink(ctx, newCommand("create"))
+	assert.Equal(t, errUnavailable, err, "the probe is let through")
+	assert.Equal(t, BreakerOpen, b.State(), "the failed probe opens the circuit")
+
+	fake.Advance(time.Second)
+	fail = false
+	_, err = sinker.CommandSink(ctx, newCommand("create"))
+	assert.NoError(t, err)
+	assert.Equal(t, BreakerClosed, b.State())
+}
+
+func TestCircuitBreaker_SingleProbe(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	b := NewCircuitBreaker("orders", WithBreakerFailures(1), WithBreakerClock(fake))
+	done, err := b.Allow()
+	assert.NoError(t, err)
+	done(errUnavailable)
+	fake.Advance(DefaultBreakerCooldown)
+	probe, err := b.Allow()
+	assert.NoError(t, err)
+	_, err = b.Allow()
+	assert.True(t, errors.Is(err, ErrCircuitOpen), "only one probe is let through")
+	probe(nil)
+	_, err = b.Allow()
+	assert.NoError(t, err)
+}
+
+func TestCircuitBreaker_PanicProbe(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	b := NewCircuitBreaker("orders", WithBreakerFailures(1), WithBreakerClock(fake))
+	done, err := b.Allow()
+	assert.NoError(t, err)
+	done(errUnavailable)
+	fake.Advance(DefaultBreakerCooldown)
+	sinker := stream.WithCommandSinkerInterceptor(
+		commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			panic("boom")
+		}),
+		NewCommandSinkerRecovery(),
+		NewCommandSinkerCircuitBreaker(b),
+	)
+	_, err = sinker.CommandSink(context.Background(), newCommand("create"))
+	var perr *PanicError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, BreakerOpen, b.State(), "the panicked probe opens the circuit")
+	fake.Advance(DefaultBreakerCooldown)
+	_, err = b.Allow()
+	assert.NoError(t, err, "the next probe is let through")
+}
+
+func TestCommandSinkerHedging(t *testing.T) {
+	var calls int32
+	sinker := stream.WithCommandSinkerInterceptor(
+		commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return cmd.ReplyOk(0), nil
+		}),
+		NewCommandSinkerHedging(5*time.Millisecond, 2),
+	)
+	reply, err := sinker.CommandSink(context.Background(), newCommand("create"))
+	assert.NoError(t, err)
+	assert.NotNil(t, reply)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestCommandSinkerHedging_AllFailed(t *testing.T) {
+	var calls int32
+	sinker := stream.WithCommandSinkerInterceptor(
+		commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			atomic.AddInt32(&calls, 1)
+			return nil, errUnavailable
+		}),
+		NewCommandSinkerHedging(time.Millisecond, 3),
+	)
+	_, err := sinker.CommandSink(context.Background(), newCommand("create"))
+	assert.Equal(t, errUnavailable, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestCommandSinkerIdempotency(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls int
+		fail  = true
+	)
+	release := make(chan struct{})
+	sinker := stream.WithCommandSinkerInterceptor(
+		commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			mu.Lock()
+			calls++
+			failed := fail
+			fail = false
+			mu.Unlock()
+			if failed {
+				return nil, errUnavailable
+			}
+			<-release
+			return cmd.ReplyOk(0), nil
+		}),
+		NewCommandSinkerIdempotency(),
+	)
+	ctx := context.Background()
+	cmd := newCommand("create")
+	_, err := sinker.CommandSink(ctx, cmd)
+	assert.Equal(t, errUnavailable, err, "the errors are not kept")
+
+	replies := make(chan *command.Reply, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			reply, err := sinker.CommandSink(ctx, cmd)
+			assert.NoError(t, err)
+			replies <- reply
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	first := <-replies
+	assert.Equal(t, first, <-replies)
+	assert.Equal(t, first, <-replies)
+	reply, err := sinker.CommandSink(ctx, cmd)
+	assert.NoError(t, err)
+	assert.Equal(t, first, reply)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, calls)
+}
+
+func TestCommandSinkerIdempotency_Expired(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	var calls int
+	sinker := stream.WithCommandSinkerInterceptor(
+		commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			calls++
+			return cmd.ReplyOk(0), nil
+		}),
+		NewCommandSinkerIdempotency(WithIdempotencyTTL(time.Minute), WithIdempotencyClock(fake)),
+	)
+	cmd := newCommand("create")
+	for i := 0; i < 2; i++ {
+		_, err := sinker.CommandSink(context.Background(), cmd)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, calls)
+	fake.Advance(time.Minute)
+	_, err := sinker.CommandSink(context.Background(), cmd)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestCommandSinkerIdempotency_Panic(t *testing.T) {
+	var calls int
+	sinker := stream.WithCommandSinkerInterceptor(
+		commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			calls++
+			if calls == 1 {
+				panic("boom")
+			}
+			return cmd.ReplyOk(0), nil
+		}),
+		NewCommandSinkerRecovery(),
+		NewCommandSinkerIdempotency(),
+	)
+	cmd := newCommand("create")
+	_, err := sinker.CommandSink(context.Background(), cmd)
+	var perr *PanicError
+	assert.True(t, errors.As(err, &perr))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	reply, err := sinker.CommandSink(ctx, cmd)
+	assert.NoError(t, err, "the retry does not wait for the panicked call")
+	assert.NotNil(t, reply)
+	assert.Equal(t, 2, calls)
+}
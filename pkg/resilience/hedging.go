@@ -0,0 +1,61 @@
+package resilience
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+// NewCommandSinkerHedging sends the same command again if there is no answer
+// after the delay, up to the attempts in flight. The first reply or
+// the first not transient error wins and the other attempts are canceled.
+// The server must deduplicate the commands by ID, see NewCommandSinkerIdempotency.
+func NewCommandSinkerHedging(delay time.Duration, attempts int) stream.CommandSinkerInterceptor {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return func(next stream.CommandSinker) stream.CommandSinker {
+		return commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			type result struct {
+				reply *command.Reply
+				err   error
+			}
+			results := make(chan result, attempts)
+			send := func() {
+				go func() {
+					reply, err := next.CommandSink(ctx, cmd)
+					results <- result{reply: reply, err: err}
+				}()
+			}
+			send()
+			sent, inflight := 1, 1
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			var last result
+			for {
+				select {
+				case res := <-results:
+					inflight--
+					if res.err == nil || !IsTransient(res.err) {
+						return res.reply, res.err
+					}
+					last = res
+					if inflight == 0 && (sent == attempts || ctx.Err() != nil) {
+						return last.reply, last.err
+					}
+				case <-timer.C:
+					if sent < attempts {
+						send()
+						sent++
+						inflight++
+						timer.Reset(delay)
+					}
+				}
+			}
+		})
+	}
+}
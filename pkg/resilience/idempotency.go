@@ -0,0 +1,133 @@
+package resilience
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/go-gulfstream/gulfstream/pkg/clock"
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+const DefaultIdempotencyTTL = 5 * time.Minute
+
+type idempotency struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	clock   clock.Clock
+	calls   map[uuid.UUID]*idempotentCall
+	sweptAt time.Time
+}
+
+type idempotentCall struct {
+	done      chan struct{}
+	reply     *command.Reply
+	err       error
+	expiresAt time.Time
+}
+
+type IdempotencyOption func(*idempotency)
+
+// WithIdempotencyTTL sets how long the replies are kept.
+func WithIdempotencyTTL(d time.Duration) IdempotencyOption {
+	return func(i *idempotency) {
+		i.ttl = d
+	}
+}
+
+func WithIdempotencyClock(c clock.Clock) IdempotencyOption {
+	return func(i *idempotency) {
+		i.clock = c
+	}
+}
+
+// NewCommandSinkerIdempotency executes the command with the same ID once
+// and answers the repeated ones with the kept reply. The duplicates sent
+// while the command is executed wait for its reply. The errors are not kept,
+// the command failed with the error is executed again.
+// The replies are kept in memory, so the interceptor serves the single server.
+func NewCommandSinkerIdempotency(opts ...IdempotencyOption) stream.CommandSinkerInterceptor {
+	i := &idempotency{
+		ttl:   DefaultIdempotencyTTL,
+		calls: make(map[uuid.UUID]*idempotentCall),
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	i.clock = clock.OrSystem(i.clock)
+	return func(next stream.CommandSinker) stream.CommandSinker {
+		return commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			if cmd == nil {
+				return next.CommandSink(ctx, cmd)
+			}
+			call, found := i.begin(cmd.ID())
+			if found {
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-call.done:
+				}
+				if call.err == nil {
+					return call.reply, nil
+				}
+				return next.CommandSink(ctx, cmd)
+			}
+			defer onPanic(func(err *PanicError) {
+				call.err = err
+				i.end(cmd.ID(), call)
+			})
+			call.reply, call.err = next.CommandSink(ctx, cmd)
+			i.end(cmd.ID(), call)
+			return call.reply, call.err
+		})
+	}
+}
+
+func (i *idempotency) begin(id uuid.UUID) (*idempotentCall, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	now := i.clock.Now()
+	i.sweep(now)
+	if call, found := i.calls[id]; found {
+		select {
+		case <-call.done:
+			if call.err == nil && now.Before(call.expiresAt) {
+				return call, true
+			}
+		default:
+			return call, true
+		}
+	}
+	call := &idempotentCall{done: make(chan struct{})}
+	i.calls[id] = call
+	return call, false
+}
+
+func (i *idempotency) end(id uuid.UUID, call *idempotentCall) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	call.expiresAt = i.clock.Now().Add(i.ttl)
+	if call.err != nil && i.calls[id] == call {
+		delete(i.calls, id)
+	}
+	close(call.done)
+}
+
+func (i *idempotency) sweep(now time.Time) {
+	if now.Sub(i.sweptAt) < i.ttl {
+		return
+	}
+	i.sweptAt = now
+	for id, call := range i.calls {
+		select {
+		case <-call.done:
+			if !now.Before(call.expiresAt) {
+				delete(i.calls, id)
+			}
+		default:
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+// PanicError is returned instead of the recovered panic.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("resilience: panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is the error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// PanicHandler is called with each recovered panic, e.g. to log the stack.
+type PanicHandler func(ctx context.Context, err *PanicError)
+
+type RecoveryOption func(*recovery)
+
+func WithRecoveryHandler(h PanicHandler) RecoveryOption {
+	return func(r *recovery) {
+		r.handlers = append(r.handlers, h)
+	}
+}
+
+type recovery struct {
+	handlers []PanicHandler
+}
+
+func newRecovery(opts []RecoveryOption) *recovery {
+	r := &recovery{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *recovery) recover(ctx context.Context, err *error) {
+	val := recover()
+	if val == nil {
+		return
+	}
+	perr := &PanicError{Value: val, Stack: debug.Stack()}
+	for _, h := range r.handlers {
+		h(ctx, perr)
+	}
+	*err = perr
+}
+
+// onPanic calls fn with the recovered panic and panics again,
+// so the interceptor releases its state and the outer Recovery handles the panic.
+func onPanic(fn func(err *PanicError)) {
+	val := recover()
+	if val == nil {
+		return
+	}
+	fn(&PanicError{Value: val, Stack: debug.Stack()})
+	panic(val)
+}
+
+// NewCommandSinkerRecovery converts the panics of the sinker into *PanicError.
+func NewCommandSinkerRecovery(opts ...RecoveryOption) stream.CommandSinkerInterceptor {
+	r := newRecovery(opts)
+	return func(next stream.CommandSinker) stream.CommandSinker {
+		return commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (reply *command.Reply, err error) {
+			defer r.recover(ctx, &err)
+			return next.CommandSink(ctx, cmd)
+		})
+	}
+}
+
+// NewEventSinkerRecovery converts the panics of the sinker into *PanicError.
+func NewEventSinkerRecovery(opts ...RecoveryOption) stream.EventSinkerInterceptor {
+	r := newRecovery(opts)
+	return func(next stream.EventSinker) stream.EventSinker {
+		return eventSinkerFunc(func(ctx context.Context, e *event.Event) (err error) {
+			defer r.recover(ctx, &err)
+			return next.EventSink(ctx, e)
+		})
+	}
+}
+
+// NewEventHandlerRecovery converts the panics of Handle and Rollback
+// of the handler into *PanicError, so the event bus rolls back the event
+// instead of losing the partition goroutine.
+func NewEventHandlerRecovery(opts ...RecoveryOption) stream.EventHandlerInterceptor {
+	r := newRecovery(opts)
+	return func(next stream.EventHandler) stream.EventHandler {
+		return eventHandler{
+			next: next,
+			wrap: func(ctx context.Context, e *event.Event, call func(context.Context, *event.Event) error) (err error) {
+				defer r.recover(ctx, &err)
+				return call(ctx, e)
+			},
+		}
+	}
+}
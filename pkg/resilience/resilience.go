@@ -0,0 +1,79 @@
+// Package resilience provides the interceptors of the command sinkers,
+// the event sinkers and the event handlers recovering the panics,
+// applying the timeouts and limiting the concurrency.
+//
+//	sinker := stream.WithCommandSinkerInterceptor(mutator,
+//		resilience.NewCommandSinkerRecovery(),
+//		resilience.NewCommandSinkerTimeout(5*time.Second),
+//		resilience.NewCommandSinkerBulkhead(resilience.NewBulkhead(16)),
+//		resilience.NewCommandSinkerIdempotency(),
+//	)
+//
+// The client policies wrap the commandbus clients or any other sinker:
+//
+//	client := stream.WithCommandSinkerInterceptor(commandbushttp.NewClient(addr),
+//		resilience.NewCommandSinkerRetry(),
+//		resilience.NewCommandSinkerCircuitBreaker(resilience.NewCircuitBreaker(addr)),
+//		resilience.NewCommandSinkerHedging(100*time.Millisecond, 2),
+//	)
+package resilience
+
+import (
+	"context"
+
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+type commandSinkerFunc func(ctx context.Context, cmd *command.Command) (*command.Reply, error)
+
+func (fn commandSinkerFunc) CommandSink(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+	return fn(ctx, cmd)
+}
+
+type eventSinkerFunc func(ctx context.Context, e *event.Event) error
+
+func (fn eventSinkerFunc) EventSink(ctx context.Context, e *event.Event) error {
+	return fn(ctx, e)
+}
+
+// eventHandler wraps the calls of Handle and Rollback of the handler
+// keeping its Match and EventNames.
+type eventHandler struct {
+	next stream.EventHandler
+	wrap func(ctx context.Context, e *event.Event, call func(context.Context, *event.Event) error) error
+}
+
+func (h eventHandler) Match(eventName string) bool {
+	return h.next.Match(eventName)
+}
+
+func (h eventHandler) Handle(ctx context.Context, e *event.Event) error {
+	return h.wrap(ctx, e, h.next.Handle)
+}
+
+func (h eventHandler) Rollback(ctx context.Context, e *event.Event) error {
+	return h.wrap(ctx, e, h.next.Rollback)
+}
+
+func (h eventHandler) EventNames() []string {
+	if namer, ok := h.next.(stream.EventNamer); ok {
+		return namer.EventNames()
+	}
+	return nil
+}
+
+func eventName(e *event.Event) string {
+	if e == nil {
+		return ""
+	}
+	return e.Name()
+}
+
+func commandName(cmd *command.Command) string {
+	if cmd == nil {
+		return ""
+	}
+	return cmd.Name()
+}
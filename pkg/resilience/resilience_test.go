@@ -0,0 +1,179 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+type handler struct {
+	handle   func(ctx context.Context, e *event.Event) error
+	rollback func(ctx context.Context, e *event.Event) error
+}
+
+func (h handler) Match(eventName string) bool { return eventName == "created" }
+
+func (h handler) Handle(ctx context.Context, e *event.Event) error { return h.handle(ctx, e) }
+
+func (h handler) Rollback(ctx context.Context, e *event.Event) error { return h.rollback(ctx, e) }
+
+func (h handler) EventNames() []string { return []string{"created"} }
+
+func newCommand(name string) *command.Command {
+	return command.New(name, "users", uuid.New(), nil)
+}
+
+func TestCommandSinkerRecovery(t *testing.T) {
+	var recovered *PanicError
+	errBoom := errors.New("boom")
+	sinker := stream.WithCommandSinkerInterceptor(
+		commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			panic(errBoom)
+		}),
+		NewCommandSinkerRecovery(WithRecoveryHandler(func(_ context.Context, err *PanicError) {
+			recovered = err
+		})),
+	)
+	reply, err := sinker.CommandSink(context.Background(), newCommand("create"))
+	assert.Nil(t, reply)
+	var perr *PanicError
+	if !assert.True(t, errors.As(err, &perr)) {
+		return
+	}
+	assert.True(t, errors.Is(err, errBoom))
+	assert.Equal(t, "resilience: panic: boom", err.Error())
+	assert.Contains(t, string(perr.Stack), "resilience_test.go")
+	assert.Equal(t, perr, recovered)
+}
+
+func TestEventSinkerRecovery(t *testing.T) {
+	sinker := stream.WithEventSinkerInterceptor(
+		eventSinkerFunc(func(ctx context.Context, e *event.Event) error {
+			panic("boom")
+		}),
+		NewEventSinkerRecovery(),
+	)
+	err := sinker.EventSink(context.Background(), event.New("created", "users", uuid.New(), 1, nil))
+	var perr *PanicError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, "boom", perr.Value)
+}
+
+func TestEventHandlerRecovery(t *testing.T) {
+	h := stream.WithEventHandlerInterceptor(handler{
+		handle:   func(ctx context.Context, e *event.Event) error { panic("handle") },
+		rollback: func(ctx context.Context, e *event.Event) error { panic("rollback") },
+	}, NewEventHandlerRecovery())
+	e := event.New("created", "users", uuid.New(), 1, nil)
+	assert.True(t, h.Match("created"))
+	assert.False(t, h.Match("renamed"))
+	assert.Equal(t, []string{"created"}, h.(stream.EventNamer).EventNames())
+	assert.EqualError(t, h.Handle(context.Background(), e), "resilience: panic: handle")
+	assert.EqualError(t, h.Rollback(context.Background(), e), "resilience: panic: rollback")
+}
+
+func TestCommandSinkerTimeout(t *testing.T) {
+	sinker := stream.WithCommandSinkerInterceptor(
+		commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}),
+		NewCommandSinkerTimeout(10*time.Millisecond),
+	)
+	_, err := sinker.CommandSink(context.Background(), newCommand("create"))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestEventHandlerTimeout(t *testing.T) {
+	h := stream.WithEventHandlerInterceptor(handler{
+		handle: func(ctx context.Context, e *event.Event) error {
+			_, ok := ctx.Deadline()
+			assert.True(t, ok)
+			return nil
+		},
+	}, NewEventHandlerTimeout(time.Second))
+	assert.NoError(t, h.Handle(context.Background(), event.New("created", "users", uuid.New(), 1, nil)))
+}
+
+func TestBulkhead_FailFast(t *testing.T) {
+	b := NewBulkhead(1, WithBulkheadMaxWait(0), WithBulkheadLimit("update", 2))
+	ctx := context.Background()
+	release, err := b.Acquire(ctx, "create")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = b.Acquire(ctx, "create")
+	assert.True(t, errors.Is(err, ErrBulkheadFull))
+
+	for i := 0; i < 2; i++ {
+		_, err = b.Acquire(ctx, "update")
+		assert.NoError(t, err, "the names have own slots")
+	}
+	_, err = b.Acquire(ctx, "update")
+	assert.True(t, errors.Is(err, ErrBulkheadFull))
+
+	release()
+	_, err = b.Acquire(ctx, "create")
+	assert.NoError(t, err)
+}
+
+func TestBulkhead_Wait(t *testing.T) {
+	b := NewBulkhead(1)
+	release, err := b.Acquire(context.Background(), "create")
+	if !assert.NoError(t, err) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = b.Acquire(ctx, "create")
+	assert.True(t, errors.Is(err, ErrBulkheadFull))
+
+	time.AfterFunc(10*time.Millisecond, release)
+	_, err = b.Acquire(context.Background(), "create")
+	assert.NoError(t, err)
+}
+
+func TestCommandSinkerBulkhead(t *testing.T) {
+	const limit = 3
+	var (
+		mu      sync.Mutex
+		running int
+		max     int
+	)
+	sinker := stream.WithCommandSinkerInterceptor(
+		commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			mu.Lock()
+			running++
+			if running > max {
+				max = running
+			}
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return cmd.ReplyOk(0), nil
+		}),
+		NewCommandSinkerBulkhead(NewBulkhead(limit)),
+	)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := sinker.CommandSink(context.Background(), newCommand("create"))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, max, limit)
+}
@@ -0,0 +1,105 @@
+package resilience
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+const (
+	DefaultRetryAttempts  = 3
+	DefaultRetryBaseDelay = 50 * time.Millisecond
+	DefaultRetryMaxDelay  = time.Second
+)
+
+type retry struct {
+	attempts  int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+	retryOn   func(error) bool
+}
+
+type RetryOption func(*retry)
+
+// WithRetryAttempts sets the number of the attempts including the first one.
+func WithRetryAttempts(n int) RetryOption {
+	return func(r *retry) {
+		if n >= 1 {
+			r.attempts = n
+		}
+	}
+}
+
+// WithRetryBackoff sets the exponential backoff between the attempts.
+// The delays are jittered.
+func WithRetryBackoff(base, max time.Duration) RetryOption {
+	return func(r *retry) {
+		r.baseDelay = base
+		r.maxDelay = max
+	}
+}
+
+// WithRetryOn sets the errors to retry, IsTransient by default.
+func WithRetryOn(fn func(error) bool) RetryOption {
+	return func(r *retry) {
+		r.retryOn = fn
+	}
+}
+
+// NewCommandSinkerRetry repeats the command on the transient errors.
+// The command keeps its ID between the attempts, so the server deduplicating
+// the commands by ID, see NewCommandSinkerIdempotency, executes it once.
+// The replies with the errors of the controllers are not retried.
+func NewCommandSinkerRetry(opts ...RetryOption) stream.CommandSinkerInterceptor {
+	r := &retry{
+		attempts:  DefaultRetryAttempts,
+		baseDelay: DefaultRetryBaseDelay,
+		maxDelay:  DefaultRetryMaxDelay,
+		retryOn:   IsTransient,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return func(next stream.CommandSinker) stream.CommandSinker {
+		return commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (reply *command.Reply, err error) {
+			for attempt := 0; attempt < r.attempts; attempt++ {
+				if attempt > 0 && !sleep(ctx, r.backoff(attempt)) {
+					return nil, err
+				}
+				reply, err = next.CommandSink(ctx, cmd)
+				if err == nil || !r.retryOn(err) || ctx.Err() != nil {
+					return reply, err
+				}
+			}
+			return reply, err
+		})
+	}
+}
+
+func (r *retry) backoff(attempt int) time.Duration {
+	if r.baseDelay <= 0 {
+		return 0
+	}
+	delay := r.baseDelay << uint(attempt-1)
+	if delay <= 0 || (r.maxDelay > 0 && delay > r.maxDelay) {
+		delay = r.maxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
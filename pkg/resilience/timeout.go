@@ -0,0 +1,46 @@
+package resilience
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+// The timeout interceptors set the deadline of the context of each call.
+// The callee must respect the context, the call is not abandoned.
+
+func NewCommandSinkerTimeout(d time.Duration) stream.CommandSinkerInterceptor {
+	return func(next stream.CommandSinker) stream.CommandSinker {
+		return commandSinkerFunc(func(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next.CommandSink(ctx, cmd)
+		})
+	}
+}
+
+func NewEventSinkerTimeout(d time.Duration) stream.EventSinkerInterceptor {
+	return func(next stream.EventSinker) stream.EventSinker {
+		return eventSinkerFunc(func(ctx context.Context, e *event.Event) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next.EventSink(ctx, e)
+		})
+	}
+}
+
+func NewEventHandlerTimeout(d time.Duration) stream.EventHandlerInterceptor {
+	return func(next stream.EventHandler) stream.EventHandler {
+		return eventHandler{
+			next: next,
+			wrap: func(ctx context.Context, e *event.Event, call func(context.Context, *event.Event) error) error {
+				ctx, cancel := context.WithTimeout(ctx, d)
+				defer cancel()
+				return call(ctx, e)
+			},
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTransient marks the errors after which the call may succeed if repeated,
+// e.g. the timeouts and the unavailable servers. The commandbus clients wrap
+// such errors of the transport with Transient.
+var ErrTransient = errors.New("resilience: transient error")
+
+type transientError struct {
+	err error
+}
+
+func (e transientError) Error() string {
+	return e.err.Error()
+}
+
+func (e transientError) Unwrap() error {
+	return e.err
+}
+
+func (e transientError) Is(target error) bool {
+	return target == ErrTransient
+}
+
+// Transient marks the error as transient keeping its message and chain.
+func Transient(err error) error {
+	if err == nil || errors.Is(err, ErrTransient) {
+		return err
+	}
+	return transientError{err: err}
+}
+
+// IsTransient reports whether the error is marked with Transient,
+// is the deadline exceeded or the timeout of the network.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrTransient) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
@@ -0,0 +1,45 @@
+package storagepostgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+var _ stream.CheckpointStore = (*Checkpoints)(nil)
+
+// Checkpoints is the checkpoint store of the projection. The checkpoint
+// is saved after the handlers of the event return, so the read model
+// may be ahead of it after a failure and the handlers must be idempotent.
+type Checkpoints struct {
+	pool       *pgxpool.Pool
+	projection string
+}
+
+func NewCheckpoints(pool *pgxpool.Pool, projection string) *Checkpoints {
+	return &Checkpoints{
+		pool:       pool,
+		projection: projection,
+	}
+}
+
+func (c *Checkpoints) SaveCheckpoint(ctx context.Context, streamName string, streamID uuid.UUID, version int) error {
+	return exec(ctx, c.pool, upsertCheckpointSQL, c.projection, streamName, streamID.String(), version)
+}
+
+func (c *Checkpoints) LoadCheckpoint(ctx context.Context, streamName string, streamID uuid.UUID) (int, error) {
+	var version int
+	row := queryRow(ctx, c.pool, selectCheckpointSQL, c.projection, streamName, streamID.String())
+	if err := row.Scan(&version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return version, nil
+}
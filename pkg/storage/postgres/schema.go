@@ -33,7 +33,7 @@ CREATE TABLE IF NOT EXISTS gulfstream.events
     stream_name      VARCHAR(128) NOT NULL,
     event_name      VARCHAR(256) NOT NULL,
     version    integer,
-    created_at BIGINT,
+    created_at TIMESTAMPTZ,
     raw_data    bytea,
     PRIMARY KEY (stream_name, stream_id, version)
 );
@@ -47,8 +47,24 @@ CREATE TABLE IF NOT EXISTS gulfstream.outbox
     raw_data    bytea,
     PRIMARY KEY (stream_name, stream_id, version)
 );
+
+CREATE TABLE IF NOT EXISTS gulfstream.checkpoints
+(
+    projection     VARCHAR(128) NOT NULL,
+    stream_id      uuid         NOT NULL,
+    stream_name    VARCHAR(128) NOT NULL,
+    version integer,
+    PRIMARY KEY (projection, stream_name, stream_id)
+);
 `
 
+// MigrateCreatedAtSQL converts the created_at column of the events journal
+// created by the older versions from Unix seconds to TIMESTAMPTZ.
+// CreateSchema runs it when the column is not converted yet.
+const MigrateCreatedAtSQL = `
+ALTER TABLE gulfstream.events
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING to_timestamp(created_at)`
+
 func CreateSchema(ctx context.Context, pool *pgxpool.Pool) error {
 	queries := strings.Split(Schema, ";")
 	for _, query := range queries {
@@ -65,7 +81,19 @@ func CreateSchema(ctx context.Context, pool *pgxpool.Pool) error {
 			return err
 		}
 	}
-	return nil
+	return migrateCreatedAt(ctx, pool)
+}
+
+func migrateCreatedAt(ctx context.Context, pool *pgxpool.Pool) error {
+	var dataType string
+	if err := pool.QueryRow(ctx, selectCreatedAtTypeSQL).Scan(&dataType); err != nil {
+		return err
+	}
+	if dataType != "bigint" {
+		return nil
+	}
+	_, err := pool.Exec(ctx, MigrateCreatedAtSQL)
+	return err
 }
 
 func DropSchema(ctx context.Context, pool *pgxpool.Pool) error {
@@ -95,6 +123,11 @@ WHERE table_schema = 'gulfstream'`
 
 	dropTableSQL = `DROP TABLE IF EXISTS`
 
+	selectCreatedAtTypeSQL = `
+SELECT data_type
+FROM information_schema.columns
+WHERE table_schema = 'gulfstream' AND table_name = 'events' AND column_name = 'created_at'`
+
 	insertVersionSQL = `
 INSERT INTO gulfstream.versions (stream_name, stream_id, version) 
 VALUES ($1, $2, $3)`
@@ -125,5 +158,19 @@ WHERE stream_name=$1 AND stream_id=$2`
 INSERT INTO gulfstream.outbox (stream_id, stream_name, event_name, version, raw_data) 
 VALUES ($1, $2, $3, $4, $5)`
 
+	deleteStateSQL = `DELETE FROM gulfstream.states WHERE stream_name=$1 AND stream_id=$2`
+
+	deleteVersionSQL = `DELETE FROM gulfstream.versions WHERE stream_name=$1 AND stream_id=$2`
+
+	upsertCheckpointSQL = `
+INSERT INTO gulfstream.checkpoints (projection, stream_name, stream_id, version) 
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (projection, stream_name, stream_id) DO UPDATE SET version=GREATEST(gulfstream.checkpoints.version, EXCLUDED.version)`
+
+	selectCheckpointSQL = `
+SELECT version
+FROM gulfstream.checkpoints
+WHERE projection=$1 AND stream_name=$2 AND stream_id=$3`
+
 	deleteOutboxSQL = `DELETE FROM gulfstream.outbox WHERE stream_name=$1 AND stream_id=$2 AND version <= $3`
 )
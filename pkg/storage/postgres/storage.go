@@ -74,13 +74,17 @@ func (s Storage) Persist(ctx context.Context, ss *stream.Stream) (err error) {
 		ctx = context.WithValue(ctx, pkey, tx)
 		defer func() {
 			if err != nil {
-				err = tx.Rollback(ctx)
+				_ = tx.Rollback(ctx)
 			} else {
 				err = tx.Commit(ctx)
 			}
 		}()
 	}
 
+	if err := s.updateStreamVersion(ctx, ss); err != nil {
+		return err
+	}
+
 	for _, e := range ss.Changes() {
 		eventData, err := s.encodeEvent(e)
 		if err != nil {
@@ -94,10 +98,6 @@ func (s Storage) Persist(ctx context.Context, ss *stream.Stream) (err error) {
 		}
 	}
 
-	if err := s.updateStreamVersion(ctx, ss); err != nil {
-		return err
-	}
-
 	if ss.PreviousVersion() == 0 {
 		err = exec(ctx, s.pool, insertStateSQL, ss.Name(), ss.ID().String(), ss.Version(), rawData)
 	} else {
@@ -116,9 +116,10 @@ func (s Storage) updateStreamVersion(ctx context.Context, ss *stream.Stream) (er
 		err = exec(ctx, s.pool, updateVersionSQL, ss.Version(), ss.Name(), ss.ID(), ss.PreviousVersion())
 	}
 	if err != nil {
-		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" {
-			return fmt.Errorf("storage/postgres: mismatch stream version: stream=%s, id=%s, ver=%d, expectedVer=%d",
-				ss.Name(), ss.ID(), ss.Version(), ss.PreviousVersion())
+		var pgErr *pgconn.PgError
+		if errors.Is(err, errNoAffectedRows) || errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return fmt.Errorf("storage/postgres: %w: stream=%s, id=%s, ver=%d, expectedVer=%d",
+				stream.ErrVersionConflict, ss.Name(), ss.ID(), ss.Version(), ss.PreviousVersion())
 		}
 		return err
 	}
@@ -134,7 +135,7 @@ func (s Storage) appendEventToJournal(ctx context.Context, e *event.Event, data
 		e.StreamName(),
 		e.Name(),
 		e.Version(),
-		e.Unix(),
+		e.CreatedAt(),
 		data,
 	)
 }
@@ -157,8 +158,8 @@ func (s Storage) Load(ctx context.Context, streamID uuid.UUID) (*stream.Stream,
 	var rawData []byte
 	if err := row.Scan(&rawData); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("storage/postgres: storage.Load(%s,%s) not found",
-				s.streamName, streamID)
+			return nil, fmt.Errorf("storage/postgres: storage.Load(%s,%s): %w",
+				s.streamName, streamID, stream.ErrStreamNotFound)
 		}
 		return nil, err
 	}
@@ -169,12 +170,13 @@ func (s Storage) Load(ctx context.Context, streamID uuid.UUID) (*stream.Stream,
 	return blankStream, nil
 }
 
+// Drop deletes the state and the version of the stream.
+// The journal of the events is kept.
 func (s Storage) Drop(ctx context.Context, streamID uuid.UUID) error {
-	if s.journalEnabled {
-		return nil
+	if err := execDelete(ctx, s.pool, deleteStateSQL, s.streamName, streamID.String()); err != nil {
+		return err
 	}
-	// TODO:
-	return nil
+	return execDelete(ctx, s.pool, deleteVersionSQL, s.streamName, streamID.String())
 }
 
 func (s Storage) decodeEvent(data []byte) (*event.Event, error) {
@@ -223,11 +225,22 @@ func exec(ctx context.Context, pool *pgxpool.Pool, sql string, arguments ...inte
 		return err
 	}
 	if res.RowsAffected() == 0 && !res.Delete() {
-		return fmt.Errorf("storage/postgres: no affected rows")
+		return errNoAffectedRows
 	}
 	return
 }
 
+var errNoAffectedRows = errors.New("storage/postgres: no affected rows")
+
+func execDelete(ctx context.Context, pool *pgxpool.Pool, sql string, arguments ...interface{}) error {
+	if tx, ok := ctx.Value(pkey).(pgx.Tx); ok {
+		_, err := tx.Exec(ctx, sql, arguments...)
+		return err
+	}
+	_, err := pool.Exec(ctx, sql, arguments...)
+	return err
+}
+
 func queryRow(ctx context.Context, pool *pgxpool.Pool, sql string, arguments ...interface{}) (row pgx.Row) {
 	tx, txnExists := ctx.Value(pkey).(pgx.Tx)
 	if txnExists {
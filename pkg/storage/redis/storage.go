@@ -69,11 +69,11 @@ func (s Storage) Persist(ctx context.Context, ss *stream.Stream) (err error) {
 			}
 		}
 		if currentVersion > ss.Version() {
-			return fmt.Errorf("storage/redis: stream %s already exists", ss)
+			return fmt.Errorf("storage/redis: %w: stream %s already exists", stream.ErrVersionConflict, ss)
 		}
 		if currentVersion != ss.PreviousVersion() {
-			return fmt.Errorf("storage/redis: mismatch stream version. got v%d, expected v%d",
-				currentVersion, ss.PreviousVersion())
+			return fmt.Errorf("storage/redis: %w: got v%d, expected v%d",
+				stream.ErrVersionConflict, currentVersion, ss.PreviousVersion())
 		}
 		pipe := tx.TxPipeline()
 		pipe.Set(ctx, toKey(ss.Name(), ss.ID().String(), versionPrefix), ss.Version(), -1)
@@ -81,20 +81,23 @@ func (s Storage) Persist(ctx context.Context, ss *stream.Stream) (err error) {
 		_, err := pipe.Exec(ctx)
 		return err
 	}, versionKey)
-	if err == redis.Nil {
+	switch err {
+	case redis.Nil:
 		err = nil
+	case redis.TxFailedErr:
+		err = fmt.Errorf("storage/redis: %w: stream %s changed concurrently", stream.ErrVersionConflict, ss)
 	}
 	return
 }
 
 func (s Storage) Load(ctx context.Context, streamID uuid.UUID) (*stream.Stream, error) {
 	key := toKey(s.streamName, streamID.String(), streamPrefix)
-	res := s.rds.Get(ctx, key)
-	if res.Err() != nil && res.Err() != redis.Nil {
-		return nil, res.Err()
+	data, err := s.rds.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("storage/redis: %s{StreamID:%s}: %w",
+			s.streamName, streamID, stream.ErrStreamNotFound)
 	}
-	data, err := res.Bytes()
-	if err != nil && err != redis.Nil {
+	if err != nil {
 		return nil, err
 	}
 	blankStream := s.blankStream()
@@ -105,7 +108,10 @@ func (s Storage) Load(ctx context.Context, streamID uuid.UUID) (*stream.Stream,
 }
 
 func (s Storage) Drop(ctx context.Context, streamID uuid.UUID) error {
-	return nil
+	return s.rds.Del(ctx,
+		toKey(s.streamName, streamID.String(), versionPrefix),
+		toKey(s.streamName, streamID.String(), streamPrefix),
+	).Err()
 }
 
 func toKey(name string, id string, prefix string) string {
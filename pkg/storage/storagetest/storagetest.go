@@ -0,0 +1,286 @@
+// Package storagetest is the conformance suite of the stream.Storage
+// implementations. The in-tree storages run it and the third-party
+// storages can reuse it:
+//
+//	storagetest.Run(t, func(t *testing.T, streamName string, newState func() stream.State) stream.Storage {
+//		return storageredis.New(rdb, streamName, func() *stream.Stream {
+//			return stream.Blank(streamName, newState())
+//		})
+//	})
+package storagetest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+// Factory returns the empty storage of the stream. The storage restores
+// the loaded streams with the states made by newState.
+type Factory func(t *testing.T, streamName string, newState func() stream.State) stream.Storage
+
+// Run runs the conformance suite against the storages made by the factory.
+// Each case gets a new storage with the unique stream name.
+func Run(t *testing.T, factory Factory) {
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, s stream.Storage)
+	}{
+		{"PersistLoad", testPersistLoad},
+		{"SequentialVersions", testSequentialVersions},
+		{"NotFound", testNotFound},
+		{"VersionConflict", testVersionConflict},
+		{"CreateConflict", testCreateConflict},
+		{"Drop", testDrop},
+		{"DropNotFound", testDropNotFound},
+		{"NameMismatch", testNameMismatch},
+		{"Isolation", testIsolation},
+		{"Concurrency", testConcurrency},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			streamName := "storagetest" + uuid.New().String()
+			storage := factory(t, streamName, newCounter)
+			if !assert.Equal(t, streamName, storage.StreamName()) {
+				return
+			}
+			c.fn(t, storage)
+		})
+	}
+}
+
+// counter is the state of the suite streams.
+type counter struct {
+	Events []string `json:"events"`
+}
+
+func newCounter() stream.State {
+	return &counter{}
+}
+
+func (c *counter) Mutate(e *event.Event) {
+	c.Events = append(c.Events, e.Name())
+}
+
+func (c *counter) MarshalBinary() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+func (c *counter) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, c)
+}
+
+func newStream(s stream.Storage) *stream.Stream {
+	return stream.New(s.StreamName(), uuid.New(), newCounter())
+}
+
+func eventsOf(s *stream.Stream) []string {
+	return s.State().(*counter).Events
+}
+
+func persistNew(t *testing.T, s stream.Storage, events ...string) (*stream.Stream, bool) {
+	ss := newStream(s)
+	for _, name := range events {
+		ss.Mutate(name, nil)
+	}
+	return ss, assert.NoError(t, s.Persist(context.Background(), ss))
+}
+
+func testPersistLoad(t *testing.T, s stream.Storage) {
+	ss, ok := persistNew(t, s, "created", "renamed")
+	if !ok {
+		return
+	}
+	loaded, err := s.Load(context.Background(), ss.ID())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, ss.ID(), loaded.ID())
+	assert.Equal(t, ss.Name(), loaded.Name())
+	assert.Equal(t, 2, loaded.Version())
+	assert.Equal(t, 2, loaded.PreviousVersion())
+	assert.Empty(t, loaded.Changes())
+	assert.Equal(t, []string{"created", "renamed"}, eventsOf(loaded))
+}
+
+func testSequentialVersions(t *testing.T, s stream.Storage) {
+	ctx := context.Background()
+	ss, ok := persistNew(t, s, "created")
+	if !ok {
+		return
+	}
+	for version := 2; version <= 5; version++ {
+		loaded, err := s.Load(ctx, ss.ID())
+		if !assert.NoError(t, err) {
+			return
+		}
+		if !assert.Equal(t, version-1, loaded.Version()) {
+			return
+		}
+		loaded.Mutate("changed", nil)
+		if !assert.NoError(t, s.Persist(ctx, loaded)) {
+			return
+		}
+	}
+	loaded, err := s.Load(ctx, ss.ID())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 5, loaded.Version())
+	assert.Len(t, eventsOf(loaded), 5)
+}
+
+func testNotFound(t *testing.T, s stream.Storage) {
+	loaded, err := s.Load(context.Background(), uuid.New())
+	assert.Nil(t, loaded)
+	assert.True(t, errors.Is(err, stream.ErrStreamNotFound),
+		"Load must wrap stream.ErrStreamNotFound, got %v", err)
+}
+
+func testVersionConflict(t *testing.T, s stream.Storage) {
+	ctx := context.Background()
+	ss, ok := persistNew(t, s, "created")
+	if !ok {
+		return
+	}
+	first, err := s.Load(ctx, ss.ID())
+	if !assert.NoError(t, err) {
+		return
+	}
+	second, err := s.Load(ctx, ss.ID())
+	if !assert.NoError(t, err) {
+		return
+	}
+	first.Mutate("first", nil)
+	second.Mutate("second", nil)
+	if !assert.NoError(t, s.Persist(ctx, first)) {
+		return
+	}
+	err = s.Persist(ctx, second)
+	assert.True(t, errors.Is(err, stream.ErrVersionConflict),
+		"Persist of the stale stream must wrap stream.ErrVersionConflict, got %v", err)
+
+	loaded, err := s.Load(ctx, ss.ID())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, loaded.Version())
+	assert.Equal(t, []string{"created", "first"}, eventsOf(loaded))
+}
+
+func testCreateConflict(t *testing.T, s stream.Storage) {
+	ss, ok := persistNew(t, s, "created")
+	if !ok {
+		return
+	}
+	other := stream.New(s.StreamName(), ss.ID(), newCounter())
+	other.Mutate("created", nil)
+	err := s.Persist(context.Background(), other)
+	assert.True(t, errors.Is(err, stream.ErrVersionConflict),
+		"Persist of the existing stream must wrap stream.ErrVersionConflict, got %v", err)
+}
+
+func testDrop(t *testing.T, s stream.Storage) {
+	ctx := context.Background()
+	ss, ok := persistNew(t, s, "created")
+	if !ok {
+		return
+	}
+	if !assert.NoError(t, s.Drop(ctx, ss.ID())) {
+		return
+	}
+	_, err := s.Load(ctx, ss.ID())
+	assert.True(t, errors.Is(err, stream.ErrStreamNotFound),
+		"Load of the dropped stream must wrap stream.ErrStreamNotFound, got %v", err)
+}
+
+func testDropNotFound(t *testing.T, s stream.Storage) {
+	assert.NoError(t, s.Drop(context.Background(), uuid.New()))
+}
+
+func testNameMismatch(t *testing.T, s stream.Storage) {
+	ctx := context.Background()
+	ss := stream.New("other"+s.StreamName(), uuid.New(), newCounter())
+	ss.Mutate("created", nil)
+	assert.Error(t, s.Persist(ctx, ss))
+	_, err := s.Load(ctx, ss.ID())
+	assert.True(t, errors.Is(err, stream.ErrStreamNotFound),
+		"the stream of the other name must not be persisted, got %v", err)
+}
+
+func testIsolation(t *testing.T, s stream.Storage) {
+	ctx := context.Background()
+	first, ok := persistNew(t, s, "first")
+	if !ok {
+		return
+	}
+	second, ok := persistNew(t, s, "second", "second")
+	if !ok {
+		return
+	}
+	if !assert.NoError(t, s.Drop(ctx, first.ID())) {
+		return
+	}
+	loaded, err := s.Load(ctx, second.ID())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, loaded.Version())
+	assert.Equal(t, []string{"second", "second"}, eventsOf(loaded))
+}
+
+func testConcurrency(t *testing.T, s stream.Storage) {
+	const writers = 8
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	ss, ok := persistNew(t, s, "created")
+	if !ok {
+		return
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				loaded, err := s.Load(ctx, ss.ID())
+				if err != nil {
+					errs <- err
+					return
+				}
+				loaded.Mutate("changed", nil)
+				err = s.Persist(ctx, loaded)
+				if err == nil {
+					return
+				}
+				if !errors.Is(err, stream.ErrVersionConflict) || ctx.Err() != nil {
+					errs <- err
+					return
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	loaded, err := s.Load(ctx, ss.ID())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1+writers, loaded.Version())
+	assert.Len(t, eventsOf(loaded), 1+writers)
+}
@@ -0,0 +1,22 @@
+package storagetest
+
+import (
+	"testing"
+
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+	"github.com/go-gulfstream/gulfstream/pkg/streamtest"
+)
+
+func TestRun_StateStorage(t *testing.T) {
+	Run(t, func(t *testing.T, streamName string, newState func() stream.State) stream.Storage {
+		return stream.NewStorage(streamName, func() *stream.Stream {
+			return stream.Blank(streamName, newState())
+		})
+	})
+}
+
+func TestRun_EventStorage(t *testing.T) {
+	Run(t, func(t *testing.T, streamName string, newState func() stream.State) stream.Storage {
+		return streamtest.NewStorage(streamName, newState)
+	})
+}
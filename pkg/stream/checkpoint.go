@@ -0,0 +1,213 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+)
+
+const DefaultCheckpointPollInterval = 50 * time.Millisecond
+
+// ErrVersionNotReached is wrapped by the waiters when the context is done
+// before the projection applies the version.
+var ErrVersionNotReached = errors.New("stream: version not reached")
+
+// CheckpointStore keeps the last version of each stream applied by the projection.
+// The checkpoint never moves back, the lower versions of the redelivered
+// events are ignored by SaveCheckpoint.
+type CheckpointStore interface {
+	SaveCheckpoint(ctx context.Context, streamName string, streamID uuid.UUID, version int) error
+	LoadCheckpoint(ctx context.Context, streamName string, streamID uuid.UUID) (int, error)
+}
+
+// VersionWaiter blocks until the projection applies at least the version
+// of the stream or the context is done.
+type VersionWaiter interface {
+	WaitForVersion(ctx context.Context, streamName string, streamID uuid.UUID, version int) error
+}
+
+type checkpointKey struct {
+	streamName string
+	streamID   uuid.UUID
+}
+
+// Checkpoints is the in-memory checkpoint store. The waiters are woken up
+// on each saved checkpoint, so it serves the projections of the same process.
+type Checkpoints struct {
+	mu       sync.Mutex
+	versions map[checkpointKey]int
+	waiters  map[checkpointKey]chan struct{}
+}
+
+var (
+	_ CheckpointStore = (*Checkpoints)(nil)
+	_ VersionWaiter   = (*Checkpoints)(nil)
+)
+
+func NewCheckpoints() *Checkpoints {
+	return &Checkpoints{
+		versions: make(map[checkpointKey]int),
+		waiters:  make(map[checkpointKey]chan struct{}),
+	}
+}
+
+func (c *Checkpoints) SaveCheckpoint(_ context.Context, streamName string, streamID uuid.UUID, version int) error {
+	key := checkpointKey{streamName: streamName, streamID: streamID}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version <= c.versions[key] {
+		return nil
+	}
+	c.versions[key] = version
+	if ch, found := c.waiters[key]; found {
+		close(ch)
+		delete(c.waiters, key)
+	}
+	return nil
+}
+
+func (c *Checkpoints) LoadCheckpoint(_ context.Context, streamName string, streamID uuid.UUID) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.versions[checkpointKey{streamName: streamName, streamID: streamID}], nil
+}
+
+func (c *Checkpoints) WaitForVersion(ctx context.Context, streamName string, streamID uuid.UUID, version int) error {
+	key := checkpointKey{streamName: streamName, streamID: streamID}
+	for {
+		c.mu.Lock()
+		if c.versions[key] >= version {
+			c.mu.Unlock()
+			return nil
+		}
+		ch, found := c.waiters[key]
+		if !found {
+			ch = make(chan struct{})
+			c.waiters[key] = ch
+		}
+		c.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return versionNotReached(ctx, streamName, streamID, version)
+		case <-ch:
+		}
+	}
+}
+
+// NewCheckpointWaiter returns the waiter polling the store, e.g. the database
+// shared with the projection running in the other process.
+func NewCheckpointWaiter(store CheckpointStore, interval time.Duration) VersionWaiter {
+	if interval <= 0 {
+		interval = DefaultCheckpointPollInterval
+	}
+	return checkpointWaiter{store: store, interval: interval}
+}
+
+type checkpointWaiter struct {
+	store    CheckpointStore
+	interval time.Duration
+}
+
+func (w checkpointWaiter) WaitForVersion(ctx context.Context, streamName string, streamID uuid.UUID, version int) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		current, err := w.store.LoadCheckpoint(ctx, streamName, streamID)
+		if err != nil {
+			return err
+		}
+		if current >= version {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return versionNotReached(ctx, streamName, streamID, version)
+		case <-ticker.C:
+		}
+	}
+}
+
+// NewRemoteVersionWaiter returns the waiter sending command.WaitForVersionCommand
+// through the sinker, e.g. the commandbus client. The deadline of the context
+// is passed to the server.
+func NewRemoteVersionWaiter(sinker CommandSinker) VersionWaiter {
+	return remoteVersionWaiter{sinker: sinker}
+}
+
+type remoteVersionWaiter struct {
+	sinker CommandSinker
+}
+
+func (w remoteVersionWaiter) WaitForVersion(ctx context.Context, streamName string, streamID uuid.UUID, version int) error {
+	var timeout time.Duration
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	cmd := command.NewWaitForVersion(streamName, streamID, version, timeout)
+	reply, err := w.sinker.CommandSink(ctx, cmd)
+	if err != nil {
+		if ctx.Err() != nil {
+			return versionNotReached(ctx, streamName, streamID, version)
+		}
+		return err
+	}
+	var nerr *command.VersionNotReachedError
+	if errors.As(reply.Err(), &nerr) {
+		return errVersionNotReached(streamName, streamID, version, nerr.Reason)
+	}
+	return reply.Err()
+}
+
+// NewVersionWaiterInterceptor answers command.WaitForVersionCommand with
+// the waiter, the other commands are passed to the next sinker.
+func NewVersionWaiterInterceptor(w VersionWaiter) CommandSinkerInterceptor {
+	return func(next CommandSinker) CommandSinker {
+		return versionWaiterSinker{next: next, waiter: w}
+	}
+}
+
+type versionWaiterSinker struct {
+	next   CommandSinker
+	waiter VersionWaiter
+}
+
+func (s versionWaiterSinker) CommandSink(ctx context.Context, cmd *command.Command) (*command.Reply, error) {
+	if cmd == nil || cmd.Name() != command.WaitForVersionCommand {
+		return s.next.CommandSink(ctx, cmd)
+	}
+	wait, ok := cmd.Payload().(*command.VersionWait)
+	if !ok {
+		return nil, fmt.Errorf("stream: %s invalid payload %T", cmd.Name(), cmd.Payload())
+	}
+	if wait.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, wait.Timeout)
+		defer cancel()
+	}
+	if err := s.waiter.WaitForVersion(ctx, cmd.StreamName(), cmd.StreamID(), wait.Version); err != nil {
+		if errors.Is(err, ErrVersionNotReached) {
+			reason := err.Error()
+			if ctx.Err() != nil {
+				reason = ctx.Err().Error()
+			}
+			err = &command.VersionNotReachedError{Version: wait.Version, Reason: reason}
+		}
+		return cmd.ReplyErr(err), nil
+	}
+	return cmd.ReplyOk(wait.Version), nil
+}
+
+func versionNotReached(ctx context.Context, streamName string, streamID uuid.UUID, version int) error {
+	return errVersionNotReached(streamName, streamID, version, ctx.Err().Error())
+}
+
+func errVersionNotReached(streamName string, streamID uuid.UUID, version int, reason string) error {
+	return fmt.Errorf("%w: %s{StreamID:%s} v%d: %s",
+		ErrVersionNotReached, streamName, streamID, version, reason)
+}
@@ -0,0 +1,71 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+)
+
+func TestProjection_Checkpoints(t *testing.T) {
+	ctx := context.Background()
+	checkpoints := NewCheckpoints()
+	var handled []string
+	proj := NewProjection(WithProjectionCheckpoints(checkpoints))
+	proj.AddEventController("created", func(ctx context.Context, e *event.Event) error {
+		handled = append(handled, e.Name())
+		return nil
+	})
+	streamID := uuid.New()
+	assert.True(t, proj.Match("renamed"), "the projection with checkpoints matches all events")
+
+	done := make(chan error, 1)
+	go func() {
+		done <- checkpoints.WaitForVersion(ctx, "users", streamID, 2)
+	}()
+	assert.NoError(t, proj.Handle(ctx, event.New("created", "users", streamID, 1, nil)))
+	select {
+	case <-done:
+		t.Fatal("the version is not applied yet")
+	case <-time.After(10 * time.Millisecond):
+	}
+	assert.NoError(t, proj.Handle(ctx, event.New("renamed", "users", streamID, 2, nil)))
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the waiter is not woken up")
+	}
+	assert.Equal(t, []string{"created"}, handled)
+
+	assert.NoError(t, proj.Handle(ctx, event.New("created", "users", streamID, 1, nil)), "redelivered")
+	assert.NoError(t, proj.Rollback(ctx, event.New("renamed", "users", streamID, 2, nil)))
+	version, err := checkpoints.LoadCheckpoint(ctx, "users", streamID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version, "the checkpoint never moves back")
+}
+
+func TestCheckpoints_WaitForVersionTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := NewCheckpoints().WaitForVersion(ctx, "users", uuid.New(), 1)
+	assert.True(t, errors.Is(err, ErrVersionNotReached))
+}
+
+func TestCheckpointWaiter(t *testing.T) {
+	checkpoints := NewCheckpoints()
+	waiter := NewCheckpointWaiter(checkpoints, time.Millisecond)
+	streamID := uuid.New()
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		_ = checkpoints.SaveCheckpoint(context.Background(), "users", streamID, 7)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, waiter.WaitForVersion(ctx, "users", streamID, 5))
+}
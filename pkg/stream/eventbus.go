@@ -11,7 +11,11 @@ type Publisher interface {
 }
 
 type Subscriber interface {
-	Subscribe(streamName string, h ...EventHandler)
+	Subscribe(streamName string, h ...EventHandler) Subscription
+}
+
+type Subscription interface {
+	Unsubscribe()
 }
 
 type EventHandler interface {
@@ -0,0 +1,103 @@
+package stream
+
+import "sort"
+
+// EventNamer is implemented by the event handlers that can list
+// the names of the events they consume.
+type EventNamer interface {
+	EventNames() []string
+}
+
+// MutatorInfo describes the controllers registered in the mutator.
+type MutatorInfo struct {
+	StreamName        string
+	Commands          []CommandInfo
+	Events            []EventInfo
+	BlacklistOfEvents []string
+}
+
+type CommandInfo struct {
+	Name         string
+	CreateStream bool
+	DropStream   bool
+	Validators   int
+	Emits        []string
+}
+
+type EventInfo struct {
+	Name         string
+	CreateStream bool
+	DropStream   bool
+	Emits        []string
+}
+
+// WithCommandControllerEmits declares the events the controller may add
+// to the stream. It is used only by the introspection.
+func WithCommandControllerEmits(eventNames ...string) CommandControllerOption {
+	return func(ctrl *commandController) {
+		ctrl.emits = append(ctrl.emits, eventNames...)
+	}
+}
+
+// WithEventControllerEmits declares the events the controller may add
+// to the stream. It is used only by the introspection.
+func WithEventControllerEmits(eventNames ...string) EventControllerOption {
+	return func(ctrl *eventController) {
+		ctrl.emits = append(ctrl.emits, eventNames...)
+	}
+}
+
+// Describe returns the registration metadata of the mutator
+// sorted by the command and event names.
+func (m *Mutator) Describe() MutatorInfo {
+	info := MutatorInfo{
+		StreamName:        m.storage.StreamName(),
+		Commands:          make([]CommandInfo, 0, len(m.commandControllers)),
+		Events:            make([]EventInfo, 0, len(m.eventControllers)),
+		BlacklistOfEvents: append([]string(nil), m.blacklistOfEvents...),
+	}
+	for name, cc := range m.commandControllers {
+		info.Commands = append(info.Commands, CommandInfo{
+			Name:         name,
+			CreateStream: cc.createStream,
+			DropStream:   cc.dropStream,
+			Validators:   len(cc.validators),
+			Emits:        append([]string(nil), cc.emits...),
+		})
+	}
+	for name, ec := range m.eventControllers {
+		info.Events = append(info.Events, EventInfo{
+			Name:         name,
+			CreateStream: ec.createStream,
+			DropStream:   ec.dropStream,
+			Emits:        append([]string(nil), ec.emits...),
+		})
+	}
+	sort.Slice(info.Commands, func(i, j int) bool {
+		return info.Commands[i].Name < info.Commands[j].Name
+	})
+	sort.Slice(info.Events, func(i, j int) bool {
+		return info.Events[i].Name < info.Events[j].Name
+	})
+	return info
+}
+
+// EventNames returns the sorted names of the events handled by the mutator.
+func (m *Mutator) EventNames() []string {
+	names := make([]string, 0, len(m.eventControllers))
+	for name := range m.eventControllers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EventNames returns the sorted names of the events handled by the projection.
+func (p *Projection) EventNames() []string {
+	names := make([]string, 0, len(p.handlers))
+	for name := range p.handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,41 @@
+package stream_test
+
+import (
+	"context"
+	"testing"
+
+	mockstream "github.com/go-gulfstream/gulfstream/mocks/stream"
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutator_Describe(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage := mockstream.NewMockStorage(ctrl)
+	storage.EXPECT().StreamName().Return("users").AnyTimes()
+	mutator := stream.NewMutator(storage, mockstream.NewMockPublisher(ctrl))
+	mutator.AddCommandController("create", mockstream.NewMockCommandController(ctrl),
+		stream.WithCommandControllerCreateIfNotExists(),
+		stream.WithCommandControllerEmits("created"),
+		stream.WithCommandControllerValidator(func(context.Context, *command.Command) error { return nil }))
+	mutator.AddCommandController("delete", mockstream.NewMockCommandController(ctrl),
+		stream.WithCommandControllerDropStream())
+	mutator.AddEventController("groupJoined", mockstream.NewMockEventController(ctrl),
+		stream.WithEventControllerEmits("joined"))
+	mutator.SetBlacklistOfEvents("created")
+
+	assert.Equal(t, stream.MutatorInfo{
+		StreamName: "users",
+		Commands: []stream.CommandInfo{
+			{Name: "create", CreateStream: true, Validators: 1, Emits: []string{"created"}},
+			{Name: "delete", DropStream: true},
+		},
+		Events: []stream.EventInfo{
+			{Name: "groupJoined", Emits: []string{"joined"}},
+		},
+		BlacklistOfEvents: []string{"created"},
+	}, mutator.Describe())
+	assert.Equal(t, []string{"groupJoined"}, mutator.EventNames())
+}
@@ -6,7 +6,9 @@ import (
 
 	"github.com/go-gulfstream/gulfstream/pkg/event"
 
+	"github.com/go-gulfstream/gulfstream/pkg/clock"
 	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/idgen"
 
 	"github.com/google/uuid"
 )
@@ -72,8 +74,16 @@ type Mutator struct {
 	eventControllers   map[string]*eventController
 	strict             bool
 	blacklistOfEvents  []string
+	guards             []StreamGuard
+	clock              clock.Clock
+	ids                idgen.Generator
 }
 
+// StreamGuard checks the command against the loaded stream before
+// the controller is called, e.g. the ownership of the stream.
+// The error is returned in the reply.
+type StreamGuard func(ctx context.Context, s *Stream, cmd *command.Command) error
+
 func NewMutator(
 	storage Storage,
 	publisher Publisher,
@@ -99,6 +109,27 @@ func WithMutatorStrictMode() MutatorOption {
 	}
 }
 
+// WithMutatorClock sets the clock of the streams changed by the mutator.
+func WithMutatorClock(c clock.Clock) MutatorOption {
+	return func(m *Mutator) {
+		m.clock = c
+	}
+}
+
+// WithMutatorIDGenerator sets the generator of the IDs of the events
+// added by the mutator.
+func WithMutatorIDGenerator(g idgen.Generator) MutatorOption {
+	return func(m *Mutator) {
+		m.ids = g
+	}
+}
+
+func WithMutatorStreamGuard(guards ...StreamGuard) MutatorOption {
+	return func(m *Mutator) {
+		m.guards = append(m.guards, guards...)
+	}
+}
+
 func (m *Mutator) AddCommandController(
 	commandName string,
 	ctrl CommandController,
@@ -142,6 +173,9 @@ func (m *Mutator) CommandSink(ctx context.Context, cmd *command.Command) (*comma
 		return nil, fmt.Errorf("stream: mutator.CommandSink controller for command %s.%s not found",
 			cmd.StreamName(), cmd.Name())
 	}
+	if err := command.Validate(ctx, cmd, cc.validators...); err != nil {
+		return cmd.ReplyErr(err), nil
+	}
 	var stream *Stream
 	var err error
 	if cc.createStream {
@@ -155,7 +189,13 @@ func (m *Mutator) CommandSink(ctx context.Context, cmd *command.Command) (*comma
 		if err != nil {
 			return nil, err
 		}
+		for _, guard := range m.guards {
+			if err := guard(ctx, stream, cmd); err != nil {
+				return cmd.ReplyErr(err), nil
+			}
+		}
 	}
+	m.prepare(stream)
 	r, err := cc.controller.CommandSink(ctx, stream, cmd)
 	if err != nil {
 		return nil, err
@@ -258,10 +298,27 @@ func (m *Mutator) EventSink(ctx context.Context, e *event.Event) (err error) {
 }
 
 func (m *Mutator) loadStreamFromEvent(ctx context.Context, streamID uuid.UUID, createStream bool) (*Stream, error) {
+	var s *Stream
+	var err error
 	if createStream {
-		return m.storage.NewStream(), nil
+		s = m.storage.NewStream()
 	} else {
-		return m.storage.Load(ctx, streamID)
+		s, err = m.storage.Load(ctx, streamID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.prepare(s)
+	return s, nil
+}
+
+// prepare sets the clock and the ID generator of the mutator to the stream.
+func (m *Mutator) prepare(s *Stream) {
+	if m.clock != nil {
+		s.clock = m.clock
+	}
+	if m.ids != nil {
+		s.ids = m.ids
 	}
 }
 
@@ -295,6 +352,15 @@ func WithCommandControllerCreateIfNotExists() CommandControllerOption {
 	}
 }
 
+// WithCommandControllerValidator adds the validators of the command.
+// The validators and the payload implementing command.Validator run before
+// the stream is loaded, the validation error is returned in the reply.
+func WithCommandControllerValidator(validators ...command.ValidatorFunc) CommandControllerOption {
+	return func(ctrl *commandController) {
+		ctrl.validators = append(ctrl.validators, validators...)
+	}
+}
+
 func WithCommandControllerDropStream() CommandControllerOption {
 	return func(ctrl *commandController) {
 		ctrl.dropStream = true
@@ -341,6 +407,8 @@ type commandController struct {
 	commandType  string
 	createStream bool
 	dropStream   bool
+	validators   []command.ValidatorFunc
+	emits        []string
 }
 
 type eventController struct {
@@ -348,4 +416,5 @@ type eventController struct {
 	eventType    string
 	createStream bool
 	dropStream   bool
+	emits        []string
 }
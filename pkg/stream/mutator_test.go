@@ -2,12 +2,19 @@ package stream_test
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
+
+	"github.com/go-gulfstream/gulfstream/pkg/clock"
+	"github.com/go-gulfstream/gulfstream/pkg/idgen"
+	"github.com/go-gulfstream/gulfstream/pkg/streamtest"
 
 	"github.com/go-gulfstream/gulfstream/pkg/stream"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/go-gulfstream/gulfstream/pkg/command"
 	"github.com/go-gulfstream/gulfstream/pkg/event"
 	"github.com/google/uuid"
 
@@ -123,6 +130,75 @@ func TestMutator_EventSinkPickOneStream(t *testing.T) {
 	assert.Equal(t, groupJoinedEvent.StreamID(), userStream.State().(*userState).Groups[0])
 }
 
+func TestMutator_CommandSinkValidation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage := mockstream.NewMockStorage(ctrl)
+	storage.EXPECT().StreamName().Return("users")
+	publisher := mockstream.NewMockPublisher(ctrl)
+	controller := mockstream.NewMockCommandController(ctrl)
+
+	mutator := stream.NewMutator(storage, publisher)
+	mutator.AddCommandController("rename", controller,
+		stream.WithCommandControllerValidator(func(ctx context.Context, cmd *command.Command) error {
+			return errors.New("name is empty")
+		}))
+	cmd := command.New("rename", "users", uuid.New(), nil)
+	reply, err := mutator.CommandSink(context.Background(), cmd)
+	assert.NoError(t, err)
+	assert.Equal(t, cmd.ID(), reply.Command())
+	assert.IsType(t, &command.ValidationError{}, reply.Err())
+}
+
+func TestMutator_CommandSinkStreamGuard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	streamID := uuid.New()
+	storage := mockstream.NewMockStorage(ctrl)
+	storage.EXPECT().StreamName().Return("users")
+	storage.EXPECT().Load(gomock.Any(), streamID).
+		Return(stream.New("users", streamID, &userState{}), nil)
+	publisher := mockstream.NewMockPublisher(ctrl)
+	controller := mockstream.NewMockCommandController(ctrl)
+
+	denied := errors.New("denied")
+	mutator := stream.NewMutator(storage, publisher,
+		stream.WithMutatorStreamGuard(func(ctx context.Context, s *stream.Stream, cmd *command.Command) error {
+			return denied
+		}))
+	mutator.AddCommandController("rename", controller)
+	cmd := command.New("rename", "users", streamID, nil)
+	reply, err := mutator.CommandSink(context.Background(), cmd)
+	assert.NoError(t, err)
+	assert.Equal(t, denied.Error(), reply.Err().Error())
+}
+
+func TestMutator_Clock(t *testing.T) {
+	now := time.Date(2021, 10, 18, 12, 0, 0, 0, time.UTC)
+	c := clock.NewFake(now)
+	c.SetStep(time.Second)
+	streamID := uuid.New()
+	f := streamtest.New(t, "users", func() stream.State { return new(userState) },
+		func(m *stream.Mutator) {
+			m.AddCommandController("join",
+				stream.ControllerFunc(func(ctx context.Context, s *stream.Stream, c *command.Command) (*command.Reply, error) {
+					s.Mutate("userJoined", &userJoinedPayload{})
+					s.Mutate("userJoined", &userJoinedPayload{})
+					return nil, nil
+				}))
+		},
+		stream.WithMutatorClock(c),
+		stream.WithMutatorIDGenerator(idgen.NewSequence(7)))
+	f.Given(event.New("created", "users", streamID, 1, nil)).
+		When(command.New("join", "users", streamID, nil)).
+		ThenNoError()
+
+	events := f.Publisher().Events()
+	assert.Len(t, events, 2)
+	assert.Equal(t, "00000000-0000-0007-0000-000000000001", events[0].ID().String())
+	assert.Equal(t, "00000000-0000-0007-0000-000000000002", events[1].ID().String())
+	assert.Equal(t, now.Unix(), events[0].Unix())
+	assert.Equal(t, now.Add(2*time.Second).Unix(), events[1].Unix())
+}
+
 type groupJoinedPayload struct {
 	Name   string
 	UserID uuid.UUID
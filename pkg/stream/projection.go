@@ -8,17 +8,34 @@ import (
 )
 
 type Projection struct {
-	handlers   map[string]projectionHandler
-	strictMode bool
+	handlers    map[string]projectionHandler
+	strictMode  bool
+	checkpoints CheckpointStore
 }
 
 type EventHandlerFunc func(ctx context.Context, e *event.Event) error
 
-func NewProjection() *Projection {
-	return &Projection{
+type ProjectionOption func(*Projection)
+
+func NewProjection(opts ...ProjectionOption) *Projection {
+	p := &Projection{
 		handlers:   make(map[string]projectionHandler),
 		strictMode: true,
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithProjectionCheckpoints saves the version of each applied event to the store.
+// The projection matches all events of the stream to keep the versions
+// contiguous, the events without the controller are skipped, so the strict
+// mode does not apply. The rollback does not move the checkpoint back.
+func WithProjectionCheckpoints(store CheckpointStore) ProjectionOption {
+	return func(p *Projection) {
+		p.checkpoints = store
+	}
 }
 
 func (p *Projection) SkipUnhandledEvent() {
@@ -34,6 +51,9 @@ func (p *Projection) AddEventControllerWithRollback(eventName string, fn, rollba
 }
 
 func (p *Projection) Match(eventName string) bool {
+	if p.checkpoints != nil {
+		return true
+	}
 	_, found := p.handlers[eventName]
 	return found
 }
@@ -41,23 +61,36 @@ func (p *Projection) Match(eventName string) bool {
 func (p *Projection) Handle(ctx context.Context, e *event.Event) error {
 	proj, found := p.handlers[e.Name()]
 	if !found {
-		if p.strictMode {
+		if p.strictMode && p.checkpoints == nil {
 			return fmt.Errorf("stream: %s projection not found", e.Name())
 		}
-		return nil
+		return p.saveCheckpoint(ctx, e)
 	}
-	return proj.handler(ctx, e)
+	if err := proj.handler(ctx, e); err != nil {
+		return err
+	}
+	return p.saveCheckpoint(ctx, e)
 }
 
 func (p *Projection) Rollback(ctx context.Context, e *event.Event) error {
 	proj, found := p.handlers[e.Name()]
 	if !found {
-		if p.strictMode {
+		if p.strictMode && p.checkpoints == nil {
 			return fmt.Errorf("stream: %s rollback projection not found", e.Name())
 		}
 		return nil
 	}
-	return proj.rollback(ctx, e)
+	if proj.rollback != nil {
+		return proj.rollback(ctx, e)
+	}
+	return nil
+}
+
+func (p *Projection) saveCheckpoint(ctx context.Context, e *event.Event) error {
+	if p.checkpoints == nil {
+		return nil
+	}
+	return p.checkpoints.SaveCheckpoint(ctx, e.StreamName(), e.StreamID(), e.Version())
 }
 
 type projectionHandler struct {
@@ -3,6 +3,7 @@ package stream
 import (
 	"encoding"
 
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
 	"github.com/go-gulfstream/gulfstream/pkg/event"
 )
 
@@ -11,3 +12,35 @@ type State interface {
 	encoding.BinaryUnmarshaler
 	encoding.BinaryMarshaler
 }
+
+// Mutable is the plain state without the binary encoding.
+type Mutable interface {
+	Mutate(*event.Event)
+}
+
+// StateOf adapts the pointer to the plain state to State with the marshaler.
+func StateOf(m codec.Marshaler, state Mutable) State {
+	return &valueState{
+		Value: codec.Wrap(m, state),
+		state: state,
+	}
+}
+
+// JSONState adapts the pointer to the plain state to State with the JSON encoding.
+func JSONState(state Mutable) State {
+	return StateOf(codec.JSONMarshaler, state)
+}
+
+// ProtobufState adapts the protobuf message with the Mutate method to State.
+func ProtobufState(state Mutable) State {
+	return StateOf(codec.ProtobufMarshaler, state)
+}
+
+type valueState struct {
+	*codec.Value
+	state Mutable
+}
+
+func (s *valueState) Mutate(e *event.Event) {
+	s.state.Mutate(e)
+}
@@ -2,6 +2,7 @@ package stream
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -9,6 +10,14 @@ import (
 	"github.com/google/uuid"
 )
 
+var (
+	// ErrStreamNotFound is wrapped by the storages when the stream does not exist.
+	ErrStreamNotFound = errors.New("stream: not found")
+	// ErrVersionConflict is wrapped by the storages when the stream
+	// was changed after it was loaded.
+	ErrVersionConflict = errors.New("stream: version conflict")
+)
+
 type Storage interface {
 	StreamName() string
 	NewStream() *Stream
@@ -56,8 +65,8 @@ func (s *stateStorage) Persist(_ context.Context, ss *Stream) error {
 			return err
 		}
 		if prev.Version() != ss.PreviousVersion() {
-			return fmt.Errorf("storage: mismatch stream version. got v%d, expected v%d",
-				prev.Version(), ss.PreviousVersion())
+			return fmt.Errorf("storage: %w: got v%d, expected v%d",
+				ErrVersionConflict, prev.Version(), ss.PreviousVersion())
 		}
 	}
 	data, err := ss.MarshalBinary()
@@ -73,8 +82,8 @@ func (s *stateStorage) Load(_ context.Context, streamID uuid.UUID) (*Stream, err
 	defer s.mu.RUnlock()
 	rawData, found := s.data[streamID]
 	if !found {
-		return nil, fmt.Errorf("storage: %s{StreamID:%s} not found",
-			s.streamName, streamID)
+		return nil, fmt.Errorf("storage: %s{StreamID:%s}: %w",
+			s.streamName, streamID, ErrStreamNotFound)
 	}
 	blankStream := s.blankStream()
 	if err := blankStream.UnmarshalBinary(rawData); err != nil {
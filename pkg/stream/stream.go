@@ -4,7 +4,9 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/go-gulfstream/gulfstream/pkg/clock"
 	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/go-gulfstream/gulfstream/pkg/idgen"
 
 	"github.com/go-gulfstream/gulfstream/pkg/event"
 
@@ -17,25 +19,57 @@ type Stream struct {
 	id        uuid.UUID
 	name      string
 	version   int
-	updatedAt int64
+	updatedAt time.Time
 	state     State
 	changes   []*event.Event
+	filters   []StateFilter
+	clock     clock.Clock
+	ids       idgen.Generator
 }
 
-func New(name string, id uuid.UUID, initState State) *Stream {
+type StreamOption func(*Stream)
+
+func New(name string, id uuid.UUID, initState State, opts ...StreamOption) *Stream {
 	if len(name) == 0 {
 		panic("no stream name")
 	}
 	checkPtr(initState)
-	return &Stream{
+	s := &Stream{
 		id:    id,
 		state: initState,
 		name:  name,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func Blank(name string, initState State, opts ...StreamOption) *Stream {
+	return New(name, uuid.UUID{}, initState, opts...)
+}
+
+// WithStateFilter adds the filters of the raw state, e.g. to compress it.
+// The filters are applied in the order of adding on marshaling
+// and in the reverse order on unmarshaling.
+func WithStateFilter(filters ...StateFilter) StreamOption {
+	return func(s *Stream) {
+		s.filters = append(s.filters, filters...)
+	}
 }
 
-func Blank(name string, initState State) *Stream {
-	return New(name, uuid.UUID{}, initState)
+// WithClock sets the clock of the stream and its new events.
+func WithClock(c clock.Clock) StreamOption {
+	return func(s *Stream) {
+		s.clock = c
+	}
+}
+
+// WithIDGenerator sets the generator of the IDs of the new events.
+func WithIDGenerator(g idgen.Generator) StreamOption {
+	return func(s *Stream) {
+		s.ids = g
+	}
 }
 
 func (s *Stream) String() string {
@@ -71,6 +105,14 @@ func (s *Stream) PreviousVersion() int {
 }
 
 func (s *Stream) Unix() int64 {
+	if s.updatedAt.IsZero() {
+		return 0
+	}
+	return s.updatedAt.Unix()
+}
+
+// UpdatedAt returns the time of the last change of the stream.
+func (s *Stream) UpdatedAt() time.Time {
 	return s.updatedAt
 }
 
@@ -79,10 +121,12 @@ func (s *Stream) Mutate(eventName string, payload codec.Codec) {
 	if s.isPlaceholderVersion() {
 		version++
 	}
-	e := event.New(eventName, s.name, s.id, version, payload)
+	c := clock.OrSystem(s.clock)
+	e := event.New(eventName, s.name, s.id, version, payload,
+		event.WithClock(c), event.WithIDGenerator(s.ids))
 	s.state.Mutate(e)
 	s.changes = append(s.changes, e)
-	s.updatedAt = time.Now().Unix()
+	s.updatedAt = c.Now()
 }
 
 func (s *Stream) isPlaceholderVersion() bool {
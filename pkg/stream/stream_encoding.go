@@ -6,17 +6,50 @@ import (
 	"errors"
 	"unsafe"
 
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
 	"github.com/go-gulfstream/gulfstream/pkg/util"
+	"github.com/google/uuid"
 )
 
 const (
-	magicNumber   = uint16(791)
-	containerSize = int(unsafe.Sizeof(Stream{})) - 24
+	// FormatVersion is the version of the container written by the stream.
+	// Version 0 is the legacy container without the version and the extensions.
+	FormatVersion = uint8(1)
+
+	magicNumber       = uint16(791)
+	formatMagicNumber = uint16(1791)
+	containerSize     = int(unsafe.Sizeof(magicNumber) +
+		2*unsafe.Sizeof(uint32(0)) +
+		unsafe.Sizeof(uuid.UUID{}) +
+		2*unsafe.Sizeof(int64(0)))
+
+	extUpdatedAtNanos = uint16(1)
+	extStateFilters   = uint16(2)
+
+	nanosExtensionSize = 10
 )
 
-var ErrInvalidInputData = errors.New("stream: unmarshal error: invalid data input")
+var (
+	ErrInvalidInputData  = errors.New("stream: unmarshal error: invalid data input")
+	ErrUnsupportedFormat = errors.New("stream: unmarshal error: unsupported container format version")
+)
+
+// StateFilter transforms the raw state of the stream after it is marshaled
+// and before it is unmarshaled.
+// The filter implementing codec.Marker is recorded in the container
+// and decodes only the states it encoded. EncodeState returns
+// codec.ErrSkipFilter to leave the state as is.
+type StateFilter interface {
+	EncodeState(s *Stream, data []byte) ([]byte, error)
+	DecodeState(s *Stream, data []byte) ([]byte, error)
+}
+
+func (s *Stream) MarshalBinary() ([]byte, error) {
+	return s.AppendBinary(nil)
+}
 
-func (s *Stream) MarshalBinary() (data []byte, err error) {
+// AppendBinary appends the encoded stream to dst.
+func (s *Stream) AppendBinary(dst []byte) ([]byte, error) {
 	rawState, err := s.state.MarshalBinary()
 	if err != nil {
 		return nil, err
@@ -24,21 +57,21 @@ func (s *Stream) MarshalBinary() (data []byte, err error) {
 	if len(rawState) == 0 {
 		return nil, ErrInvalidInputData
 	}
-	w := newWriter(rawState)
-	w.container = s
-	if err := util.ErrOneOf(
-		w.writeMagicNumber,
-		w.writePayloadSize,
-		w.writeNameSize,
-		w.writeID,
-		w.writeName,
-		w.writeVersion,
-		w.writeUpdatedAt,
-		w.writePayload(rawState),
-	); err != nil {
-		return nil, err
+	var marks codec.FilterMarks
+	for _, filter := range s.filters {
+		filter := filter
+		rawState, marks, err = codec.EncodeFilter(filter, marks, rawState, func(data []byte) ([]byte, error) {
+			return filter.EncodeState(s, data)
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
-	return w.buf.Bytes(), nil
+	w := writer{container: s, payload: rawState}
+	if len(marks) > 0 {
+		w.extensions = codec.Extensions{extStateFilters: marks}
+	}
+	return w.append(dst), nil
 }
 
 func (s *Stream) UnmarshalBinary(data []byte) error {
@@ -49,12 +82,15 @@ func (s *Stream) UnmarshalBinary(data []byte) error {
 	reader.container = s
 	if err := util.ErrOneOf(
 		reader.checkMagicNumber,
+		reader.readFormatVersion,
 		reader.readPayloadSize,
 		reader.readNameSize,
 		reader.readID,
 		reader.readName,
 		reader.readVersion,
 		reader.readUpdatedAt,
+		reader.readExtensions,
+		reader.readUpdatedAtNanos,
 	); err != nil {
 		return err
 	}
@@ -65,63 +101,63 @@ func (s *Stream) UnmarshalBinary(data []byte) error {
 	if s.state == nil {
 		return nil
 	}
+	marks, _ := reader.extensions.Get(extStateFilters)
+	for i := len(s.filters) - 1; i >= 0; i-- {
+		filter := s.filters[i]
+		rawPayload, err = codec.DecodeFilter(filter, marks, rawPayload, func(data []byte) ([]byte, error) {
+			return filter.DecodeState(s, data)
+		})
+		if err != nil {
+			return err
+		}
+	}
 	return s.state.UnmarshalBinary(rawPayload)
 }
 
 type writer struct {
-	buf       *bytes.Buffer
-	prev      uintptr
-	container *Stream
-	payload   []byte
+	container  *Stream
+	payload    []byte
+	extensions codec.Extensions
 }
 
-func newWriter(payload []byte) *writer {
-	return &writer{
-		buf:     bytes.NewBuffer(nil),
-		payload: payload,
-	}
+func (w *writer) write() ([]byte, error) {
+	return w.append(nil), nil
 }
 
-func (w *writer) writeMagicNumber() error {
-	return binary.Write(w.buf, binary.LittleEndian, magicNumber)
-}
-
-func (w *writer) writePayloadSize() error {
-	return binary.Write(w.buf, binary.LittleEndian, uint32(len(w.payload)))
-}
-
-func (w *writer) writeNameSize() error {
-	return binary.Write(w.buf, binary.LittleEndian, uint32(len(w.container.name)))
-}
-
-func (w *writer) writeID() error {
-	return binary.Write(w.buf, binary.LittleEndian, w.container.id)
-}
-
-func (w *writer) writeName() error {
-	return binary.Write(w.buf, binary.LittleEndian, []byte(w.container.name))
-}
-
-func (w *writer) writeVersion() error {
-	return binary.Write(w.buf, binary.LittleEndian, int64(w.container.Version()))
-}
-
-func (w *writer) writeUpdatedAt() error {
-	return binary.Write(w.buf, binary.LittleEndian, w.container.updatedAt)
-}
-
-func (w *writer) writePayload(data []byte) func() error {
-	return func() error {
-		return binary.Write(w.buf, binary.LittleEndian, data)
+func (w *writer) append(dst []byte) []byte {
+	s := w.container
+	sec, nsec := codec.SplitTimestamp(s.updatedAt)
+	dst = codec.Grow(dst, containerSize+1+4+nanosExtensionSize+w.extensions.Size()+
+		len(s.name)+len(w.payload))
+	dst = codec.AppendUint16(dst, formatMagicNumber)
+	dst = append(dst, FormatVersion)
+	dst = codec.AppendUint32(dst, uint32(len(w.payload)))
+	dst = codec.AppendUint32(dst, uint32(len(s.name)))
+	dst = append(dst, s.id[:]...)
+	dst = append(dst, s.name...)
+	dst = codec.AppendUint64(dst, uint64(s.Version()))
+	dst = codec.AppendUint64(dst, uint64(sec))
+	dst, offset := codec.BeginExtensions(dst)
+	if len(w.extensions) > 0 {
+		w.extensions.SetNanos(extUpdatedAtNanos, nsec)
+		dst = w.extensions.AppendBinary(dst)
+	} else {
+		dst = codec.AppendNanos(dst, extUpdatedAtNanos, nsec)
 	}
+	dst = codec.EndExtensions(dst, offset)
+	return append(dst, w.payload...)
 }
 
 type reader struct {
 	reader      *bytes.Reader
 	data        []byte
 	prev        uintptr
+	versioned   bool
+	format      uint8
 	payloadSize uint32
 	nameSize    uint32
+	updatedAt   int64
+	extensions  codec.Extensions
 	container   *Stream
 }
 
@@ -133,8 +169,12 @@ func newReader(data []byte) *reader {
 }
 
 func (r *reader) next(offset uintptr) {
-	r.reader.Reset(r.data[r.prev : r.prev+offset])
-	r.prev += offset
+	end := r.prev + offset
+	if end > uintptr(len(r.data)) {
+		end = uintptr(len(r.data))
+	}
+	r.reader.Reset(r.data[r.prev:end])
+	r.prev = end
 }
 
 func (r *reader) checkMagicNumber() error {
@@ -143,12 +183,52 @@ func (r *reader) checkMagicNumber() error {
 	if err := binary.Read(r.reader, binary.LittleEndian, &val); err != nil {
 		return err
 	}
-	if val != magicNumber {
+	switch val {
+	case magicNumber:
+	case formatMagicNumber:
+		r.versioned = true
+	default:
 		return ErrInvalidInputData
 	}
 	return nil
 }
 
+func (r *reader) readFormatVersion() error {
+	if !r.versioned {
+		return nil
+	}
+	r.next(unsafe.Sizeof(r.format))
+	if err := binary.Read(r.reader, binary.LittleEndian, &r.format); err != nil {
+		return err
+	}
+	if r.format == 0 || r.format > FormatVersion {
+		return ErrUnsupportedFormat
+	}
+	return nil
+}
+
+// readExtensions reads the extension area of the container.
+// The extensions with unknown tags are skipped.
+func (r *reader) readExtensions() error {
+	if r.format < 1 {
+		return nil
+	}
+	var size uint32
+	r.next(unsafe.Sizeof(size))
+	if err := binary.Read(r.reader, binary.LittleEndian, &size); err != nil {
+		return err
+	}
+	if uintptr(size) > uintptr(len(r.data))-r.prev {
+		return ErrInvalidInputData
+	}
+	r.next(uintptr(size))
+	data := make([]byte, size)
+	if err := binary.Read(r.reader, binary.LittleEndian, &data); err != nil {
+		return err
+	}
+	return r.extensions.UnmarshalBinary(data)
+}
+
 func (r *reader) readPayloadSize() error {
 	r.next(unsafe.Sizeof(r.payloadSize))
 	return binary.Read(r.reader, binary.LittleEndian, &r.payloadSize)
@@ -175,8 +255,19 @@ func (r *reader) readVersion() error {
 }
 
 func (r *reader) readUpdatedAt() error {
-	r.next(unsafe.Sizeof(r.container.updatedAt))
-	return binary.Read(r.reader, binary.LittleEndian, &r.container.updatedAt)
+	r.next(unsafe.Sizeof(r.updatedAt))
+	return binary.Read(r.reader, binary.LittleEndian, &r.updatedAt)
+}
+
+// readUpdatedAtNanos restores the update time with the nanoseconds
+// from the extensions.
+func (r *reader) readUpdatedAtNanos() error {
+	nsec, err := r.extensions.Nanos(extUpdatedAtNanos)
+	if err != nil {
+		return err
+	}
+	r.container.updatedAt = codec.JoinTimestamp(r.updatedAt, nsec)
+	return nil
 }
 
 func (r *reader) readName() error {
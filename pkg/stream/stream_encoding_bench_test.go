@@ -0,0 +1,69 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+)
+
+type benchState []byte
+
+func (s *benchState) Mutate(*event.Event) {}
+
+func (s *benchState) MarshalBinary() ([]byte, error) {
+	return *s, nil
+}
+
+func (s *benchState) UnmarshalBinary(data []byte) error {
+	*s = append((*s)[:0], data...)
+	return nil
+}
+
+func benchStream() *Stream {
+	state := benchState(`{"One":"one","Two":"two"}`)
+	s := New("users", uuid.New(), &state)
+	s.version = 3
+	s.updatedAt = time.Unix(1634567890, 123456789)
+	return s
+}
+
+func BenchmarkStream_MarshalBinary(b *testing.B) {
+	s := benchStream()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.MarshalBinary(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStream_AppendBinary(b *testing.B) {
+	s := benchStream()
+	buf := make([]byte, 0, 256)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var err error
+		if buf, err = s.AppendBinary(buf[:0]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestStream_AppendBinary(t *testing.T) {
+	s := benchStream()
+	expected, err := s.MarshalBinary()
+	assert.NoError(t, err)
+	data, err := s.AppendBinary([]byte("prefix"))
+	assert.NoError(t, err)
+	assert.Equal(t, append([]byte("prefix"), expected...), data)
+
+	dst := make([]byte, 0, 256)
+	allocs := testing.AllocsPerRun(100, func() {
+		dst, _ = s.AppendBinary(dst[:0])
+	})
+	assert.Zero(t, allocs)
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
 	"github.com/go-gulfstream/gulfstream/pkg/event"
 
 	"github.com/google/uuid"
@@ -74,3 +75,21 @@ func (s *myState) MarshalBinary() ([]byte, error) {
 func (s *myState) UnmarshalBinary(data []byte) error {
 	return json.Unmarshal(data, s)
 }
+
+type plainState struct {
+	Names []string
+}
+
+func (s *plainState) Mutate(e *event.Event) {
+	s.Names = append(s.Names, e.Name())
+}
+
+func TestStream_JSONState(t *testing.T) {
+	stream1 := New("users", uuid.New(), JSONState(&plainState{}))
+	stream1.Mutate("created", nil)
+	data, err := stream1.MarshalBinary()
+	assert.NoError(t, err)
+	stream2 := Blank("users", JSONState(&plainState{}))
+	assert.NoError(t, stream2.UnmarshalBinary(data))
+	assert.Equal(t, []string{"created"}, codec.Unwrap(stream2.State()).(*plainState).Names)
+}
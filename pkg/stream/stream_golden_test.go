@@ -0,0 +1,102 @@
+package stream
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update the golden file of the current format version")
+
+func goldenStream() *Stream {
+	return &Stream{
+		id:        uuid.MustParse("6ba7b811-9dad-11d1-80b4-00c04fd430c8"),
+		name:      "users",
+		version:   3,
+		updatedAt: time.Unix(1634567890, 0),
+		state:     &myState{One: "one", Two: "two"},
+	}
+}
+
+func goldenFile(version uint8) string {
+	return filepath.Join("testdata", fmt.Sprintf("stream.v%d.golden", version))
+}
+
+func TestStream_GoldenFiles(t *testing.T) {
+	expected := goldenStream()
+	data, err := expected.MarshalBinary()
+	assert.NoError(t, err)
+	if *update {
+		assert.NoError(t, os.WriteFile(goldenFile(FormatVersion), data, 0644))
+	}
+	golden, err := os.ReadFile(goldenFile(FormatVersion))
+	assert.NoError(t, err)
+	assert.Equal(t, golden, data)
+
+	for version := uint8(0); version <= FormatVersion; version++ {
+		data, err := os.ReadFile(goldenFile(version))
+		assert.NoError(t, err)
+		s := Blank("users", &myState{})
+		if !assert.NoError(t, s.UnmarshalBinary(data), "version %d", version) {
+			continue
+		}
+		assert.Equal(t, expected.ID(), s.ID())
+		assert.Equal(t, expected.Name(), s.Name())
+		assert.Equal(t, expected.Version(), s.Version())
+		assert.Equal(t, expected.Unix(), s.Unix())
+		assert.Equal(t, expected.State(), s.State())
+	}
+}
+
+func TestStream_UnmarshalSkipsUnknownExtensions(t *testing.T) {
+	expected := goldenStream()
+	rawState, err := expected.state.MarshalBinary()
+	assert.NoError(t, err)
+	w := writer{
+		container:  expected,
+		payload:    rawState,
+		extensions: codec.Extensions{1000: []byte("unknown")},
+	}
+	data, err := w.write()
+	assert.NoError(t, err)
+	s := Blank("users", &myState{})
+	assert.NoError(t, s.UnmarshalBinary(data))
+	assert.Equal(t, expected.ID(), s.ID())
+	assert.Equal(t, expected.State(), s.State())
+}
+
+func TestStream_UnmarshalUnsupportedFormat(t *testing.T) {
+	golden, err := os.ReadFile(goldenFile(FormatVersion))
+	assert.NoError(t, err)
+	data := append([]byte(nil), golden...)
+	data[2] = FormatVersion + 1
+	assert.ErrorIs(t, Blank("users", &myState{}).UnmarshalBinary(data), ErrUnsupportedFormat)
+}
+
+func TestStream_UpdatedAtNanos(t *testing.T) {
+	expected := goldenStream()
+	expected.updatedAt = time.Unix(1634567890, 123456789)
+	data, err := expected.MarshalBinary()
+	assert.NoError(t, err)
+	s := Blank("users", &myState{})
+	assert.NoError(t, s.UnmarshalBinary(data))
+	assert.True(t, expected.UpdatedAt().Equal(s.UpdatedAt()))
+}
+
+func TestStream_UnmarshalOversizedExtensions(t *testing.T) {
+	s := goldenStream()
+	data, err := s.MarshalBinary()
+	assert.NoError(t, err)
+	offset := 2 + 1 + 2*4 + 16 + len(s.Name()) + 2*8
+	binary.LittleEndian.PutUint32(data[offset:], math.MaxUint32)
+	assert.ErrorIs(t, Blank("users", &myState{}).UnmarshalBinary(data), ErrInvalidInputData)
+}
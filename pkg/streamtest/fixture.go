@@ -0,0 +1,285 @@
+package streamtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/codec"
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+// Fixture runs the given/when/then scenarios against the real mutator
+// backed by the in-memory storage and the recording publisher.
+//
+//	streamtest.New(t, "users", newState, register).
+//		Given(created).
+//		When(rename).
+//		ThenEvents(streamtest.Emitted("renamed", &renamed{Name: "Bob"}))
+type Fixture struct {
+	t         testing.TB
+	ctx       context.Context
+	storage   *Storage
+	publisher *Recorder
+	mutator   *stream.Mutator
+	streamID  uuid.UUID
+	reply     *command.Reply
+	err       error
+	done      bool
+}
+
+// New creates the fixture of the stream. The register func adds
+// the controllers under test to the mutator.
+func New(
+	t testing.TB,
+	streamName string,
+	newState func() stream.State,
+	register func(m *stream.Mutator),
+	opts ...stream.MutatorOption,
+) *Fixture {
+	storage := NewStorage(streamName, newState)
+	publisher := NewRecorder()
+	mutator := stream.NewMutator(storage, publisher, opts...)
+	if register != nil {
+		register(mutator)
+	}
+	return &Fixture{
+		t:         t,
+		ctx:       context.Background(),
+		storage:   storage,
+		publisher: publisher,
+		mutator:   mutator,
+	}
+}
+
+func (f *Fixture) WithContext(ctx context.Context) *Fixture {
+	f.ctx = ctx
+	return f
+}
+
+func (f *Fixture) Mutator() *stream.Mutator {
+	return f.mutator
+}
+
+func (f *Fixture) Storage() *Storage {
+	return f.storage
+}
+
+func (f *Fixture) Publisher() *Recorder {
+	return f.publisher
+}
+
+// Given seeds the streams with the prior events.
+// The events are not published.
+func (f *Fixture) Given(events ...*event.Event) *Fixture {
+	f.storage.Append(events...)
+	if len(events) > 0 {
+		f.streamID = events[len(events)-1].StreamID()
+	}
+	return f
+}
+
+// When sends the command to the mutator.
+func (f *Fixture) When(cmd *command.Command) *Fixture {
+	f.publisher.Reset()
+	f.reply, f.err = f.mutator.CommandSink(f.ctx, cmd)
+	if f.err == nil && f.reply != nil {
+		f.err = f.reply.Err()
+	}
+	if !cmd.IsEmptyStreamID() {
+		f.streamID = cmd.StreamID()
+	}
+	f.pickStreamID()
+	f.done = true
+	return f
+}
+
+// WhenEvent sends the foreign event to the mutator.
+func (f *Fixture) WhenEvent(e *event.Event) *Fixture {
+	f.publisher.Reset()
+	f.reply = nil
+	f.err = f.mutator.EventSink(f.ctx, e)
+	f.pickStreamID()
+	f.done = true
+	return f
+}
+
+// Reply returns the reply of the last command.
+func (f *Fixture) Reply() *command.Reply {
+	return f.reply
+}
+
+// Err returns the error of the last command or event. The error
+// of the reply is returned as well.
+func (f *Fixture) Err() error {
+	return f.err
+}
+
+// Expected is the event expected to be emitted.
+type Expected struct {
+	Name    string
+	Payload codec.Codec
+}
+
+func Emitted(name string, payload codec.Codec) Expected {
+	return Expected{Name: name, Payload: payload}
+}
+
+// ThenEvents checks the names and the payloads of the published events in order.
+// The payloads are compared by value, the pointer fields are followed.
+func (f *Fixture) ThenEvents(expected ...Expected) *Fixture {
+	f.t.Helper()
+	if !f.checkWhen() {
+		return f
+	}
+	published := f.publisher.Events()
+	if eventsEqual(expected, published) {
+		return f
+	}
+	want := make([]string, 0, len(expected))
+	for _, e := range expected {
+		want = append(want, formatEvent(e.Name, e.Payload))
+	}
+	got := make([]string, 0, len(published))
+	for _, e := range published {
+		got = append(got, formatEvent(e.Name(), e.Payload()))
+	}
+	if !assert.ObjectsAreEqual(want, got) {
+		assert.Equal(f.t, want, got, "published events")
+		return f
+	}
+	// the payloads are formatted alike, e.g. the nil and the empty slices
+	for i, e := range expected {
+		want, got := unwrap(e.Payload), unwrap(published[i].Payload())
+		if !assert.ObjectsAreEqual(want, got) {
+			assert.Equal(f.t, want, got, "published event %d %s", i, e.Name)
+			break
+		}
+	}
+	return f
+}
+
+func (f *Fixture) ThenNoEvents() *Fixture {
+	f.t.Helper()
+	return f.ThenEvents()
+}
+
+// ThenError checks the error of the command or event by errors.Is.
+func (f *Fixture) ThenError(err error) *Fixture {
+	f.t.Helper()
+	if !f.checkWhen() {
+		return f
+	}
+	if f.err == nil {
+		f.t.Errorf("streamtest: expected error %q, got nil", err)
+		return f
+	}
+	if !errors.Is(f.err, err) {
+		f.t.Errorf("streamtest: expected error %q, got %q", err, f.err)
+	}
+	return f
+}
+
+// ThenErrorMessage checks the message of the error of the command or event,
+// e.g. of the reply error decoded without its type.
+func (f *Fixture) ThenErrorMessage(msg string) *Fixture {
+	f.t.Helper()
+	if !f.checkWhen() {
+		return f
+	}
+	if f.err == nil {
+		f.t.Errorf("streamtest: expected error %q, got nil", msg)
+		return f
+	}
+	assert.Equal(f.t, msg, f.err.Error(), "error")
+	return f
+}
+
+// ThenErrorAs checks that the error of the command or event is of the target type.
+func (f *Fixture) ThenErrorAs(target interface{}) *Fixture {
+	f.t.Helper()
+	if !f.checkWhen() {
+		return f
+	}
+	if f.err == nil || !errors.As(f.err, target) {
+		f.t.Errorf("streamtest: expected error of type %T, got %v", target, f.err)
+	}
+	return f
+}
+
+func (f *Fixture) ThenNoError() *Fixture {
+	f.t.Helper()
+	if !f.checkWhen() {
+		return f
+	}
+	if f.err != nil {
+		f.t.Errorf("streamtest: unexpected error: %v", f.err)
+	}
+	return f
+}
+
+// ThenState checks the state of the stream restored from all its events.
+func (f *Fixture) ThenState(expected stream.State) *Fixture {
+	f.t.Helper()
+	if !f.checkWhen() {
+		return f
+	}
+	s, err := f.storage.Load(f.ctx, f.streamID)
+	if err != nil {
+		f.t.Errorf("streamtest: %v", err)
+		return f
+	}
+	assert.Equal(f.t, expected, s.State(), "state of %s", s)
+	return f
+}
+
+// pickStreamID takes the stream checked by ThenState from the published
+// events, the command may have no stream id and the event is foreign.
+func (f *Fixture) pickStreamID() {
+	if published := f.publisher.Events(); len(published) > 0 {
+		f.streamID = published[len(published)-1].StreamID()
+	}
+}
+
+func (f *Fixture) checkWhen() bool {
+	f.t.Helper()
+	if !f.done {
+		f.t.Errorf("streamtest: When or WhenEvent is not called")
+	}
+	return f.done
+}
+
+func eventsEqual(expected []Expected, published []*event.Event) bool {
+	if len(expected) != len(published) {
+		return false
+	}
+	for i, e := range expected {
+		if e.Name != published[i].Name() {
+			return false
+		}
+		if !assert.ObjectsAreEqual(unwrap(e.Payload), unwrap(published[i].Payload())) {
+			return false
+		}
+	}
+	return true
+}
+
+func unwrap(payload codec.Codec) interface{} {
+	if payload == nil {
+		return nil
+	}
+	return codec.Unwrap(payload)
+}
+
+func formatEvent(name string, payload codec.Codec) string {
+	if payload == nil {
+		return name
+	}
+	return fmt.Sprintf("%s %+v", name, codec.Unwrap(payload))
+}
@@ -0,0 +1,171 @@
+package streamtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/command"
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+type created struct{ Name string }
+type renamed struct{ Name string }
+type rename struct{ Name string }
+
+func (p *created) MarshalBinary() ([]byte, error) { return nil, nil }
+func (p *created) UnmarshalBinary([]byte) error   { return nil }
+func (p *renamed) MarshalBinary() ([]byte, error) { return nil, nil }
+func (p *renamed) UnmarshalBinary([]byte) error   { return nil }
+func (p *rename) MarshalBinary() ([]byte, error)  { return nil, nil }
+func (p *rename) UnmarshalBinary([]byte) error    { return nil }
+
+type user struct {
+	Name    string
+	Renames int
+}
+
+func (s *user) Mutate(e *event.Event) {
+	switch p := e.Payload().(type) {
+	case *created:
+		s.Name = p.Name
+	case *renamed:
+		s.Name = p.Name
+		s.Renames++
+	}
+}
+
+func (s *user) MarshalBinary() ([]byte, error) { return nil, nil }
+func (s *user) UnmarshalBinary([]byte) error   { return nil }
+
+var errSameName = errors.New("same name")
+
+func newUserFixture(t testing.TB) *Fixture {
+	return New(t, "users", func() stream.State { return new(user) }, func(m *stream.Mutator) {
+		m.AddCommandController("rename",
+			stream.ControllerFunc(func(ctx context.Context, s *stream.Stream, c *command.Command) (*command.Reply, error) {
+				name := c.Payload().(*rename).Name
+				if s.State().(*user).Name == name {
+					return c.ReplyErr(errSameName), nil
+				}
+				s.Mutate("renamed", &renamed{Name: name})
+				return c.ReplyOk(s.Version()), nil
+			}))
+	})
+}
+
+func TestFixture(t *testing.T) {
+	id := uuid.New()
+	newUserFixture(t).
+		Given(event.New("created", "users", id, 1, &created{Name: "John"})).
+		When(command.New("rename", "users", id, &rename{Name: "Bob"})).
+		ThenNoError().
+		ThenEvents(Emitted("renamed", &renamed{Name: "Bob"})).
+		ThenState(&user{Name: "Bob", Renames: 1}).
+		When(command.New("rename", "users", id, &rename{Name: "Alice"})).
+		ThenEvents(Emitted("renamed", &renamed{Name: "Alice"})).
+		ThenState(&user{Name: "Alice", Renames: 2})
+
+	f := newUserFixture(t).
+		Given(event.New("created", "users", id, 1, &created{Name: "John"})).
+		When(command.New("rename", "users", id, &rename{Name: "John"})).
+		ThenError(errSameName).
+		ThenNoEvents().
+		ThenState(&user{Name: "John"})
+	assert.Equal(t, 0, f.Reply().StreamVersion())
+}
+
+func TestFixture_ReadableDiff(t *testing.T) {
+	rec := &recordingT{TB: t}
+	id := uuid.New()
+	newUserFixture(rec).
+		Given(event.New("created", "users", id, 1, &created{Name: "John"})).
+		When(command.New("rename", "users", id, &rename{Name: "Bob"})).
+		ThenEvents(Emitted("renamed", &renamed{Name: "Alice"}))
+	assert.Len(t, rec.errors, 1)
+	assert.Contains(t, rec.errors[0], `- (string) (len=21) "renamed &{Name:Alice}"`)
+	assert.Contains(t, rec.errors[0], `+ (string) (len=19) "renamed &{Name:Bob}"`)
+}
+
+func TestFixture_FieldDiff(t *testing.T) {
+	rec := &recordingT{TB: t}
+	id := uuid.New()
+	New(rec, "users", func() stream.State { return new(user) }, func(m *stream.Mutator) {
+		m.AddCommandController("tag",
+			stream.ControllerFunc(func(ctx context.Context, s *stream.Stream, c *command.Command) (*command.Reply, error) {
+				s.Mutate("tagged", &tagged{Tags: []string{}})
+				return c.ReplyOk(s.Version()), nil
+			}))
+	}).
+		Given(event.New("created", "users", id, 1, &created{Name: "John"})).
+		When(command.New("tag", "users", id, nil)).
+		ThenEvents(Emitted("tagged", &tagged{}))
+	assert.Len(t, rec.errors, 1)
+	assert.Contains(t, rec.errors[0], "published event 0 tagged")
+	assert.Contains(t, rec.errors[0], "Tags: ([]string) <nil>")
+}
+
+func TestFixture_ThenError(t *testing.T) {
+	id := uuid.New()
+	given := event.New("created", "users", id, 1, &created{Name: "John"})
+	newUserFixture(t).
+		Given(given).
+		When(command.New("rename", "users", id, &rename{Name: "John"})).
+		ThenError(errSameName).
+		ThenErrorMessage("same name")
+
+	rec := &recordingT{TB: t}
+	newUserFixture(rec).
+		Given(given).
+		When(command.New("rename", "users", id, &rename{Name: "John"})).
+		ThenError(errors.New("same name"))
+	assert.Len(t, rec.errors, 1, "the error with the same message does not match")
+}
+
+type tagged struct {
+	Name *string
+	Tags []string
+}
+
+func (p *tagged) MarshalBinary() ([]byte, error) { return nil, nil }
+func (p *tagged) UnmarshalBinary([]byte) error   { return nil }
+
+func TestFixture_PointerFieldsAndForeignEvent(t *testing.T) {
+	name := func(s string) *string { return &s }
+	userID := uuid.New()
+	New(t, "users", func() stream.State { return new(user) }, func(m *stream.Mutator) {
+		m.AddEventController("signedUp",
+			stream.EventControllerFunc(
+				func(e *event.Event) stream.Picker {
+					return stream.Picker{StreamID: userID}
+				},
+				func(ctx context.Context, s *stream.Stream, e *event.Event) error {
+					s.Mutate("created", &created{Name: "John"})
+					s.Mutate("tagged", &tagged{Name: name("John"), Tags: []string{"new"}})
+					return nil
+				}), stream.WithEventControllerCreateIfNotExists())
+	}).
+		Given(event.New("created", "users", uuid.New(), 1, &created{Name: "Bob"})).
+		WhenEvent(event.New("signedUp", "accounts", uuid.New(), 1, nil)).
+		ThenNoError().
+		ThenEvents(
+			Emitted("created", &created{Name: "John"}),
+			Emitted("tagged", &tagged{Name: name("John"), Tags: []string{"new"}}),
+		).
+		ThenState(&user{Name: "John"})
+}
+
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, strings.TrimSpace(fmt.Sprintf(format, args...)))
+}
@@ -0,0 +1,101 @@
+package streamtest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/go-gulfstream/gulfstream/pkg/event"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+)
+
+var _ stream.Storage = (*Storage)(nil)
+
+// Storage is the in-memory storage keeping the events of the streams.
+// The streams are restored from the events on load, so the states
+// need no binary encoding.
+type Storage struct {
+	mu         sync.RWMutex
+	streamName string
+	newState   func() stream.State
+	events     map[uuid.UUID][]*event.Event
+}
+
+func NewStorage(streamName string, newState func() stream.State) *Storage {
+	return &Storage{
+		streamName: streamName,
+		newState:   newState,
+		events:     make(map[uuid.UUID][]*event.Event),
+	}
+}
+
+func (s *Storage) StreamName() string {
+	return s.streamName
+}
+
+func (s *Storage) NewStream() *stream.Stream {
+	return stream.Blank(s.streamName, s.newState())
+}
+
+func (s *Storage) Persist(_ context.Context, ss *stream.Stream) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ss.Name() != s.streamName {
+		return fmt.Errorf("streamtest: different stream names got %s, expected %s",
+			ss.Name(), s.streamName)
+	}
+	if version := s.version(ss.ID()); version != ss.PreviousVersion() {
+		return fmt.Errorf("streamtest: %w: got v%d, expected v%d",
+			stream.ErrVersionConflict, version, ss.PreviousVersion())
+	}
+	s.events[ss.ID()] = append(s.events[ss.ID()], ss.Changes()...)
+	return nil
+}
+
+func (s *Storage) Load(_ context.Context, streamID uuid.UUID) (*stream.Stream, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	events, found := s.events[streamID]
+	if !found {
+		return nil, fmt.Errorf("streamtest: %s{StreamID:%s}: %w",
+			s.streamName, streamID, stream.ErrStreamNotFound)
+	}
+	ss := stream.New(s.streamName, streamID, s.newState())
+	for _, e := range events {
+		stream.RestoreFromEvent(ss, e)
+	}
+	return ss, nil
+}
+
+func (s *Storage) Drop(_ context.Context, streamID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.events, streamID)
+	return nil
+}
+
+// Append adds the events to the streams as if they were persisted before.
+func (s *Storage) Append(events ...*event.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range events {
+		s.events[e.StreamID()] = append(s.events[e.StreamID()], e)
+	}
+}
+
+// Events returns the persisted events of the stream.
+func (s *Storage) Events(streamID uuid.UUID) []*event.Event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]*event.Event(nil), s.events[streamID]...)
+}
+
+func (s *Storage) version(streamID uuid.UUID) int {
+	events := s.events[streamID]
+	if len(events) == 0 {
+		return 0
+	}
+	return events[len(events)-1].Version()
+}
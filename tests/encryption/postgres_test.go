@@ -0,0 +1,53 @@
+package encryption
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/encryption"
+	encryptionpostgres "github.com/go-gulfstream/gulfstream/pkg/encryption/postgres"
+	"github.com/go-gulfstream/gulfstream/tests"
+)
+
+func TestKeyStore_Postgres(t *testing.T) {
+	tests.SkipIfNotIntegration(t)
+
+	ctx := context.Background()
+	pool, err := pgxpool.Connect(ctx, tests.PostgresAddr)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer pool.Close()
+	if !assert.NoError(t, encryptionpostgres.CreateSchema(ctx, pool)) {
+		return
+	}
+
+	keys := encryptionpostgres.New(pool)
+	streamID := uuid.New()
+
+	_, err = keys.Key(ctx, "users", streamID)
+	assert.ErrorIs(t, err, encryption.ErrKeyNotFound)
+
+	key, err := keys.CreateKey(ctx, "users", streamID)
+	assert.NoError(t, err)
+	assert.Len(t, key, encryption.KeySize)
+
+	same, err := keys.CreateKey(ctx, "users", streamID)
+	assert.NoError(t, err)
+	assert.Equal(t, key, same)
+
+	assert.NoError(t, keys.DeleteKey(ctx, "users", streamID))
+	_, err = keys.Key(ctx, "users", streamID)
+	assert.ErrorIs(t, err, encryption.ErrKeyNotFound)
+	_, err = keys.CreateKey(ctx, "users", streamID)
+	assert.ErrorIs(t, err, encryption.ErrShredded, "the deleted key is never created again")
+
+	forgotten := uuid.New()
+	assert.NoError(t, keys.DeleteKey(ctx, "users", forgotten))
+	_, err = keys.CreateKey(ctx, "users", forgotten)
+	assert.ErrorIs(t, err, encryption.ErrShredded)
+}
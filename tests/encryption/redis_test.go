@@ -0,0 +1,50 @@
+package encryption
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-gulfstream/gulfstream/pkg/encryption"
+	encryptionredis "github.com/go-gulfstream/gulfstream/pkg/encryption/redis"
+	"github.com/go-gulfstream/gulfstream/tests"
+)
+
+func TestKeyStore_Redis(t *testing.T) {
+	tests.SkipIfNotIntegration(t)
+
+	conn := redis.NewClient(&redis.Options{
+		Addr: tests.RedisAddr,
+		DB:   0,
+	})
+	defer conn.Close()
+
+	ctx := context.Background()
+	keys := encryptionredis.New(conn)
+	streamID := uuid.New()
+
+	_, err := keys.Key(ctx, "users", streamID)
+	assert.ErrorIs(t, err, encryption.ErrKeyNotFound)
+
+	key, err := keys.CreateKey(ctx, "users", streamID)
+	assert.NoError(t, err)
+	assert.Len(t, key, encryption.KeySize)
+
+	same, err := keys.CreateKey(ctx, "users", streamID)
+	assert.NoError(t, err)
+	assert.Equal(t, key, same)
+
+	assert.NoError(t, keys.DeleteKey(ctx, "users", streamID))
+	_, err = keys.Key(ctx, "users", streamID)
+	assert.ErrorIs(t, err, encryption.ErrKeyNotFound)
+	_, err = keys.CreateKey(ctx, "users", streamID)
+	assert.ErrorIs(t, err, encryption.ErrShredded, "the deleted key is never created again")
+
+	forgotten := uuid.New()
+	assert.NoError(t, keys.DeleteKey(ctx, "users", forgotten))
+	_, err = keys.CreateKey(ctx, "users", forgotten)
+	assert.ErrorIs(t, err, encryption.ErrShredded)
+}
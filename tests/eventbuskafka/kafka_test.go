@@ -13,6 +13,8 @@ import (
 	"github.com/go-gulfstream/gulfstream/pkg/event"
 	"github.com/go-gulfstream/gulfstream/pkg/stream"
 
+	"github.com/go-gulfstream/gulfstream/pkg/eventbus"
+	"github.com/go-gulfstream/gulfstream/pkg/eventbus/eventbustest"
 	eventbuskafka "github.com/go-gulfstream/gulfstream/pkg/eventbus/kafka"
 	"github.com/go-gulfstream/gulfstream/tests"
 
@@ -85,3 +87,87 @@ func (s *KafkaSuite) TestPublishSubscriber() {
 	<-time.After(time.Second)
 	s.Equal(uint32(2), total)
 }
+
+func (s *KafkaSuite) TestStartPositionNewGroup() {
+	publisher := eventbuskafka.NewPublisher(s.addr, nil)
+	defer publisher.Close()
+	var err error
+	for i := 0; i < 7; i++ {
+		if err = publisher.Connect(); err == nil {
+			break
+		}
+		time.Sleep(time.Second)
+	}
+	s.Require().NoError(err)
+	streamID := uuid.New()
+	s.NoError(publisher.Publish([]*event.Event{event.New("before", s.topic, streamID, 1, nil)}))
+	time.Sleep(2 * time.Second)
+	since := time.Now()
+	s.NoError(publisher.Publish([]*event.Event{event.New("after", s.topic, streamID, 2, nil)}))
+
+	consume := func(position eventbuskafka.StartPosition) []string {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		received := make(chan string, 2)
+		sub := eventbuskafka.NewSubscriber(s.addr, nil,
+			eventbuskafka.WithSubscriberStartPosition(position))
+		defer sub.Close()
+		receive := func(ctx context.Context, e *event.Event) error {
+			received <- e.Name()
+			return nil
+		}
+		sub.Subscribe(s.topic,
+			eventbus.HandlerFunc("before", receive, nil),
+			eventbus.HandlerFunc("after", receive, nil))
+		go func() {
+			_ = sub.Listen(ctx)
+		}()
+		var names []string
+		timeout := time.After(30 * time.Second)
+		for len(names) < 2 {
+			select {
+			case name := <-received:
+				names = append(names, name)
+			case <-timeout:
+				return names
+			case <-time.After(5 * time.Second):
+				if len(names) > 0 {
+					return names
+				}
+			}
+		}
+		return names
+	}
+	s.Equal([]string{"before", "after"}, consume(eventbuskafka.FromOldest()),
+		"the new group starts from the oldest offset")
+	s.Equal([]string{"after"}, consume(eventbuskafka.FromTime(since)),
+		"the new group starts from the time")
+}
+
+func TestEventbus_KafkaConformance(t *testing.T) {
+	tests.SkipIfNotIntegration(t)
+
+	addr := []string{tests.KafkaAddr}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub := eventbuskafka.NewSubscriber(addr, nil)
+	defer sub.Close()
+	go sub.Listen(ctx)
+	pub := eventbuskafka.NewPublisher(addr, nil)
+	defer pub.Close()
+	var err error
+	for i := 0; i < 7; i++ {
+		if err = pub.Connect(); err == nil {
+			break
+		}
+		time.Sleep(time.Second)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eventbustest.Run(t, pub, sub,
+		eventbustest.WithSettle(5*time.Second),
+		eventbustest.WithTimeout(30*time.Second),
+	)
+}
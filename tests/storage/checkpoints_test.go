@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/stretchr/testify/assert"
+
+	storagepostgres "github.com/go-gulfstream/gulfstream/pkg/storage/postgres"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+	"github.com/go-gulfstream/gulfstream/tests"
+)
+
+func TestCheckpoints_Postgres(t *testing.T) {
+	tests.SkipIfNotIntegration(t)
+
+	ctx := context.Background()
+	pool, err := pgxpool.Connect(ctx, tests.PostgresAddr)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer pool.Close()
+	if !assert.NoError(t, storagepostgres.CreateSchema(ctx, pool)) {
+		return
+	}
+
+	checkpoints := storagepostgres.NewCheckpoints(pool, "users-view")
+	streamID := uuid.New()
+	version, err := checkpoints.LoadCheckpoint(ctx, streamName, streamID)
+	assert.NoError(t, err)
+	assert.Zero(t, version)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = checkpoints.SaveCheckpoint(ctx, streamName, streamID, 2)
+	}()
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	waiter := stream.NewCheckpointWaiter(checkpoints, 10*time.Millisecond)
+	assert.NoError(t, waiter.WaitForVersion(waitCtx, streamName, streamID, 2))
+
+	assert.NoError(t, checkpoints.SaveCheckpoint(ctx, streamName, streamID, 3))
+	version, err = checkpoints.LoadCheckpoint(ctx, streamName, streamID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, version)
+
+	assert.NoError(t, checkpoints.SaveCheckpoint(ctx, streamName, streamID, 1))
+	version, err = checkpoints.LoadCheckpoint(ctx, streamName, streamID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, version, "the checkpoint never moves back")
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/stretchr/testify/assert"
+
+	storagepostgres "github.com/go-gulfstream/gulfstream/pkg/storage/postgres"
+	storageredis "github.com/go-gulfstream/gulfstream/pkg/storage/redis"
+	"github.com/go-gulfstream/gulfstream/pkg/storage/storagetest"
+	"github.com/go-gulfstream/gulfstream/pkg/stream"
+	"github.com/go-gulfstream/gulfstream/tests"
+)
+
+func TestStorage_RedisConformance(t *testing.T) {
+	tests.SkipIfNotIntegration(t)
+
+	conn := redis.NewClient(&redis.Options{
+		Addr: tests.RedisAddr,
+		DB:   0,
+	})
+	defer conn.Close()
+
+	storagetest.Run(t, func(t *testing.T, streamName string, newState func() stream.State) stream.Storage {
+		return storageredis.New(conn, streamName, func() *stream.Stream {
+			return stream.Blank(streamName, newState())
+		})
+	})
+}
+
+func TestStorage_PostgresConformance(t *testing.T) {
+	tests.SkipIfNotIntegration(t)
+
+	ctx := context.Background()
+	pool, err := pgxpool.Connect(ctx, tests.PostgresAddr)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer pool.Close()
+	if !assert.NoError(t, storagepostgres.CreateSchema(ctx, pool)) {
+		return
+	}
+
+	storagetest.Run(t, func(t *testing.T, streamName string, newState func() stream.State) stream.Storage {
+		return storagepostgres.New(pool, streamName, func() *stream.Stream {
+			return stream.Blank(streamName, newState())
+		}, storagepostgres.WithJournal())
+	})
+}
@@ -1,10 +1,16 @@
 package storage
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 
+	storagepostgres "github.com/go-gulfstream/gulfstream/pkg/storage/postgres"
 	"github.com/go-gulfstream/gulfstream/tests"
 )
 
@@ -15,3 +21,53 @@ func TestStorage_Postgres(t *testing.T) {
 type PostgresSuite struct {
 	suite.Suite
 }
+
+func TestSchema_PostgresMigrateCreatedAt(t *testing.T) {
+	tests.SkipIfNotIntegration(t)
+
+	ctx := context.Background()
+	pool, err := pgxpool.Connect(ctx, tests.PostgresAddr)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer pool.Close()
+
+	// the journal created by the older versions keeps Unix seconds
+	for _, sql := range []string{
+		`CREATE SCHEMA IF NOT EXISTS gulfstream`,
+		`DROP TABLE IF EXISTS gulfstream.events`,
+		`CREATE TABLE gulfstream.events
+(
+    stream_id        uuid         NOT NULL,
+    stream_name      VARCHAR(128) NOT NULL,
+    event_name      VARCHAR(256) NOT NULL,
+    version    integer,
+    created_at BIGINT,
+    raw_data    bytea,
+    PRIMARY KEY (stream_name, stream_id, version)
+)`,
+	} {
+		if !assert.NoError(t, exec(ctx, pool, sql)) {
+			return
+		}
+	}
+	streamID := uuid.New()
+	createdAt := time.Unix(1600000000, 0)
+	assert.NoError(t, exec(ctx, pool,
+		`INSERT INTO gulfstream.events (stream_id, stream_name, event_name, version, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		streamID.String(), streamName, "created", 1, createdAt.Unix()))
+
+	assert.NoError(t, storagepostgres.CreateSchema(ctx, pool))
+	assert.NoError(t, storagepostgres.CreateSchema(ctx, pool), "the migration runs once")
+
+	var got time.Time
+	assert.NoError(t, pool.QueryRow(ctx,
+		`SELECT created_at FROM gulfstream.events WHERE stream_name=$1 AND stream_id=$2`,
+		streamName, streamID.String()).Scan(&got))
+	assert.True(t, createdAt.Equal(got), got)
+}
+
+func exec(ctx context.Context, pool *pgxpool.Pool, sql string, args ...interface{}) error {
+	_, err := pool.Exec(ctx, sql, args...)
+	return err
+}